@@ -8,50 +8,63 @@ import (
 
 	"github.com/Veraticus/cc-tools/internal/config"
 	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/lsp"
+	"github.com/Veraticus/cc-tools/internal/sandbox"
 )
 
 func main() {
+	// Sandboxed validation commands re-execute this binary as the sandbox helper
+	if len(os.Args) > 1 && os.Args[1] == sandbox.HelperCommand {
+		os.Exit(sandbox.Main(os.Args[2:]))
+	}
+	// The language server stage keeps servers warm in a daemon run by this binary
+	if len(os.Args) > 1 && os.Args[1] == lsp.DaemonCommand {
+		os.Exit(lsp.Main(os.Args[2:]))
+	}
+
 	debug := os.Getenv("CLAUDE_HOOKS_DEBUG") == "1"
-	timeoutSecs, cooldownSecs := loadValidateConfig()
+	validateCfg := loadValidateConfig()
 
-	exitCode := hooks.ValidateWithSkipCheck(
+	exitCode := hooks.ValidateWithConfig(
 		context.Background(),
 		os.Stdin,
 		os.Stdout,
 		os.Stderr,
 		debug,
-		timeoutSecs,
-		cooldownSecs,
+		validateCfg,
 	)
 	os.Exit(exitCode)
 }
 
-func loadValidateConfig() (int, int) {
-	timeoutSecs := 60
-	cooldownSecs := 5
+func loadValidateConfig() *config.ValidateConfig {
+	validateCfg := &config.ValidateConfig{
+		TimeoutSeconds:  60,
+		CooldownSeconds: 5,
+	}
 
 	// Try to load from config file
 	if cfg, err := config.Load(); err == nil {
+		validateCfg.ValidateOptions = cfg.Hooks.Validate.ValidateOptions
 		// Check if validate config exists
 		if cfg.Hooks.Validate.TimeoutSeconds > 0 {
-			timeoutSecs = cfg.Hooks.Validate.TimeoutSeconds
+			validateCfg.TimeoutSeconds = cfg.Hooks.Validate.TimeoutSeconds
 		}
 		if cfg.Hooks.Validate.CooldownSeconds > 0 {
-			cooldownSecs = cfg.Hooks.Validate.CooldownSeconds
+			validateCfg.CooldownSeconds = cfg.Hooks.Validate.CooldownSeconds
 		}
 	}
 
 	// Environment variables override config
 	if timeout := os.Getenv("CC_TOOLS_HOOKS_VALIDATE_TIMEOUT_SECONDS"); timeout != "" {
 		if val, err := strconv.Atoi(timeout); err == nil && val > 0 {
-			timeoutSecs = val
+			validateCfg.TimeoutSeconds = val
 		}
 	}
 	if cooldown := os.Getenv("CC_TOOLS_HOOKS_VALIDATE_COOLDOWN_SECONDS"); cooldown != "" {
 		if val, err := strconv.Atoi(cooldown); err == nil && val > 0 {
-			cooldownSecs = val
+			validateCfg.CooldownSeconds = val
 		}
 	}
 
-	return timeoutSecs, cooldownSecs
+	return validateCfg
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/output"
+)
+
+const minBaselineArgs = 3
+
+// runBaselineCommand handles the baseline command and its subcommands.
+func runBaselineCommand() {
+	out := output.NewTerminal(os.Stdout, os.Stderr)
+
+	if len(os.Args) < minBaselineArgs {
+		printBaselineUsage(out)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	var err error
+	switch os.Args[2] {
+	case "capture":
+		err = captureBaseline(ctx, out)
+	case "shrink":
+		err = shrinkBaseline(ctx, out)
+	case "status":
+		err = showBaselineStatus(out)
+	default:
+		out.Error("Unknown baseline subcommand: %s", os.Args[2])
+		printBaselineUsage(out)
+		os.Exit(1)
+	}
+	if err != nil {
+		out.Error("Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func printBaselineUsage(out *output.Terminal) {
+	out.RawError(`Usage: cc-tools baseline <subcommand>
+
+Subcommands:
+  capture   Run the lint command and record its current findings as the baseline
+  shrink    Drop baseline entries that have been fixed (never adds new ones)
+  status    Show the baseline of the current project
+
+With a baseline in place, validation only blocks on lint findings that are
+not in it. The baseline is stored in ` + hooks.BaselineFile + ` in the project root.
+
+Examples:
+  cc-tools baseline capture
+  cc-tools baseline shrink
+`)
+}
+
+// baselineProject returns the project root and validate configuration for the current directory.
+func baselineProject() (string, *config.ValidateConfig, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", nil, fmt.Errorf("get current directory: %w", err)
+	}
+	cfg := loadValidateConfig()
+	projectRoot, err := findProjectRoot(cwd, cfg.Root)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if data, readErr := os.ReadFile(filepath.Join(projectRoot, config.ProjectConfigFile)); readErr == nil {
+		if merged, mergeErr := cfg.WithProjectOverrides(data); mergeErr == nil {
+			cfg = &merged
+		}
+	}
+	return projectRoot, cfg, nil
+}
+
+func captureBaseline(ctx context.Context, out *output.Terminal) error {
+	projectRoot, cfg, err := baselineProject()
+	if err != nil {
+		return err
+	}
+
+	out.Info("Running lint in %s...", projectRoot)
+	cmd, diagnostics, err := hooks.CaptureLintDiagnostics(ctx, projectRoot, cfg, nil)
+	if err != nil {
+		return fmt.Errorf("capture lint findings: %w", err)
+	}
+
+	baseline := hooks.NewBaseline(cmd.String(), diagnostics)
+	if saveErr := baseline.Save(projectRoot, nil); saveErr != nil {
+		return saveErr
+	}
+	out.Success("✓ Recorded %d lint finding(s) from '%s' in %s", baseline.Size(), cmd.String(), hooks.BaselineFile)
+	return nil
+}
+
+func shrinkBaseline(ctx context.Context, out *output.Terminal) error {
+	projectRoot, cfg, err := baselineProject()
+	if err != nil {
+		return err
+	}
+
+	deps := hooks.NewDefaultDependencies()
+	baseline, err := hooks.LoadBaseline(projectRoot, deps)
+	if err != nil {
+		return fmt.Errorf("load baseline: %w", err)
+	}
+
+	out.Info("Running lint in %s...", projectRoot)
+	_, diagnostics, err := hooks.CaptureLintDiagnostics(ctx, projectRoot, cfg, deps)
+	if err != nil {
+		return fmt.Errorf("capture lint findings: %w", err)
+	}
+
+	removed := baseline.Shrink(diagnostics)
+	if saveErr := baseline.Save(projectRoot, deps); saveErr != nil {
+		return saveErr
+	}
+	out.Success("✓ Removed %d fixed finding(s); %d remain in %s", removed, baseline.Size(), hooks.BaselineFile)
+	return nil
+}
+
+func showBaselineStatus(out *output.Terminal) error {
+	projectRoot, _, err := baselineProject()
+	if err != nil {
+		return err
+	}
+
+	baseline, err := hooks.LoadBaseline(projectRoot, hooks.NewDefaultDependencies())
+	if errors.Is(err, hooks.ErrNoBaseline) {
+		out.Info("No lint baseline in %s", projectRoot)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load baseline: %w", err)
+	}
+
+	out.Info("Lint baseline for %s:", projectRoot)
+	out.Raw(fmt.Sprintf("  Command:  %s\n  Findings: %d in %d file/message pair(s)\n",
+		baseline.Command, baseline.Size(), len(baseline.Entries)))
+	return nil
+}
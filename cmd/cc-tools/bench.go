@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/output"
+)
+
+const minBenchArgs = 3
+
+// benchBaselineTimeoutSecs bounds a full benchmark run, which takes far
+// longer than the validation timeout allows.
+const benchBaselineTimeoutSecs = 30 * 60
+
+// runBenchCommand handles the bench command and its subcommands.
+func runBenchCommand() {
+	out := output.NewTerminal(os.Stdout, os.Stderr)
+
+	if len(os.Args) < minBenchArgs {
+		printBenchUsage(out)
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "baseline":
+		if err := captureBenchBaseline(context.Background(), out, os.Args[3:]); err != nil {
+			out.Error("Error: %v", err)
+			os.Exit(1)
+		}
+	default:
+		out.Error("Unknown bench subcommand: %s", os.Args[2])
+		printBenchUsage(out)
+		os.Exit(1)
+	}
+}
+
+func printBenchUsage(out *output.Terminal) {
+	out.RawError(`Usage: cc-tools bench <subcommand>
+
+Subcommands:
+  baseline [packages]   Run the benchmarks of HEAD and store them as its baseline
+
+The benchmarks run in a temporary worktree of HEAD, so uncommitted changes are
+not measured. With packages, only their results are replaced in the baseline
+of HEAD. With validate.bench.enabled, validation compares the benchmarks
+exercising an edited Go file with the baseline of the nearest commit.
+
+Examples:
+  cc-tools bench baseline
+  cc-tools bench baseline ./internal/parser
+`)
+}
+
+func captureBenchBaseline(ctx context.Context, out *output.Terminal, packages []string) error {
+	projectRoot, cfg, err := baselineProject()
+	if err != nil {
+		return err
+	}
+
+	out.Info("Running benchmarks of HEAD in %s...", projectRoot)
+	baseline, err := hooks.CaptureBenchBaseline(ctx, projectRoot, cfg.Bench, benchBaselineTimeoutSecs, packages, nil)
+	if err != nil {
+		return fmt.Errorf("capture benchmark baseline: %w", err)
+	}
+
+	count := 0
+	for _, benchmarks := range baseline.Packages {
+		count += len(benchmarks)
+	}
+	out.Success("✓ Recorded %d benchmark(s) in %d package(s) as the baseline of %s",
+		count, len(baseline.Packages), baseline.Commit)
+	return nil
+}
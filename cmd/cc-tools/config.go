@@ -83,6 +83,8 @@ Subcommands:
 Configuration Keys:
   validate.timeout    Timeout for validation commands (seconds)
   validate.cooldown   Cooldown between validation runs (seconds)
+  validate.on_busy    What to do when validation is already running (drop, queue, supersede)
+  validate.lock_dir   Directory for lock files (default: $XDG_RUNTIME_DIR/cc-tools/locks)
   statusline.workspace    Custom workspace label
   statusline.cache_dir    Cache directory path
   statusline.cache_seconds    Cache duration
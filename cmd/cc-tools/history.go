@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/output"
+)
+
+const (
+	statsCommand        = "stats"
+	defaultHistoryLimit = 20
+	maxMostFailing      = 5
+	historyPrecision    = 100 * time.Millisecond
+)
+
+// historyArgs are the parsed arguments of the history command.
+type historyArgs struct {
+	subcommand string
+	filter     hooks.HistoryFilter
+	limit      int
+}
+
+// runHistoryCommand handles the history command and its subcommands.
+func runHistoryCommand() {
+	out := output.NewTerminal(os.Stdout, os.Stderr)
+	cfg := loadValidateConfig()
+
+	args, err := parseHistoryArgs(os.Args[2:], cfg.Root)
+	if err != nil {
+		out.Error("Error: %v", err)
+		printHistoryUsage(out)
+		os.Exit(1)
+	}
+
+	switch args.subcommand {
+	case helpCommand:
+		printHistoryUsage(out)
+		return
+	case listCommand, statsCommand:
+	default:
+		out.Error("Unknown history subcommand: %s", args.subcommand)
+		printHistoryUsage(out)
+		os.Exit(1)
+	}
+
+	records, err := hooks.NewHistory(cfg.History, nil).Load()
+	if err != nil {
+		out.Error("Error: %v", err)
+		os.Exit(1)
+	}
+	records = hooks.FilterHistory(records, args.filter)
+
+	if args.subcommand == statsCommand {
+		showHistoryStats(out, records)
+		return
+	}
+	listHistory(out, records, args.limit)
+}
+
+func printHistoryUsage(out *output.Terminal) {
+	out.RawError(`Usage: cc-tools history [subcommand] [flags]
+
+Subcommands:
+  list     Show the most recent validation runs (default)
+  stats    Show pass rate, duration percentiles and the most failing commands
+
+Flags:
+  --project DIR        Only runs of the project containing DIR
+  --session ID         Only runs of a Claude Code session
+  --status pass|fail|skipped
+                       Only passing or failing runs, or invocations that did not validate
+  --limit N            Number of runs to list (default: 20)
+
+Examples:
+  cc-tools history
+  cc-tools history --project . --status fail
+  cc-tools history stats --project .
+`)
+}
+
+func parseHistoryArgs(args []string, rootCfg config.RootConfig) (historyArgs, error) {
+	parsed := historyArgs{subcommand: listCommand, limit: defaultHistoryLimit}
+	if len(args) > 0 && !strings.HasPrefix(args[0], "--") {
+		parsed.subcommand = args[0]
+		args = args[1:]
+	}
+
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		if flag == helpFlag || flag == "-h" {
+			parsed.subcommand = helpCommand
+			return parsed, nil
+		}
+		if i+1 >= len(args) {
+			return parsed, fmt.Errorf("%s requires a value", flag)
+		}
+		value := args[i+1]
+		i++
+
+		switch flag {
+		case "--project":
+			abs, err := filepath.Abs(value)
+			if err != nil {
+				return parsed, fmt.Errorf("resolve %s: %w", value, err)
+			}
+			root, err := findProjectRoot(abs, rootCfg)
+			if err != nil {
+				return parsed, err
+			}
+			parsed.filter.Project = root
+		case "--session":
+			parsed.filter.Session = value
+		case "--status":
+			if value != hooks.HistoryStatusPass && value != hooks.HistoryStatusFail &&
+				value != hooks.HistoryStatusSkipped {
+				return parsed, fmt.Errorf("invalid status %q: want %s, %s or %s",
+					value, hooks.HistoryStatusPass, hooks.HistoryStatusFail, hooks.HistoryStatusSkipped)
+			}
+			parsed.filter.Status = value
+		case "--limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				return parsed, fmt.Errorf("invalid limit %q", value)
+			}
+			parsed.limit = limit
+		default:
+			return parsed, fmt.Errorf("unknown flag %s", flag)
+		}
+	}
+	return parsed, nil
+}
+
+func listHistory(out *output.Terminal, records []hooks.HistoryRecord, limit int) {
+	if len(records) == 0 {
+		out.Info("No validation runs recorded")
+		return
+	}
+
+	start := max(len(records)-limit, 0)
+	var b strings.Builder
+	for i := len(records) - 1; i >= start; i-- {
+		record := records[i]
+		status := "pass"
+		switch {
+		case record.Skipped != "":
+			status = "skip"
+		case !record.Passed:
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s  %s  %6s  %s  %s\n",
+			record.Time.Local().Format(time.DateTime), status, formatHistoryDuration(record.Duration()),
+			filepath.Base(record.Project), record.File)
+		if record.Skipped != "" {
+			fmt.Fprintf(&b, "    %s\n", describeHistorySkip(record.Skipped))
+		}
+		for _, cmd := range record.Commands {
+			fmt.Fprintf(&b, "    %-4s %s\n", cmd.Type, describeHistoryCommand(cmd))
+		}
+	}
+	out.Raw(b.String())
+}
+
+// describeHistoryCommand summarizes the outcome of one command of a run.
+func describeHistoryCommand(cmd hooks.HistoryCommand) string {
+	switch {
+	case cmd.Skipped:
+		return "skipped"
+	case cmd.Cached:
+		return fmt.Sprintf("%s (cached) %s", commandOutcome(cmd), cmd.Command)
+	default:
+		return fmt.Sprintf("%s in %s  %s", commandOutcome(cmd), formatHistoryDuration(cmd.Duration()), cmd.Command)
+	}
+}
+
+// describeHistorySkip explains why an invocation did not validate.
+func describeHistorySkip(reason string) string {
+	switch reason {
+	case hooks.HistorySkipChecks:
+		return "lint and test skipped for this file"
+	case hooks.HistorySkipBusy:
+		return "another run was in progress or cooling down"
+	case hooks.HistorySkipQueued:
+		return "queued behind another run"
+	default:
+		return "skipped: " + reason
+	}
+}
+
+func commandOutcome(cmd hooks.HistoryCommand) string {
+	if cmd.Success {
+		return "passed"
+	}
+	return fmt.Sprintf("failed (exit %d)", cmd.ExitCode)
+}
+
+func showHistoryStats(out *output.Terminal, records []hooks.HistoryRecord) {
+	stats := hooks.SummarizeHistory(records)
+	if stats.Runs == 0 && stats.Skipped == 0 {
+		out.Info("No validation runs recorded")
+		return
+	}
+
+	out.Info("%d runs, %.1f%% passed, %d skipped", stats.Runs, stats.PassRate(), stats.Skipped)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%-4s  %5s  %5s  %6s  %8s  %8s  %s\n", "TYPE", "RUNS", "FAILS", "CACHED", "P50", "P95", "COMMAND")
+	for _, cmd := range stats.Commands {
+		fmt.Fprintf(&b, "%-4s  %5d  %5d  %6d  %8s  %8s  %s\n", cmd.Type, cmd.Runs, cmd.Failures, cmd.Cached,
+			formatHistoryDuration(cmd.P50), formatHistoryDuration(cmd.P95), cmd.Command)
+	}
+
+	if failing := stats.MostFailing(); len(failing) > 0 {
+		b.WriteString("\nMost failing:\n")
+		for _, cmd := range failing[:min(len(failing), maxMostFailing)] {
+			fmt.Fprintf(&b, "  %d/%d  %s  %s\n", cmd.Failures, cmd.Runs, cmd.Type, cmd.Command)
+		}
+	}
+	out.Raw(b.String())
+}
+
+func formatHistoryDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(historyPrecision).String()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/output"
+)
+
+const (
+	clearCommand = "clear"
+	allFlag      = "--all"
+)
+
+// runLocksCommand handles the locks command and its subcommands.
+func runLocksCommand() {
+	out := output.NewTerminal(os.Stdout, os.Stderr)
+	cfg := loadValidateConfig()
+	lockDir := cfg.LockDir
+
+	subcommand := listCommand
+	if len(os.Args) > 2 {
+		subcommand = os.Args[2]
+	}
+
+	switch subcommand {
+	case listCommand:
+		if err := listLocks(out, lockDir); err != nil {
+			out.Error("Error: %v", err)
+			os.Exit(1)
+		}
+	case clearCommand:
+		if err := clearLocks(out, lockDir, cfg.Root, os.Args[3:]); err != nil {
+			out.Error("Error: %v", err)
+			os.Exit(1)
+		}
+	case helpCommand, "-h", helpFlag:
+		printLocksUsage(out)
+	default:
+		out.Error("Unknown locks subcommand: %s", subcommand)
+		printLocksUsage(out)
+		os.Exit(1)
+	}
+}
+
+func printLocksUsage(out *output.Terminal) {
+	out.RawError(`Usage: cc-tools locks [subcommand]
+
+Subcommands:
+  list               Show lock files, their holders and remaining cooldown (default)
+  clear [dir]        Force-clear the locks of the project containing dir (default: current directory)
+  clear --all        Force-clear every lock
+
+Examples:
+  cc-tools locks
+  cc-tools locks clear
+  cc-tools locks clear --all
+`)
+}
+
+func listLocks(out *output.Terminal, lockDir string) error {
+	locks, err := hooks.ListLocks(lockDir, nil)
+	if err != nil {
+		return fmt.Errorf("list locks: %w", err)
+	}
+
+	if lockDir == "" {
+		lockDir = hooks.DefaultLockDir()
+	}
+	if len(locks) == 0 {
+		out.Info("No locks in %s", lockDir)
+		return nil
+	}
+
+	out.Info("Locks in %s:", lockDir)
+	for _, lock := range locks {
+		project := lock.Project
+		if project == "" {
+			project = "(unknown project)"
+		}
+
+		var status string
+		switch {
+		case lock.Held && lock.PID != 0:
+			status = fmt.Sprintf("held by pid %d", lock.PID)
+			if !lock.StartedAt.IsZero() {
+				status += fmt.Sprintf(" for %s", time.Since(lock.StartedAt).Round(time.Second))
+			}
+		case lock.Held:
+			status = "held"
+		case lock.CooldownRemaining > 0:
+			status = fmt.Sprintf("idle, cooldown %s remaining", lock.CooldownRemaining.Round(time.Second))
+		default:
+			status = "idle"
+		}
+
+		out.Raw(fmt.Sprintf("  %-10s %s\n             %s\n", lock.Hook, project, status))
+	}
+	return nil
+}
+
+func clearLocks(out *output.Terminal, lockDir string, rootCfg config.RootConfig, args []string) error {
+	locks, err := hooks.ListLocks(lockDir, nil)
+	if err != nil {
+		return fmt.Errorf("list locks: %w", err)
+	}
+
+	var paths []string
+	if len(args) > 0 && args[0] == allFlag {
+		for _, lock := range locks {
+			paths = append(paths, lock.Path)
+		}
+	} else {
+		dir := ""
+		if len(args) > 0 {
+			dir = args[0]
+		} else if dir, err = os.Getwd(); err != nil {
+			return fmt.Errorf("get current directory: %w", err)
+		}
+		projectRoot, rootErr := findProjectRoot(dir, rootCfg)
+		if rootErr != nil {
+			return rootErr
+		}
+		paths = hooks.LockPathsForProject(locks, projectRoot)
+	}
+
+	if len(paths) == 0 {
+		out.Info("No locks to clear")
+		return nil
+	}
+	for _, path := range paths {
+		if clearErr := hooks.ClearLock(path, nil); clearErr != nil {
+			return fmt.Errorf("clear %s: %w", path, clearErr)
+		}
+	}
+	out.Success("✓ Cleared %d lock(s)", len(paths))
+	return nil
+}
@@ -12,7 +12,9 @@ import (
 
 	"github.com/Veraticus/cc-tools/internal/config"
 	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/lsp"
 	"github.com/Veraticus/cc-tools/internal/output"
+	"github.com/Veraticus/cc-tools/internal/sandbox"
 	"github.com/Veraticus/cc-tools/internal/shared"
 	"github.com/Veraticus/cc-tools/internal/statusline"
 )
@@ -27,6 +29,15 @@ const (
 var version = "dev"
 
 func main() {
+	// Sandboxed validation commands re-execute this binary as the sandbox helper
+	if len(os.Args) > 1 && os.Args[1] == sandbox.HelperCommand {
+		os.Exit(sandbox.Main(os.Args[2:]))
+	}
+	// The language server stage keeps servers warm in a daemon run by this binary
+	if len(os.Args) > 1 && os.Args[1] == lsp.DaemonCommand {
+		os.Exit(lsp.Main(os.Args[2:]))
+	}
+
 	out := output.NewTerminal(os.Stdout, os.Stderr)
 
 	// Debug logging - log all invocations to a file
@@ -52,6 +63,16 @@ func main() {
 		runMCPCommand()
 	case "config":
 		runConfigCommand()
+	case "locks":
+		runLocksCommand()
+	case "baseline":
+		runBaselineCommand()
+	case "bench":
+		runBenchCommand()
+	case "root":
+		runRootCommand()
+	case "history":
+		runHistoryCommand()
 	case "version":
 		// Print version to stdout as intended output
 		out.Raw(fmt.Sprintf("cc-tools %s\n", version))
@@ -78,6 +99,11 @@ Commands:
   debug         Configure debug logging for directories
   mcp           Manage Claude MCP servers
   config        Manage configuration settings
+  locks         Inspect and clear hook locks
+  baseline      Record known lint findings so only new ones block
+  bench         Record benchmark baselines for the regression guard
+  root          Show the project root of a path and why it was chosen
+  history       Show recent validation runs and their statistics
   version       Print version information
   help          Show this help message
 
@@ -86,6 +112,7 @@ Examples:
   echo '{"file_path": "main.go"}' | cc-tools validate
   cc-tools mcp list
   cc-tools mcp enable jira
+  cc-tools locks
 `)
 }
 
@@ -113,48 +140,50 @@ func runStatusline() {
 	out.Raw(result)
 }
 
-func loadValidateConfig() (int, int) {
-	timeoutSecs := 60
-	cooldownSecs := 5
+func loadValidateConfig() *config.ValidateConfig {
+	validateCfg := &config.ValidateConfig{
+		TimeoutSeconds:  60,
+		CooldownSeconds: 5,
+	}
 
 	// Load configuration
 	cfg, _ := config.Load()
 	if cfg != nil {
+		validateCfg.ValidateOptions = cfg.Hooks.Validate.ValidateOptions
 		if cfg.Hooks.Validate.TimeoutSeconds > 0 {
-			timeoutSecs = cfg.Hooks.Validate.TimeoutSeconds
+			validateCfg.TimeoutSeconds = cfg.Hooks.Validate.TimeoutSeconds
 		}
 		if cfg.Hooks.Validate.CooldownSeconds > 0 {
-			cooldownSecs = cfg.Hooks.Validate.CooldownSeconds
+			validateCfg.CooldownSeconds = cfg.Hooks.Validate.CooldownSeconds
 		}
 	}
 
 	// Environment variables override config
 	if envTimeout := os.Getenv("CC_TOOLS_HOOKS_VALIDATE_TIMEOUT_SECONDS"); envTimeout != "" {
 		if val, err := strconv.Atoi(envTimeout); err == nil && val > 0 {
-			timeoutSecs = val
+			validateCfg.TimeoutSeconds = val
 		}
 	}
 	if envCooldown := os.Getenv("CC_TOOLS_HOOKS_VALIDATE_COOLDOWN_SECONDS"); envCooldown != "" {
 		if val, err := strconv.Atoi(envCooldown); err == nil && val >= 0 {
-			cooldownSecs = val
+			validateCfg.CooldownSeconds = val
 		}
 	}
 
-	return timeoutSecs, cooldownSecs
+	return validateCfg
 }
 
 func runValidate() {
-	timeoutSecs, cooldownSecs := loadValidateConfig()
+	validateCfg := loadValidateConfig()
 	debug := os.Getenv("CLAUDE_HOOKS_DEBUG") == "1"
 
-	exitCode := hooks.ValidateWithSkipCheck(
+	exitCode := hooks.ValidateWithConfig(
 		context.Background(),
 		os.Stdin,
 		os.Stdout,
 		os.Stderr,
 		debug,
-		timeoutSecs,
-		cooldownSecs,
+		validateCfg,
 	)
 	os.Exit(exitCode)
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/hooks"
+	"github.com/Veraticus/cc-tools/internal/output"
+	"github.com/Veraticus/cc-tools/internal/shared"
+)
+
+// runRootCommand explains which project root a path resolves to.
+func runRootCommand() {
+	out := output.NewTerminal(os.Stdout, os.Stderr)
+
+	path := ""
+	if len(os.Args) > 2 {
+		path = os.Args[2]
+	}
+	if path == helpFlag || path == "-h" {
+		printRootUsage(out)
+		return
+	}
+
+	if err := explainRoot(out, path, loadValidateConfig().Root); err != nil {
+		out.Error("Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func printRootUsage(out *output.Terminal) {
+	out.RawError(`Usage: cc-tools root [path]
+
+Show the project root for path (default: current directory) and which
+detection strategy chose it. Strategies are tried in the order configured
+in validate.root.strategies; the first that matches wins.
+
+Examples:
+  cc-tools root
+  cc-tools root src/main.go
+`)
+}
+
+func explainRoot(out *output.Terminal, path string, rootCfg config.RootConfig) error {
+	startDir, err := rootStartDir(path)
+	if err != nil {
+		return err
+	}
+
+	opts := hooks.ProjectRootOptions(rootCfg, "")
+	matches, err := shared.EvaluateRootStrategies(startDir, opts, nil)
+	if err != nil {
+		return fmt.Errorf("evaluate root strategies: %w", err)
+	}
+	root, err := shared.DetectProjectRoot(startDir, opts, nil)
+	if err != nil {
+		return fmt.Errorf("find project root: %w", err)
+	}
+
+	out.Info("Project root: %s", root.Dir)
+	if root.Strategy == "" {
+		out.Raw(fmt.Sprintf("  %s\n", root.Reason))
+	}
+
+	var b strings.Builder
+	chosen := false
+	for _, match := range matches {
+		mark := " "
+		switch {
+		case match.Matched && !chosen:
+			mark = "✓"
+			chosen = true
+		case match.Matched:
+			mark = "·"
+		}
+		fmt.Fprintf(&b, "  %s %-12s %s\n", mark, match.Strategy, match.Reason)
+	}
+	out.Raw(b.String())
+	return nil
+}
+
+// rootStartDir resolves the directory root detection starts from.
+func rootStartDir(path string) (string, error) {
+	if path == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("get current directory: %w", err)
+		}
+		return dir, nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", path, err)
+	}
+	if info, statErr := os.Stat(abs); statErr == nil && !info.IsDir() {
+		return filepath.Dir(abs), nil
+	}
+	return abs, nil
+}
+
+// findProjectRoot resolves the project root of dir with the configured strategies.
+func findProjectRoot(dir string, rootCfg config.RootConfig) (string, error) {
+	root, err := shared.DetectProjectRoot(dir, hooks.ProjectRootOptions(rootCfg, ""), nil)
+	if err != nil {
+		return "", fmt.Errorf("find project root: %w", err)
+	}
+	return root.Dir, nil
+}
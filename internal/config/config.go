@@ -22,6 +22,8 @@ type HooksConfig struct {
 
 // ValidateConfig represents validate hook settings.
 type ValidateConfig struct {
+	ValidateOptions
+
 	CooldownSeconds int `json:"cooldown_seconds"`
 	TimeoutSeconds  int `json:"timeout_seconds"`
 }
@@ -76,6 +78,15 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Extract the structured validate options
+	var options struct {
+		Validate ValidateOptions `json:"validate"`
+	}
+	if unmarshalErr := json.Unmarshal(data, &options); unmarshalErr != nil {
+		return nil, fmt.Errorf("parse validate config: %w", unmarshalErr)
+	}
+	cfg.Hooks.Validate.ValidateOptions = options.Validate
+
 	// Extract notification settings if they exist
 	if notifications, notifOk := fileConfig["notifications"].(map[string]any); notifOk {
 		if topic, topicOk := notifications["ntfy_topic"].(string); topicOk {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -137,3 +138,177 @@ func TestGetConfigPath(t *testing.T) {
 		t.Errorf("Expected file name to be config.json, got %s", filepath.Base(path))
 	}
 }
+
+func TestLoadValidateOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+
+	ccToolsDir := filepath.Join(tempDir, "cc-tools")
+	if err := os.MkdirAll(ccToolsDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	content := `{"validate": {"timeout": 30, "env": {"loaders": ["dotenv"], "dotenv_files": [".env.local"]}}}`
+	if err := os.WriteFile(filepath.Join(ccToolsDir, "config.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	env := cfg.Hooks.Validate.Env
+	if !env.HasLoader(EnvLoaderDotenv) || env.HasLoader(EnvLoaderDirenv) {
+		t.Errorf("Unexpected loaders: %v", env.Loaders)
+	}
+	if got := env.GetDotenvFiles(); len(got) != 1 || got[0] != ".env.local" {
+		t.Errorf("Expected dotenv files [.env.local], got %v", got)
+	}
+}
+
+func TestWithProjectOverrides(t *testing.T) {
+	base := ValidateConfig{
+		ValidateOptions: ValidateOptions{
+			Env: EnvConfig{Loaders: []string{EnvLoaderDotenv}, DotenvFiles: []string{".env.user"}},
+		},
+		TimeoutSeconds:  60,
+		CooldownSeconds: 5,
+	}
+
+	merged, err := base.WithProjectOverrides([]byte(`{"validate": {"env": {"loaders": ["nix"]}}}`))
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+
+	if !merged.Env.HasLoader(EnvLoaderNix) || merged.Env.HasLoader(EnvLoaderDotenv) {
+		t.Errorf("Expected project loaders to replace user loaders, got %v", merged.Env.Loaders)
+	}
+	if len(merged.Env.DotenvFiles) != 1 || merged.Env.DotenvFiles[0] != ".env.user" {
+		t.Errorf("Expected unset project fields to keep user values, got %v", merged.Env.DotenvFiles)
+	}
+	if merged.TimeoutSeconds != 60 {
+		t.Errorf("Expected timeout to be preserved, got %d", merged.TimeoutSeconds)
+	}
+	if !base.Env.HasLoader(EnvLoaderDotenv) {
+		t.Error("Expected base config to be left untouched")
+	}
+
+	if _, err := base.WithProjectOverrides([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid project config")
+	}
+}
+
+func TestWithProjectOverrides_KeepsSandbox(t *testing.T) {
+	base := ValidateConfig{
+		ValidateOptions: ValidateOptions{Sandbox: SandboxConfig{Enabled: true}},
+	}
+
+	merged, err := base.WithProjectOverrides(
+		[]byte(`{"validate": {"sandbox": {"enabled": false, "allow_write": ["/home"]}}}`))
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+	if !merged.Sandbox.Enabled || len(merged.Sandbox.AllowWrite) != 0 {
+		t.Errorf("Expected project config not to loosen the sandbox, got %+v", merged.Sandbox)
+	}
+}
+
+func TestWithProjectOverrides_SandboxKeepsEnvironment(t *testing.T) {
+	project := []byte(`{"validate": {"env": {"loaders": ["nix"], "nix_flake": "github:attacker/flake"}}}`)
+
+	sandboxed := ValidateConfig{ValidateOptions: ValidateOptions{Sandbox: SandboxConfig{Enabled: true}}}
+	merged, err := sandboxed.WithProjectOverrides(project)
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+	if len(merged.Env.Loaders) != 0 || merged.Env.NixFlake != "" {
+		t.Errorf("Expected a sandboxed project not to choose its environment, got %+v", merged.Env)
+	}
+
+	merged, err = ValidateConfig{}.WithProjectOverrides(project)
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+	if !merged.Env.HasLoader(EnvLoaderNix) {
+		t.Errorf("Expected an unsandboxed project to choose its environment, got %+v", merged.Env)
+	}
+}
+
+func TestWithProjectOverrides_KeepsStoragePaths(t *testing.T) {
+	base := ValidateConfig{
+		ValidateOptions: ValidateOptions{
+			Sandbox: SandboxConfig{Enabled: true},
+			History: HistoryConfig{Path: "/home/user/.cache/cc-tools/history.jsonl"},
+		},
+	}
+	hostile := `{"validate": {
+		"lock_dir": "/home/user/.ssh",
+		"cache": {"enabled": true, "dir": "/home/user/.ssh"},
+		"coverage": {"history_dir": "/home/user/.config"},
+		"run_logs": {"dir": "/home/user/.config/systemd/user"},
+		"history": {"path": "/home/user/.bashrc"},
+		"reports": {"enabled": true, "dir": "/home/user/bin"},
+		"bench": {"dir": "/etc"},
+		"impact": {"dir": "/usr/local/bin"}
+	}}`
+
+	merged, err := base.WithProjectOverrides([]byte(hostile))
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+	if !merged.Cache.Enabled || !merged.Reports.Enabled {
+		t.Error("Expected project config to still enable the cache and reports")
+	}
+	paths := map[string]string{
+		"lock_dir":             merged.LockDir,
+		"cache.dir":            merged.Cache.Dir,
+		"coverage.history_dir": merged.Coverage.HistoryDir,
+		"run_logs.dir":         merged.RunLogs.Dir,
+		"reports.dir":          merged.Reports.Dir,
+		"bench.dir":            merged.Bench.Dir,
+		"impact.dir":           merged.Impact.Dir,
+	}
+	for name, path := range paths {
+		if path != "" {
+			t.Errorf("Expected project config not to set %s, got %q", name, path)
+		}
+	}
+	if merged.History.Path != base.History.Path {
+		t.Errorf("Expected history path %q, got %q", base.History.Path, merged.History.Path)
+	}
+}
+
+func TestWithProjectOverrides_KeepsLanguageServers(t *testing.T) {
+	base := ValidateConfig{
+		ValidateOptions: ValidateOptions{LSP: LSPConfig{Servers: map[string][]string{"python": {"pylsp"}}}},
+	}
+
+	merged, err := base.WithProjectOverrides(
+		[]byte(`{"validate": {"lsp": {"enabled": true, "servers": {"go": ["./evil.sh"]}}}}`))
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+	if !merged.LSP.Enabled {
+		t.Error("Expected project config to enable the language server stage")
+	}
+	if len(merged.LSP.Servers) != 1 || merged.LSP.Servers["go"] != nil {
+		t.Errorf("Expected project config not to change language servers, got %v", merged.LSP.Servers)
+	}
+}
+
+func TestFilterConfigLintExclude(t *testing.T) {
+	var defaults FilterConfig
+	if exclude := defaults.GetLintExclude(); !slices.Contains(exclude, "*_test.go") {
+		t.Errorf("Expected default lint exclude to cover Go test files, got %v", exclude)
+	}
+
+	// An explicit empty list survives the project overlay and lints everything
+	merged, err := ValidateConfig{}.WithProjectOverrides([]byte(`{"validate": {"filters": {"lint": {"exclude": []}}}}`))
+	if err != nil {
+		t.Fatalf("WithProjectOverrides() error = %v", err)
+	}
+	if exclude := merged.Filters.GetLintExclude(); len(exclude) != 0 {
+		t.Errorf("Expected empty lint exclude, got %v", exclude)
+	}
+}
@@ -14,6 +14,8 @@ import (
 const (
 	keyValidateTimeout        = "validate.timeout"
 	keyValidateCooldown       = "validate.cooldown"
+	keyValidateOnBusy         = "validate.on_busy"
+	keyValidateLockDir        = "validate.lock_dir"
 	keyStatuslineCacheSeconds = "statusline.cache_seconds"
 	keyStatuslineWorkspace    = "statusline.workspace"
 	keyStatuslineCacheDir     = "statusline.cache_dir"
@@ -27,6 +29,8 @@ type ConfigValues struct {
 
 // ValidateConfigValues represents validate-related settings.
 type ValidateConfigValues struct {
+	ValidateOptions
+
 	Timeout  int `json:"timeout"`
 	Cooldown int `json:"cooldown"`
 }
@@ -117,6 +121,10 @@ func (m *Manager) GetString(_ context.Context, key string) (string, bool, error)
 	}
 
 	switch key {
+	case keyValidateOnBusy:
+		return m.config.Validate.OnBusy, true, nil
+	case keyValidateLockDir:
+		return m.config.Validate.LockDir, true, nil
 	case keyStatuslineWorkspace:
 		return m.config.Statusline.Workspace, true, nil
 	case keyStatuslineCacheDir:
@@ -140,6 +148,10 @@ func (m *Manager) GetValue(_ context.Context, key string) (string, bool, error)
 		return strconv.Itoa(m.config.Validate.Timeout), true, nil
 	case keyValidateCooldown:
 		return strconv.Itoa(m.config.Validate.Cooldown), true, nil
+	case keyValidateOnBusy:
+		return m.config.Validate.OnBusy, true, nil
+	case keyValidateLockDir:
+		return m.config.Validate.LockDir, true, nil
 	case keyStatuslineCacheSeconds:
 		return strconv.Itoa(m.config.Statusline.CacheSeconds), true, nil
 	case keyStatuslineWorkspace:
@@ -173,6 +185,13 @@ func (m *Manager) Set(_ context.Context, key string, value string) error {
 			return fmt.Errorf("value must be an integer: %w", err)
 		}
 		m.config.Validate.Cooldown = intVal
+	case keyValidateOnBusy:
+		if value != OnBusyDrop && value != OnBusyQueue && value != OnBusySupersede {
+			return fmt.Errorf("value must be one of: %s, %s, %s", OnBusyDrop, OnBusyQueue, OnBusySupersede)
+		}
+		m.config.Validate.OnBusy = value
+	case keyValidateLockDir:
+		m.config.Validate.LockDir = value
 	case keyStatuslineCacheSeconds:
 		intVal, err := strconv.Atoi(value)
 		if err != nil {
@@ -210,6 +229,8 @@ func (m *Manager) GetAll(ctx context.Context) (map[string]ConfigInfo, error) {
 	keys := []string{
 		keyValidateTimeout,
 		keyValidateCooldown,
+		keyValidateOnBusy,
+		keyValidateLockDir,
 		keyStatuslineWorkspace,
 		keyStatuslineCacheDir,
 		keyStatuslineCacheSeconds,
@@ -233,6 +254,8 @@ func (m *Manager) GetAllKeys(_ context.Context) ([]string, error) {
 	keys := []string{
 		keyValidateTimeout,
 		keyValidateCooldown,
+		keyValidateOnBusy,
+		keyValidateLockDir,
 		keyStatuslineWorkspace,
 		keyStatuslineCacheDir,
 		keyStatuslineCacheSeconds,
@@ -257,6 +280,10 @@ func (m *Manager) Reset(_ context.Context, key string) error {
 		m.config.Validate.Timeout = defaults.Validate.Timeout
 	case keyValidateCooldown:
 		m.config.Validate.Cooldown = defaults.Validate.Cooldown
+	case keyValidateOnBusy:
+		m.config.Validate.OnBusy = defaults.Validate.OnBusy
+	case keyValidateLockDir:
+		m.config.Validate.LockDir = defaults.Validate.LockDir
 	case keyStatuslineCacheSeconds:
 		m.config.Statusline.CacheSeconds = defaults.Statusline.CacheSeconds
 	case keyStatuslineWorkspace:
@@ -374,6 +401,9 @@ func (m *Manager) createDefaultConfig() error {
 func getDefaultConfig() *ConfigValues {
 	return &ConfigValues{
 		Validate: ValidateConfigValues{
+			ValidateOptions: ValidateOptions{
+				OnBusy: OnBusyDrop,
+			},
 			Timeout:  defaultValidateTimeout,
 			Cooldown: defaultValidateCooldown,
 		},
@@ -395,6 +425,9 @@ func (m *Manager) ensureDefaults() {
 	if m.config.Validate.Cooldown == 0 {
 		m.config.Validate.Cooldown = defaults.Validate.Cooldown
 	}
+	if m.config.Validate.OnBusy == "" {
+		m.config.Validate.OnBusy = defaults.Validate.OnBusy
+	}
 	if m.config.Statusline.CacheDir == "" {
 		m.config.Statusline.CacheDir = defaults.Statusline.CacheDir
 	}
@@ -439,6 +472,10 @@ func getDefaultValue(defaults *ConfigValues, key string) string {
 		return strconv.Itoa(defaults.Validate.Timeout)
 	case keyValidateCooldown:
 		return strconv.Itoa(defaults.Validate.Cooldown)
+	case keyValidateOnBusy:
+		return defaults.Validate.OnBusy
+	case keyValidateLockDir:
+		return defaults.Validate.LockDir
 	case keyStatuslineCacheSeconds:
 		return strconv.Itoa(defaults.Statusline.CacheSeconds)
 	case keyStatuslineWorkspace:
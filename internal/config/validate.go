@@ -0,0 +1,683 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ProjectConfigFile is the name of the optional per-project configuration file.
+// It lives in the project root and overrides the validate settings from the
+// user configuration for that project only.
+const ProjectConfigFile = ".cc-tools.json"
+
+// Environment loader names.
+const (
+	EnvLoaderDotenv = "dotenv"
+	EnvLoaderDirenv = "direnv"
+	EnvLoaderNix    = "nix"
+)
+
+// Policies for a validate hook that finds another run in progress or in cooldown.
+const (
+	// OnBusyDrop exits silently without validating.
+	OnBusyDrop = "drop"
+	// OnBusyQueue asks the lock holder to validate once more after it finishes
+	// and delivers that result to the next hook invocation.
+	OnBusyQueue = "queue"
+	// OnBusySupersede cancels the run in progress and validates the latest tree instead.
+	OnBusySupersede = "supersede"
+)
+
+// ValidateOptions holds validate settings beyond timeout and cooldown.
+// It is shared by the user configuration and per-project overrides.
+type ValidateOptions struct {
+	Env    EnvConfig `json:"env,omitzero"`
+	OnBusy string    `json:"on_busy,omitempty"`
+	// LockDir overrides the directory holding lock files. Empty selects the default.
+	LockDir      string            `json:"lock_dir,omitempty"`
+	Cache        CacheConfig       `json:"cache,omitzero"`
+	Coverage     CoverageConfig    `json:"coverage,omitzero"`
+	RunLogs      RunLogConfig      `json:"run_logs,omitzero"`
+	Filters      FilterConfig      `json:"filters,omitzero"`
+	Root         RootConfig        `json:"root,omitzero"`
+	History      HistoryConfig     `json:"history,omitzero"`
+	Severity     SeverityConfig    `json:"severity,omitzero"`
+	Autofix      AutofixConfig     `json:"autofix,omitzero"`
+	Sandbox      SandboxConfig     `json:"sandbox,omitzero"`
+	Reports      ReportsConfig     `json:"reports,omitzero"`
+	Impact       ImpactConfig      `json:"impact,omitzero"`
+	Bench        BenchConfig       `json:"bench,omitzero"`
+	API          APIConfig         `json:"api,omitzero"`
+	Suppressions SuppressionConfig `json:"suppressions,omitzero"`
+	Placeholders PlaceholderConfig `json:"placeholders,omitzero"`
+	Secrets      SecretsConfig     `json:"secrets,omitzero"`
+	Manifests    ManifestConfig    `json:"manifests,omitzero"`
+	License      LicenseConfig     `json:"license,omitzero"`
+	LSP          LSPConfig         `json:"lsp,omitzero"`
+}
+
+// GetOnBusy returns the busy policy, defaulting to OnBusyDrop.
+func (o ValidateOptions) GetOnBusy() string {
+	if o.OnBusy == "" {
+		return OnBusyDrop
+	}
+	return o.OnBusy
+}
+
+// EnvConfig selects how the environment for validation commands is built.
+type EnvConfig struct {
+	// Loaders lists the environment loaders to apply, in order.
+	// Supported values are "dotenv", "direnv" and "nix".
+	Loaders []string `json:"loaders,omitempty"`
+	// DotenvFiles lists the .env files to source, relative to the project root.
+	DotenvFiles []string `json:"dotenv_files,omitempty"`
+	// NixFlake is the flake reference passed to nix develop. Defaults to the project root.
+	NixFlake string `json:"nix_flake,omitempty"`
+}
+
+// HasLoader reports whether the named loader is enabled.
+func (e EnvConfig) HasLoader(name string) bool {
+	return slices.Contains(e.Loaders, name)
+}
+
+// GetDotenvFiles returns the configured .env files, defaulting to ".env".
+func (e EnvConfig) GetDotenvFiles() []string {
+	if len(e.DotenvFiles) == 0 {
+		return []string{".env"}
+	}
+	return e.DotenvFiles
+}
+
+// Result cache defaults.
+const (
+	defaultCacheMaxEntries  = 500
+	defaultCacheMaxAgeHours = 7 * 24
+)
+
+// CacheConfig controls the validation result cache.
+type CacheConfig struct {
+	// Enabled turns on caching of lint and test results per tree content.
+	Enabled bool `json:"enabled,omitempty"`
+	// Dir is where results are stored. Defaults to $XDG_CACHE_HOME/cc-tools/results.
+	Dir string `json:"dir,omitempty"`
+	// MaxEntries caps the number of stored results; the oldest are evicted first.
+	MaxEntries int `json:"max_entries,omitempty"`
+	// MaxAgeHours evicts results older than this many hours.
+	MaxAgeHours int `json:"max_age_hours,omitempty"`
+}
+
+// GetDir returns the cache directory, defaulting to the user cache directory.
+func (c CacheConfig) GetDir() string {
+	if c.Dir != "" {
+		return c.Dir
+	}
+	return filepath.Join(cacheHome(), "results")
+}
+
+// GetMaxEntries returns the entry limit, defaulting to 500.
+func (c CacheConfig) GetMaxEntries() int {
+	if c.MaxEntries <= 0 {
+		return defaultCacheMaxEntries
+	}
+	return c.MaxEntries
+}
+
+// GetMaxAge returns the maximum age of a cached result, defaulting to one week.
+func (c CacheConfig) GetMaxAge() time.Duration {
+	hours := c.MaxAgeHours
+	if hours <= 0 {
+		hours = defaultCacheMaxAgeHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// defaultLintExclude keeps test files out of lint runs. Tests still run for them.
+func defaultLintExclude() []string {
+	return []string{
+		"*_test.go",
+		"*_test.py",
+		"*.test.js",
+		"*.test.ts",
+		"*.spec.js",
+		"*.spec.ts",
+		"*.test.jsx",
+		"*.test.tsx",
+		"*.spec.jsx",
+		"*.spec.tsx",
+	}
+}
+
+// FilterConfig selects which edited files trigger each validation command.
+type FilterConfig struct {
+	Lint CommandFilter `json:"lint,omitzero"`
+	Test CommandFilter `json:"test,omitzero"`
+}
+
+// CommandFilter holds gitignore-style patterns, relative to the project root,
+// for the files that trigger a command.
+type CommandFilter struct {
+	// Include limits the command to matching files. Empty matches every file.
+	Include []string `json:"include,omitempty"`
+	// Exclude skips the command for matching files. Nil selects the defaults;
+	// an empty list excludes nothing.
+	Exclude []string `json:"exclude"`
+}
+
+// GetLintExclude returns the lint exclude patterns, defaulting to test files.
+func (f FilterConfig) GetLintExclude() []string {
+	if f.Lint.Exclude == nil {
+		return defaultLintExclude()
+	}
+	return f.Lint.Exclude
+}
+
+// RootConfig selects how the project root of an edited file is found.
+// It only takes effect in the user configuration, since per-project
+// configuration is read from the root it selects.
+type RootConfig struct {
+	// Strategies are tried in order: "root_file", "project_dir", "vcs" and "nearest".
+	// Defaults to "root_file" then "nearest".
+	Strategies []string `json:"strategies,omitempty"`
+	// Markers are the files the "nearest" strategy looks for. Defaults to
+	// .git, go.mod, package.json, Cargo.toml, setup.py, pyproject.toml, Makefile and justfile.
+	Markers []string `json:"markers,omitempty"`
+}
+
+// Severities of a failed check.
+const (
+	// SeverityBlock reports the failure to Claude and blocks until it is fixed.
+	SeverityBlock = "block"
+	// SeverityWarn surfaces the failure without blocking.
+	SeverityWarn = "warn"
+	// SeveritySilent only records the failure in the run log and history.
+	SeveritySilent = "silent"
+)
+
+// Checks with a configurable severity.
+const (
+	CheckLint         = "lint"
+	CheckTest         = "test"
+	CheckCoverage     = "coverage"
+	CheckBench        = "bench"
+	CheckAPI          = "api"
+	CheckSuppressions = "suppressions"
+	CheckPlaceholders = "placeholders"
+	CheckSecrets      = "secrets"
+	CheckManifests    = "manifests"
+	CheckLicense      = "license"
+	CheckLSP          = "lsp"
+)
+
+// SeverityConfig sets how failures of each check are reported.
+type SeverityConfig struct {
+	Lint         string `json:"lint,omitempty"`
+	Test         string `json:"test,omitempty"`
+	Coverage     string `json:"coverage,omitempty"`
+	Bench        string `json:"bench,omitempty"`
+	API          string `json:"api,omitempty"`
+	Suppressions string `json:"suppressions,omitempty"`
+	Placeholders string `json:"placeholders,omitempty"`
+	Secrets      string `json:"secrets,omitempty"`
+	Manifests    string `json:"manifests,omitempty"`
+	License      string `json:"license,omitempty"`
+	LSP          string `json:"lsp,omitempty"`
+}
+
+// Get returns the severity of a check, defaulting to SeverityBlock for
+// unset or unknown values.
+func (s SeverityConfig) Get(check string) string {
+	var severity string
+	switch check {
+	case CheckLint:
+		severity = s.Lint
+	case CheckTest:
+		severity = s.Test
+	case CheckCoverage:
+		severity = s.Coverage
+	case CheckBench:
+		severity = s.Bench
+	case CheckAPI:
+		severity = s.API
+	case CheckSuppressions:
+		severity = s.Suppressions
+	case CheckPlaceholders:
+		severity = s.Placeholders
+	case CheckSecrets:
+		severity = s.Secrets
+	case CheckManifests:
+		severity = s.Manifests
+	case CheckLicense:
+		severity = s.License
+	case CheckLSP:
+		severity = s.LSP
+	}
+	if severity == SeverityWarn || severity == SeveritySilent {
+		return severity
+	}
+	return SeverityBlock
+}
+
+// defaultHistoryMaxSizeMB caps the validation history file.
+const defaultHistoryMaxSizeMB = 10
+
+// HistoryConfig controls the record of past validation runs.
+type HistoryConfig struct {
+	// Disabled turns off recording validation runs.
+	Disabled bool `json:"disabled,omitempty"`
+	// Path is the history file. Defaults to $XDG_CACHE_HOME/cc-tools/history.jsonl.
+	Path string `json:"path,omitempty"`
+	// MaxSizeMB caps the history file; the oldest half of the runs is dropped
+	// when it grows beyond this size.
+	MaxSizeMB int `json:"max_size_mb,omitempty"`
+}
+
+// GetPath returns the history file, defaulting to the user cache directory.
+func (h HistoryConfig) GetPath() string {
+	if h.Path != "" {
+		return h.Path
+	}
+	return filepath.Join(cacheHome(), "history.jsonl")
+}
+
+// GetMaxBytes returns the size limit of the history file, defaulting to 10 MB.
+func (h HistoryConfig) GetMaxBytes() int64 {
+	size := h.MaxSizeMB
+	if size <= 0 {
+		size = defaultHistoryMaxSizeMB
+	}
+	const megabyte = 1 << 20
+	return int64(size) * megabyte
+}
+
+// Run log defaults.
+const (
+	defaultRunLogMaxRuns     = 50
+	defaultRunLogMaxAgeHours = 7 * 24
+)
+
+// RunLogConfig controls the per-run logs of validation commands.
+type RunLogConfig struct {
+	// Disabled turns off writing run logs.
+	Disabled bool `json:"disabled,omitempty"`
+	// Dir is where run logs are written, one subdirectory per project.
+	// Defaults to $XDG_CACHE_HOME/cc-tools/runs.
+	Dir string `json:"dir,omitempty"`
+	// MaxRuns caps the number of logs kept per project; the oldest are removed first.
+	MaxRuns int `json:"max_runs,omitempty"`
+	// MaxAgeHours removes logs older than this many hours.
+	MaxAgeHours int `json:"max_age_hours,omitempty"`
+}
+
+// GetDir returns the run log directory, defaulting to the user cache directory.
+func (r RunLogConfig) GetDir() string {
+	if r.Dir != "" {
+		return r.Dir
+	}
+	return filepath.Join(cacheHome(), "runs")
+}
+
+// GetMaxRuns returns the per-project log limit, defaulting to 50.
+func (r RunLogConfig) GetMaxRuns() int {
+	if r.MaxRuns <= 0 {
+		return defaultRunLogMaxRuns
+	}
+	return r.MaxRuns
+}
+
+// GetMaxAge returns the maximum age of a run log, defaulting to one week.
+func (r RunLogConfig) GetMaxAge() time.Duration {
+	hours := r.MaxAgeHours
+	if hours <= 0 {
+		hours = defaultRunLogMaxAgeHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// AutofixConfig controls running a linter's fixer before lint failures are reported.
+type AutofixConfig struct {
+	// Enabled turns on the fixer pass when lint fails.
+	Enabled bool `json:"enabled,omitempty"`
+	// Commands overrides the fixer per file extension, such as
+	// {".go": ["golangci-lint", "run", "--fix", "{dir}"]}. "{file}" and "{dir}"
+	// are replaced by the edited file and its directory. Commands run in the project root.
+	Commands map[string][]string `json:"commands,omitempty"`
+}
+
+// SandboxConfig controls sandboxing of validation commands.
+type SandboxConfig struct {
+	// Enabled runs every validation command in a Landlock sandbox that only
+	// allows writes to the project, the temporary directory and tool caches.
+	Enabled bool `json:"enabled,omitempty"`
+	// AllowWrite lists further paths commands may write to.
+	AllowWrite []string `json:"allow_write,omitempty"`
+}
+
+// SuppressionConfig controls the guard against edits that silence checks
+// instead of fixing them.
+type SuppressionConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// PlaceholderConfig controls the detection of placeholder code in edits.
+type PlaceholderConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Patterns replaces the built-in regular expressions of a language. Keys
+	// are "go", "python", "javascript", "typescript" and "rust", or file
+	// extensions such as ".rb" for other languages.
+	Patterns map[string][]string `json:"patterns,omitempty"`
+}
+
+// ManifestConfig controls the verification of edited dependency manifests.
+type ManifestConfig struct {
+	// Enabled checks that an edited manifest agrees with its lockfile and
+	// lists the dependencies the edit added.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// LicenseConfig controls the license header required at the top of new files.
+type LicenseConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Templates maps a file extension such as ".go", or a language such as
+	// "python", to the exact header new files of that kind start with,
+	// comment markers included. {year} stands for a four-digit year.
+	Templates map[string]string `json:"templates,omitempty"`
+	// Insert adds a missing header to the new file instead of reporting it.
+	Insert bool `json:"insert,omitempty"`
+}
+
+const (
+	defaultLSPTimeoutMS   = 2000
+	defaultLSPIdleSeconds = 600
+)
+
+// LSPConfig controls diagnostics for edited files from language servers.
+type LSPConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Servers replaces the command that starts the language server of a
+	// language. Keys are "go", "python", "javascript", "typescript" and
+	// "rust", or file extensions such as ".rb" for other languages. An empty
+	// command turns a language off.
+	Servers map[string][]string `json:"servers,omitempty"`
+	// TimeoutMS bounds how long an edit waits for diagnostics.
+	TimeoutMS int `json:"timeout_ms,omitempty"`
+	// IdleSeconds is how long an unused language server keeps running.
+	IdleSeconds int `json:"idle_seconds,omitempty"`
+}
+
+// GetTimeout returns how long an edit waits for diagnostics, defaulting to two seconds.
+func (l LSPConfig) GetTimeout() time.Duration {
+	ms := l.TimeoutMS
+	if ms <= 0 {
+		ms = defaultLSPTimeoutMS
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// GetIdleSeconds returns how long an unused language server keeps running,
+// defaulting to ten minutes.
+func (l LSPConfig) GetIdleSeconds() int {
+	if l.IdleSeconds <= 0 {
+		return defaultLSPIdleSeconds
+	}
+	return l.IdleSeconds
+}
+
+// SecretsConfig controls the scan of edited content for secrets.
+type SecretsConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Rules are added to the built-in rules. A rule with the ID of a built-in
+	// rule replaces it, and one without a pattern disables it.
+	Rules []SecretRule `json:"rules,omitempty"`
+	// Allowlist is the file of allowed secrets, relative to the project root.
+	// Empty selects .cc-tools-secrets-allow.
+	Allowlist string `json:"allowlist,omitempty"`
+}
+
+// defaultSecretsAllowlist is the allowlist file of a project.
+const defaultSecretsAllowlist = ".cc-tools-secrets-allow"
+
+// GetAllowlist returns the allowlist file, defaulting to .cc-tools-secrets-allow.
+func (s SecretsConfig) GetAllowlist() string {
+	if s.Allowlist != "" {
+		return s.Allowlist
+	}
+	return defaultSecretsAllowlist
+}
+
+// SecretRule recognizes a kind of secret.
+type SecretRule struct {
+	ID string `json:"id"`
+	// Pattern is a regular expression matching the secret, or a line holding
+	// it in its first capture group.
+	Pattern string `json:"pattern,omitempty"`
+	// MinEntropy is the Shannon entropy in bits per character the secret
+	// needs to count, so that placeholder values are not flagged.
+	MinEntropy float64 `json:"min_entropy,omitempty"`
+}
+
+// APIConfig controls the compatibility check of the exported API of Go packages.
+type APIConfig struct {
+	// Enabled compares the API of an edited Go package with git HEAD.
+	Enabled bool `json:"enabled,omitempty"`
+	// InternalSeverity overrides the api severity for packages below an
+	// internal directory, which other modules cannot import.
+	InternalSeverity string `json:"internal_severity,omitempty"`
+}
+
+// GetSeverity returns the severity of incompatible changes to a package,
+// given the configured api severity.
+func (a APIConfig) GetSeverity(severity string, internal bool) string {
+	if internal && (a.InternalSeverity == SeverityBlock || a.InternalSeverity == SeverityWarn ||
+		a.InternalSeverity == SeveritySilent) {
+		return a.InternalSeverity
+	}
+	return severity
+}
+
+// Benchmark guard defaults.
+const (
+	defaultBenchCount     = 6
+	defaultBenchTime      = "100ms"
+	defaultBenchThreshold = 5.0
+	defaultBenchAlpha     = 0.05
+)
+
+// BenchConfig controls the benchmark regression guard for Go packages.
+type BenchConfig struct {
+	// Enabled runs the benchmarks touching the edited file after the tests
+	// pass and compares them with the baseline of the current commit.
+	Enabled bool `json:"enabled,omitempty"`
+	// Count is how many times each benchmark runs. Defaults to 6.
+	Count int `json:"count,omitempty"`
+	// Benchtime is passed to go test -benchtime. Defaults to 100ms.
+	Benchtime string `json:"benchtime,omitempty"`
+	// Threshold is the smallest change, in percent, that counts as a regression. Defaults to 5.
+	Threshold float64 `json:"threshold,omitempty"`
+	// Alpha is the significance level of the comparison. Defaults to 0.05.
+	Alpha float64 `json:"alpha,omitempty"`
+	// Dir is where baselines are stored. Defaults to $XDG_CACHE_HOME/cc-tools/bench.
+	Dir string `json:"dir,omitempty"`
+}
+
+// GetCount returns the number of runs per benchmark, defaulting to 6.
+func (b BenchConfig) GetCount() int {
+	if b.Count <= 0 {
+		return defaultBenchCount
+	}
+	return b.Count
+}
+
+// GetBenchtime returns the time per benchmark run, defaulting to 100ms.
+func (b BenchConfig) GetBenchtime() string {
+	if b.Benchtime == "" {
+		return defaultBenchTime
+	}
+	return b.Benchtime
+}
+
+// GetThreshold returns the regression threshold in percent, defaulting to 5.
+func (b BenchConfig) GetThreshold() float64 {
+	if b.Threshold <= 0 {
+		return defaultBenchThreshold
+	}
+	return b.Threshold
+}
+
+// GetAlpha returns the significance level, defaulting to 0.05.
+func (b BenchConfig) GetAlpha() float64 {
+	if b.Alpha <= 0 || b.Alpha >= 1 {
+		return defaultBenchAlpha
+	}
+	return b.Alpha
+}
+
+// GetDir returns the baseline store, defaulting to the user cache directory.
+func (b BenchConfig) GetDir() string {
+	if b.Dir != "" {
+		return b.Dir
+	}
+	return filepath.Join(cacheHome(), "bench")
+}
+
+// defaultImpactMaxPackages caps the packages impact mode tests by default.
+const defaultImpactMaxPackages = 20
+
+// ImpactConfig controls test selection by reverse dependencies in Go modules.
+type ImpactConfig struct {
+	// Enabled tests the edited Go package and every package that transitively
+	// imports it, instead of running the project's test command.
+	Enabled bool `json:"enabled,omitempty"`
+	// MaxPackages caps how many packages are tested. The edited package and
+	// its nearest importers come first. Defaults to 20.
+	MaxPackages int `json:"max_packages,omitempty"`
+	// Dir is where import graphs are cached. Defaults to $XDG_CACHE_HOME/cc-tools/impact.
+	Dir string `json:"dir,omitempty"`
+}
+
+// GetMaxPackages returns the package limit, defaulting to 20.
+func (i ImpactConfig) GetMaxPackages() int {
+	if i.MaxPackages <= 0 {
+		return defaultImpactMaxPackages
+	}
+	return i.MaxPackages
+}
+
+// GetDir returns the import graph cache directory, defaulting to the user cache directory.
+func (i ImpactConfig) GetDir() string {
+	if i.Dir != "" {
+		return i.Dir
+	}
+	return filepath.Join(cacheHome(), "impact")
+}
+
+// ReportsConfig controls the machine-readable reports written after each validation.
+type ReportsConfig struct {
+	// Enabled writes a JUnit XML report of the test run and a SARIF report of
+	// the lint findings after every validation.
+	Enabled bool `json:"enabled,omitempty"`
+	// Dir is where the reports are written, relative to the project root
+	// unless absolute. Defaults to .cc-tools/reports.
+	Dir string `json:"dir,omitempty"`
+}
+
+// GetDir returns the report directory for a project.
+func (r ReportsConfig) GetDir(projectRoot string) string {
+	dir := r.Dir
+	if dir == "" {
+		dir = filepath.Join(".cc-tools", "reports")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(projectRoot, dir)
+	}
+	return dir
+}
+
+// CoverageConfig controls coverage checks of edited files after tests pass.
+type CoverageConfig struct {
+	// Enabled turns on coverage collection for the edited file.
+	Enabled bool `json:"enabled,omitempty"`
+	// Floor blocks when line coverage of the edited file is below this percentage.
+	Floor float64 `json:"floor,omitempty"`
+	// MaxDrop blocks when coverage of the edited file falls by more than this
+	// many percentage points since the last recorded value. Zero disables the check.
+	MaxDrop float64 `json:"max_drop,omitempty"`
+	// HistoryDir is where per-file coverage history is kept.
+	// Defaults to $XDG_CACHE_HOME/cc-tools/coverage.
+	HistoryDir string `json:"history_dir,omitempty"`
+}
+
+// GetHistoryDir returns the coverage history directory.
+func (c CoverageConfig) GetHistoryDir() string {
+	if c.HistoryDir != "" {
+		return c.HistoryDir
+	}
+	return filepath.Join(cacheHome(), "coverage")
+}
+
+// cacheHome returns the cc-tools directory under the user cache directory.
+func cacheHome() string {
+	if xdgCache := os.Getenv("XDG_CACHE_HOME"); xdgCache != "" {
+		return filepath.Join(xdgCache, "cc-tools")
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".cache", "cc-tools")
+}
+
+// projectFile is the layout of a per-project configuration file.
+type projectFile struct {
+	Validate json.RawMessage `json:"validate"`
+}
+
+// WithProjectOverrides returns a copy of the validate configuration with the
+// contents of a per-project configuration file applied on top of it.
+// Settings missing from the project file keep their user-level values.
+func (v ValidateConfig) WithProjectOverrides(data []byte) (ValidateConfig, error) {
+	var file projectFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return v, fmt.Errorf("parse project config: %w", err)
+	}
+	if len(file.Validate) == 0 {
+		return v, nil
+	}
+
+	// Round-trip the user options so the overlay never writes into shared slices or maps
+	base, err := json.Marshal(v.ValidateOptions)
+	if err != nil {
+		return v, fmt.Errorf("copy validate config: %w", err)
+	}
+	merged := v
+	merged.ValidateOptions = ValidateOptions{}
+	if unmarshalErr := json.Unmarshal(base, &merged.ValidateOptions); unmarshalErr != nil {
+		return v, fmt.Errorf("copy validate config: %w", unmarshalErr)
+	}
+	if err := json.Unmarshal(file.Validate, &merged.ValidateOptions); err != nil {
+		return v, fmt.Errorf("parse project validate config: %w", err)
+	}
+	// The sandbox protects against the project, so the project cannot loosen it
+	merged.Sandbox = v.Sandbox
+	if v.Sandbox.Enabled {
+		// Environment loaders evaluate project code, such as a flake's shellHook
+		merged.Env = v.Env
+	}
+	// Language servers run as long-lived daemons, so only the user chooses them
+	merged.LSP.Servers = v.LSP.Servers
+	merged.pinStorage(v.ValidateOptions)
+	return merged, nil
+}
+
+// pinStorage restores the paths the hook itself writes to, which run outside
+// the sandbox, from the user options.
+func (o *ValidateOptions) pinStorage(user ValidateOptions) {
+	o.LockDir = user.LockDir
+	o.Cache.Dir = user.Cache.Dir
+	o.Coverage.HistoryDir = user.Coverage.HistoryDir
+	o.RunLogs.Dir = user.RunLogs.Dir
+	o.History.Path = user.History.Path
+	o.Reports.Dir = user.Reports.Dir
+	o.Bench.Dir = user.Bench.Dir
+	o.Impact.Dir = user.Impact.Dir
+}
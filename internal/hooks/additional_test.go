@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
 )
 
 // Additional tests to cover remaining edge cases
@@ -247,7 +249,8 @@ func TestRunSmartHookEdgeCases(t *testing.T) {
 			return nil, os.ErrNotExist
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -291,7 +294,7 @@ func TestRunSmartHookEdgeCases(t *testing.T) {
 		exitCode := RunSmartHook(
 			context.Background(),
 			CommandTypeLint,
-			true, 20, 5,
+			true, 20, 5, config.FilterConfig{},
 			testDeps.Dependencies,
 		)
 		if exitCode != 0 {
@@ -315,7 +318,7 @@ func TestRunSmartHookEdgeCases(t *testing.T) {
 		exitCode := RunSmartHook(
 			context.Background(),
 			CommandTypeLint,
-			true, 20, 5,
+			true, 20, 5, config.FilterConfig{},
 			testDeps.Dependencies,
 		)
 		if exitCode != 0 {
@@ -362,7 +365,7 @@ func TestRunSmartHookEdgeCases(t *testing.T) {
 		exitCode := RunSmartHook(
 			context.Background(),
 			CommandTypeLint,
-			true, 20, 5,
+			true, 20, 5, config.FilterConfig{},
 			testDeps.Dependencies,
 		)
 		if exitCode != 0 {
@@ -438,36 +441,39 @@ func TestReadStatusLineInputEdgeCases(t *testing.T) {
 func TestLockManagerCleanupOnExit(t *testing.T) {
 	t.Run("Release respects cleanupOnExit flag", func(t *testing.T) {
 		testDeps := createTestDependencies()
-
-		var writeCount int
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.writeFileFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			writeCount++
-			return nil
-		}
+		held := &mockLockedFile{}
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) { return held, nil }
 
 		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
+		if acquired, _ := lm.TryAcquire(); !acquired {
+			t.Fatal("Expected to acquire lock")
+		}
 		lm.cleanupOnExit = false // Disable cleanup
+		acquiredContent := string(held.content)
 
 		err := lm.Release()
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
-		if writeCount != 0 {
+		if string(held.content) != acquiredContent {
 			t.Error("Expected no write when cleanupOnExit is false")
 		}
+		if held.unlockCount != 1 {
+			t.Error("Expected lock to be released")
+		}
 	})
 
 	t.Run("Release handles write error", func(t *testing.T) {
 		testDeps := createTestDependencies()
-
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.writeFileFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			return fmt.Errorf("disk full")
-		}
+		held := &mockLockedFile{}
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) { return held, nil }
 		testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000000, 0) }
 
 		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
+		if acquired, _ := lm.TryAcquire(); !acquired {
+			t.Fatal("Expected to acquire lock")
+		}
+		held.replaceErr = fmt.Errorf("disk full")
 
 		err := lm.Release()
 		if err == nil {
@@ -476,5 +482,8 @@ func TestLockManagerCleanupOnExit(t *testing.T) {
 		if !strings.Contains(err.Error(), "disk full") {
 			t.Errorf("Expected 'disk full' in error, got: %v", err)
 		}
+		if held.unlockCount != 1 {
+			t.Error("Expected lock to be released despite the write error")
+		}
 	})
 }
@@ -0,0 +1,512 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// ErrNoAPIChange is returned when an edit cannot have changed an exported API.
+var ErrNoAPIChange = errors.New("no exported API change")
+
+// APIChange is an incompatible change to an exported identifier.
+type APIChange struct {
+	// Name is the identifier, qualified by its type for fields and methods.
+	Name    string
+	Message string
+}
+
+// String formats the change as "Parse: removed".
+func (c APIChange) String() string {
+	return c.Name + ": " + c.Message
+}
+
+// APIChecker compares the exported API of an edited Go package with git HEAD.
+type APIChecker struct {
+	projectRoot string
+	executor    *CommandExecutor
+	deps        *Dependencies
+}
+
+// NewAPIChecker creates an API checker for the project.
+func NewAPIChecker(projectRoot string, timeoutSecs int, deps *Dependencies) *APIChecker {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &APIChecker{
+		projectRoot: projectRoot,
+		executor:    NewCommandExecutor(timeoutSecs, false, deps),
+		deps:        deps,
+	}
+}
+
+// Changes returns the import path of the edited file's package and the
+// incompatible changes of its exported API against HEAD. It returns
+// ErrNoAPIChange without type-checking when the exported declarations of the
+// file are unchanged.
+func (c *APIChecker) Changes(ctx context.Context, filePath string) (string, []APIChange, error) {
+	if filepath.Ext(filePath) != ".go" || strings.HasSuffix(filePath, "_test.go") {
+		return "", nil, ErrNoAPIChange
+	}
+	dir := filepath.Dir(filePath)
+	current, err := c.deps.FS.ReadFile(filePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("read %s: %w", filePath, err)
+	}
+	previous, _ := c.headFile(ctx, dir, filepath.Base(filePath))
+	if slices.Equal(exportedDecls(previous), exportedDecls(current)) {
+		return "", nil, ErrNoAPIChange
+	}
+
+	moduleRoot, modulePath, err := findGoModule(c.deps.FS, c.projectRoot, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	rel, err := filepath.Rel(moduleRoot, dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("relative path: %w", err)
+	}
+	importPath := path.Join(modulePath, filepath.ToSlash(rel))
+
+	oldFiles, err := c.headPackage(ctx, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(oldFiles) == 0 {
+		// A package that is new since HEAD has no API to break
+		return importPath, nil, nil
+	}
+	newFiles, err := c.workingPackage(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+	imports := importer.ForCompiler(fset, "gc", c.exportLookup(ctx, dir))
+	oldPkg := typeCheck(fset, imports, importPath, dir, oldFiles)
+	newPkg := typeCheck(fset, imports, importPath, dir, newFiles)
+	if oldPkg == nil || newPkg == nil || oldPkg.Name() == "main" {
+		return importPath, nil, nil
+	}
+	return importPath, compareAPI(oldPkg, newPkg), nil
+}
+
+// headFile returns the content of a file of dir at HEAD.
+func (c *APIChecker) headFile(ctx context.Context, dir, name string) ([]byte, error) {
+	out, err := c.deps.Runner.RunContext(ctx, dir, "git", "show", "HEAD:./"+name)
+	if err != nil {
+		return nil, fmt.Errorf("git show %s: %w", name, err)
+	}
+	return out.Stdout, nil
+}
+
+// headPackage returns the non-test Go files of dir at HEAD that match the
+// current build context, keyed by name.
+func (c *APIChecker) headPackage(ctx context.Context, dir string) (map[string][]byte, error) {
+	out, err := c.deps.Runner.RunContext(ctx, dir, "git", "ls-tree", "--name-only", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree: %w", err)
+	}
+	files := make(map[string][]byte)
+	for _, name := range strings.Fields(string(out.Stdout)) {
+		if !isPackageSource(name) {
+			continue
+		}
+		data, showErr := c.headFile(ctx, dir, name)
+		if showErr != nil {
+			return nil, showErr
+		}
+		files[name] = data
+	}
+	return matchBuildContext(dir, files), nil
+}
+
+// workingPackage returns the non-test Go files of dir in the working tree
+// that match the current build context, keyed by name.
+func (c *APIChecker) workingPackage(dir string) (map[string][]byte, error) {
+	entries, err := c.deps.FS.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read package directory: %w", err)
+	}
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || !isPackageSource(entry.Name()) {
+			continue
+		}
+		data, readErr := c.deps.FS.ReadFile(filepath.Join(dir, entry.Name()))
+		if readErr != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.Name(), readErr)
+		}
+		files[entry.Name()] = data
+	}
+	return matchBuildContext(dir, files), nil
+}
+
+// exportLookup returns an importer lookup that reads the export data the go
+// command built for the dependencies of the package in dir. Dependencies
+// without export data type-check as invalid on both sides, so they never
+// show up as changes.
+func (c *APIChecker) exportLookup(ctx context.Context, dir string) importer.Lookup {
+	exports := make(map[string]string)
+	cmd := &DiscoveredCommand{
+		Command:    "go",
+		Args:       []string{"list", "-e", "-export", "-deps", "-f", "{{if .Export}}{{.ImportPath}}={{.Export}}{{end}}", "."},
+		WorkingDir: dir,
+	}
+	result := c.executor.Execute(ctx, cmd)
+	for line := range strings.SplitSeq(result.Stdout, "\n") {
+		if importPath, export, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			exports[importPath] = export
+		}
+	}
+
+	return func(importPath string) (io.ReadCloser, error) {
+		export, ok := exports[importPath]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %s: %w", importPath, ErrNoAPIChange)
+		}
+		data, err := c.deps.FS.ReadFile(export)
+		if err != nil {
+			return nil, fmt.Errorf("read export data: %w", err)
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+// isPackageSource reports whether a file name is a non-test Go source file.
+func isPackageSource(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
+
+// matchBuildContext drops files excluded by build constraints or GOOS/GOARCH suffixes.
+func matchBuildContext(dir string, files map[string][]byte) map[string][]byte {
+	ctxt := build.Default
+	ctxt.OpenFile = func(name string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(files[filepath.Base(name)])), nil
+	}
+	for name := range files {
+		if match, err := ctxt.MatchFile(dir, name); err != nil || !match {
+			delete(files, name)
+		}
+	}
+	return files
+}
+
+// exportedDecls renders the exported declarations of a Go file without
+// function bodies, sorted, so edits that only touch bodies or unexported
+// declarations compare equal. A file that does not parse has none.
+func exportedDecls(src []byte) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	render := func(node any) string {
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, fset, node)
+		return buf.String()
+	}
+
+	var decls []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.IsExported() {
+				header := *decl
+				header.Body, header.Doc = nil, nil
+				decls = append(decls, render(&header))
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						decls = append(decls, render(spec))
+					}
+				case *ast.ValueSpec:
+					if slices.ContainsFunc(spec.Names, (*ast.Ident).IsExported) {
+						decls = append(decls, decl.Tok.String()+" "+render(spec))
+					}
+				}
+			}
+		}
+	}
+	slices.Sort(decls)
+	return decls
+}
+
+// typeCheck type-checks the files of a package, tolerating errors so a
+// package in the middle of an edit still yields its API. It returns nil when
+// the files do not parse.
+func typeCheck(
+	fset *token.FileSet,
+	imports types.Importer,
+	importPath, dir string,
+	files map[string][]byte,
+) *types.Package {
+	parsed := make([]*ast.File, 0, len(files))
+	for _, name := range sortedKeys(files) {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), files[name], parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		parsed = append(parsed, file)
+	}
+	conf := types.Config{Importer: imports, Error: func(error) {}}
+	pkg, _ := conf.Check(importPath, fset, parsed, nil)
+	return pkg
+}
+
+// compareAPI lists the incompatible changes from the exported API of old to
+// that of current: removed identifiers, changed types and signatures, removed
+// fields and methods, and interface method sets that changed.
+func compareAPI(old, current *types.Package) []APIChange {
+	qualifier := func(pkg *types.Package) string {
+		if pkg.Path() == old.Path() {
+			return ""
+		}
+		return pkg.Path()
+	}
+	typeString := func(t types.Type) string {
+		return types.TypeString(types.Unalias(t), qualifier)
+	}
+
+	var changes []APIChange
+	for _, name := range old.Scope().Names() {
+		oldObj := old.Scope().Lookup(name)
+		if !oldObj.Exported() {
+			continue
+		}
+		newObj := current.Scope().Lookup(name)
+		if newObj == nil {
+			changes = append(changes, APIChange{Name: name, Message: "removed"})
+			continue
+		}
+		if oldKind, newKind := objectKind(oldObj), objectKind(newObj); oldKind != newKind {
+			changes = append(changes, APIChange{Name: name, Message: "changed from " + oldKind + " to " + newKind})
+			continue
+		}
+		if oldType, ok := oldObj.(*types.TypeName); ok {
+			changes = append(changes, compareTypeNames(name, oldType, newObj.(*types.TypeName), typeString)...)
+			continue
+		}
+		if from, to := typeString(oldObj.Type()), typeString(newObj.Type()); from != to {
+			changes = append(changes, APIChange{Name: name, Message: "changed from " + from + " to " + to})
+		}
+	}
+	return changes
+}
+
+// objectKind names the kind of a package-level object.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Func:
+		return "func"
+	case *types.Const:
+		return "const"
+	case *types.TypeName:
+		return "type"
+	default:
+		return "var"
+	}
+}
+
+// compareTypeNames lists the incompatible changes to an exported type.
+func compareTypeNames(name string, old, current *types.TypeName, typeString func(types.Type) string) []APIChange {
+	oldNamed, oldOK := types.Unalias(old.Type()).(*types.Named)
+	newNamed, newOK := types.Unalias(current.Type()).(*types.Named)
+	if !oldOK || !newOK || old.IsAlias() || current.IsAlias() {
+		if from, to := typeString(old.Type()), typeString(current.Type()); from != to {
+			return []APIChange{{Name: name, Message: "changed from " + from + " to " + to}}
+		}
+		return nil
+	}
+
+	var changes []APIChange
+	if from, to := typeParams(oldNamed, typeString), typeParams(newNamed, typeString); from != to {
+		changes = append(changes, APIChange{Name: name, Message: "changed type parameters from [" + from + "] to [" +
+			to + "]"})
+	}
+
+	switch oldUnder := oldNamed.Underlying().(type) {
+	case *types.Struct:
+		newUnder, ok := newNamed.Underlying().(*types.Struct)
+		if !ok {
+			return append(changes, APIChange{Name: name, Message: "changed from a struct to " +
+				typeString(newNamed.Underlying())})
+		}
+		changes = append(changes, compareFields(name, oldUnder, newUnder, typeString)...)
+	case *types.Interface:
+		newUnder, ok := newNamed.Underlying().(*types.Interface)
+		if !ok {
+			return append(changes, APIChange{Name: name, Message: "changed from an interface to " +
+				typeString(newNamed.Underlying())})
+		}
+		return append(changes, compareInterfaces(name, oldUnder, newUnder, typeString)...)
+	default:
+		if from, to := typeString(oldUnder), typeString(newNamed.Underlying()); from != to {
+			return append(changes, APIChange{Name: name, Message: "changed underlying type from " + from + " to " + to})
+		}
+	}
+	return append(changes, compareMethods(name, oldNamed, newNamed, typeString)...)
+}
+
+// typeParams renders the type parameters of a named type.
+func typeParams(named *types.Named, typeString func(types.Type) string) string {
+	params := named.TypeParams()
+	rendered := make([]string, params.Len())
+	for i := range params.Len() {
+		rendered[i] = params.At(i).Obj().Name() + " " + typeString(params.At(i).Constraint())
+	}
+	return strings.Join(rendered, ", ")
+}
+
+// compareFields lists removed and retyped exported fields of a struct.
+func compareFields(name string, old, current *types.Struct, typeString func(types.Type) string) []APIChange {
+	fields := make(map[string]*types.Var, current.NumFields())
+	for field := range current.Fields() {
+		fields[field.Name()] = field
+	}
+	var changes []APIChange
+	for field := range old.Fields() {
+		if !field.Exported() {
+			continue
+		}
+		member := name + "." + field.Name()
+		newField, ok := fields[field.Name()]
+		switch {
+		case !ok || !newField.Exported():
+			changes = append(changes, APIChange{Name: member, Message: "removed field"})
+		case typeString(field.Type()) != typeString(newField.Type()):
+			changes = append(changes, APIChange{Name: member, Message: "changed field type from " +
+				typeString(field.Type()) + " to " + typeString(newField.Type())})
+		}
+	}
+	return changes
+}
+
+// compareInterfaces lists changes to the method set of an interface. Adding
+// a method breaks implementations outside the package unless the interface
+// already had an unexported method, which prevents those.
+func compareInterfaces(name string, old, current *types.Interface, typeString func(types.Type) string) []APIChange {
+	methods := make(map[string]*types.Func, current.NumMethods())
+	for method := range current.Methods() {
+		methods[method.Name()] = method
+	}
+	sealed := false
+	var changes []APIChange
+	for method := range old.Methods() {
+		if !method.Exported() {
+			sealed = true
+			continue
+		}
+		member := name + "." + method.Name()
+		newMethod, ok := methods[method.Name()]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Name: member, Message: "removed method"})
+		case typeString(method.Type()) != typeString(newMethod.Type()):
+			changes = append(changes, APIChange{Name: member, Message: "changed method from " +
+				typeString(method.Type()) + " to " + typeString(newMethod.Type())})
+		}
+		delete(methods, method.Name())
+	}
+	if sealed {
+		return changes
+	}
+	for _, added := range sortedKeys(methods) {
+		changes = append(changes, APIChange{Name: name + "." + added,
+			Message: "added to interface, which breaks its implementations"})
+	}
+	return changes
+}
+
+// compareMethods lists removed and changed exported methods of a named type,
+// including methods that moved from a value to a pointer receiver.
+func compareMethods(name string, old, current *types.Named, typeString func(types.Type) string) []APIChange {
+	exported := func(set *types.MethodSet) map[string]*types.Selection {
+		methods := make(map[string]*types.Selection)
+		for selection := range set.Methods() {
+			if selection.Obj().Exported() {
+				methods[selection.Obj().Name()] = selection
+			}
+		}
+		return methods
+	}
+	oldPointer := exported(types.NewMethodSet(types.NewPointer(old)))
+	newPointer := exported(types.NewMethodSet(types.NewPointer(current)))
+	oldValue, newValue := exported(types.NewMethodSet(old)), exported(types.NewMethodSet(current))
+
+	var changes []APIChange
+	for _, method := range sortedKeys(oldPointer) {
+		member := name + "." + method
+		newSelection, ok := newPointer[method]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Name: member, Message: "removed method"})
+		case typeString(oldPointer[method].Type()) != typeString(newSelection.Type()):
+			changes = append(changes, APIChange{Name: member, Message: "changed method from " +
+				typeString(oldPointer[method].Type()) + " to " + typeString(newSelection.Type())})
+		case oldValue[method] != nil && newValue[method] == nil:
+			changes = append(changes, APIChange{Name: member, Message: "changed to a pointer receiver"})
+		}
+	}
+	return changes
+}
+
+// checkAPI runs the API compatibility check for the edited file when
+// enabled. The finding has no message when the API is compatible or could
+// not be compared.
+func checkAPI(
+	ctx context.Context,
+	projectRoot, filePath string,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckAPI)
+	if !cfg.API.Enabled {
+		return finding{severity: severity}
+	}
+
+	checker := NewAPIChecker(projectRoot, cfg.TimeoutSeconds, deps)
+	importPath, changes, err := checker.Changes(ctx, filePath)
+	if err != nil {
+		if !errors.Is(err, ErrNoAPIChange) && logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "checking API compatibility")
+		}
+		return finding{severity: severity}
+	}
+	if len(changes) == 0 {
+		return finding{severity: severity}
+	}
+
+	internal := slices.Contains(strings.Split(importPath, "/"), "internal")
+	severity = cfg.API.GetSeverity(severity, internal)
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, "  "+change.String())
+	}
+	return finding{
+		message: formatFailure(severity, "Incompatible changes to the exported API of %s since HEAD:\n%s\n"+
+			"Keep the existing API and add to it instead, or confirm the break is intended",
+			importPath, strings.Join(lines, "\n")),
+		severity: severity,
+	}
+}
@@ -0,0 +1,292 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"go/importer"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestCompareAPI(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{
+			name: "body and unexported changes are compatible",
+			old:  "func F() int { return 1 }\nfunc helper() {}\ntype T struct{ A int }",
+			new:  "func F() int { return 2 }\nfunc helper(x int) {}\ntype T struct{ A int; B string; c bool }",
+		},
+		{
+			name: "removed and changed funcs",
+			old:  "func F(s string) int { return 0 }\nfunc G() {}",
+			new:  "func F(s string, strict bool) int { return 0 }",
+			want: []string{
+				"F: changed from func(s string) int to func(s string, strict bool) int",
+				"G: removed",
+			},
+		},
+		{
+			name: "changed kind and type",
+			old:  "var V int\nconst C = 1\nfunc F() {}",
+			new:  "var V string\nconst C = 1.5\nvar F = func() {}",
+			want: []string{
+				"C: changed from untyped int to untyped float",
+				"F: changed from func to var",
+				"V: changed from int to string",
+			},
+		},
+		{
+			name: "struct fields",
+			old:  "type T struct{ A int; B string }",
+			new:  "type T struct{ A int64; b string }",
+			want: []string{"T.A: changed field type from int to int64", "T.B: removed field"},
+		},
+		{
+			name: "methods",
+			old:  "type T struct{}\nfunc (T) Get() int { return 0 }\nfunc (T) Put() {}\nfunc (*T) Set(int) {}",
+			new:  "type T struct{}\nfunc (*T) Get() int { return 0 }\nfunc (T) Set(string) {}",
+			want: []string{
+				"T.Get: changed to a pointer receiver",
+				"T.Put: removed method",
+				"T.Set: changed method from func(int) to func(string)",
+			},
+		},
+		{
+			name: "interface method added",
+			old:  "type I interface{ Read() }",
+			new:  "type I interface{ Read(); Close() error }",
+			want: []string{"I.Close: added to interface, which breaks its implementations"},
+		},
+		{
+			name: "method added to sealed interface",
+			old:  "type I interface{ Read(); seal() }",
+			new:  "type I interface{ Read(); Close() error; seal() }",
+		},
+		{
+			name: "type parameters and underlying types",
+			old:  "type L[T any] []T\ntype ID int\ntype S struct{}",
+			new:  "type L[T comparable] []T\ntype ID string\ntype S interface{}",
+			want: []string{
+				"ID: changed underlying type from int to string",
+				"L: changed type parameters from [T interface{}] to [T comparable]",
+				"S: changed from a struct to interface{}",
+			},
+		},
+		{
+			name: "aliases",
+			old:  "type A = int\ntype B = []string",
+			new:  "type A = int\ntype B = []byte",
+			want: []string{"B: changed from []string to []byte"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			imports := importer.ForCompiler(fset, "gc", func(string) (io.ReadCloser, error) {
+				return nil, os.ErrNotExist
+			})
+			check := func(src string) map[string][]byte {
+				return map[string][]byte{"p.go": []byte("package p\n\n" + src + "\n")}
+			}
+			oldPkg := typeCheck(fset, imports, "example.com/p", "/p", check(tt.old))
+			newPkg := typeCheck(fset, imports, "example.com/p", "/p", check(tt.new))
+
+			var got []string
+			for _, change := range compareAPI(oldPkg, newPkg) {
+				got = append(got, change.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("compareAPI() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExportedDecls(t *testing.T) {
+	old := []byte("package p\n\n// F does things.\nfunc F() int { return 1 }\n\nvar x, Y = 1, 2\n")
+	if got := exportedDecls(old); !slices.Equal(got, []string{"func F() int", "var x, Y = 1, 2"}) {
+		t.Errorf("exportedDecls() = %q", got)
+	}
+	bodyOnly := []byte("package p\n\nfunc F() int {\n\treturn 2\n}\n\nvar x, Y = 1, 2\n\nfunc g() {}\n")
+	if !slices.Equal(exportedDecls(old), exportedDecls(bodyOnly)) {
+		t.Error("exportedDecls() differs for a body-only change")
+	}
+}
+
+func TestCheckAPI(t *testing.T) {
+	const head = "package lib\n\nfunc Parse(s string) int { return 0 }\n"
+	tests := []struct {
+		name         string
+		dir          string
+		current      string
+		severity     config.SeverityConfig
+		api          config.APIConfig
+		wantMessages []string
+		wantSeverity string
+		wantListed   bool
+	}{
+		{
+			name:         "break blocks",
+			dir:          "/project/lib",
+			current:      "package lib\n\nfunc Parse(s string, n int) int { return 0 }\n",
+			api:          config.APIConfig{Enabled: true},
+			wantMessages: []string{"BLOCKING", "example.com/m/lib", "Parse: changed from func(s string) int"},
+			wantSeverity: config.SeverityBlock,
+			wantListed:   true,
+		},
+		{
+			name:         "internal package uses internal severity",
+			dir:          "/project/internal/lib",
+			current:      "package lib\n",
+			api:          config.APIConfig{Enabled: true, InternalSeverity: config.SeverityWarn},
+			wantMessages: []string{"ADVISORY", "example.com/m/internal/lib", "Parse: removed"},
+			wantSeverity: config.SeverityWarn,
+			wantListed:   true,
+		},
+		{
+			name:         "public package ignores internal severity",
+			dir:          "/project/lib",
+			current:      "package lib\n",
+			severity:     config.SeverityConfig{API: config.SeverityWarn},
+			api:          config.APIConfig{Enabled: true, InternalSeverity: config.SeveritySilent},
+			wantMessages: []string{"ADVISORY", "Parse: removed"},
+			wantSeverity: config.SeverityWarn,
+			wantListed:   true,
+		},
+		{
+			name:         "body change skips the check",
+			dir:          "/project/lib",
+			current:      "package lib\n\nfunc Parse(s string) int { return len(s) }\n",
+			api:          config.APIConfig{Enabled: true},
+			wantSeverity: config.SeverityBlock,
+		},
+		{
+			name:         "added API is compatible",
+			dir:          "/project/lib",
+			current:      head + "\nfunc Format() {}\n",
+			api:          config.APIConfig{Enabled: true},
+			wantSeverity: config.SeverityBlock,
+			wantListed:   true,
+		},
+		{
+			name:         "disabled",
+			dir:          "/project/lib",
+			current:      "package lib\n",
+			wantSeverity: config.SeverityBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := fstest.MapFS{
+				"project/go.mod": {Data: []byte("module example.com/m\n")},
+				strings.TrimPrefix(tt.dir, "/") + "/lib.go":      {Data: []byte(tt.current)},
+				strings.TrimPrefix(tt.dir, "/") + "/lib_test.go": {Data: []byte("package lib\n")},
+			}
+			testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+				return fs.ReadFile(files, strings.TrimPrefix(name, "/"))
+			}
+			testDeps.MockFS.readDirFunc = func(name string) ([]os.DirEntry, error) {
+				return fs.ReadDir(files, strings.TrimPrefix(name, "/"))
+			}
+			listed := false
+			testDeps.MockRunner.runContextFunc = func(_ context.Context, dir, name string, args ...string) (
+				*CommandOutput, error) {
+				command := strings.Join(append([]string{name}, args...), " ")
+				switch {
+				case dir != tt.dir:
+					return nil, errors.New("command outside the package directory")
+				case command == "git show HEAD:./lib.go":
+					return &CommandOutput{Stdout: []byte(head)}, nil
+				case command == "git show HEAD:./lib_test.go":
+					return &CommandOutput{Stdout: []byte("package lib\n")}, nil
+				case command == "git ls-tree --name-only HEAD":
+					listed = true
+					return &CommandOutput{Stdout: []byte("lib.go\nlib_test.go\n")}, nil
+				case strings.HasPrefix(command, "go list"):
+					return &CommandOutput{}, nil
+				}
+				return nil, errors.New("unexpected command " + command)
+			}
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{Severity: tt.severity, API: tt.api},
+				TimeoutSeconds:  10,
+			}
+			result := checkAPI(context.Background(), "/project", tt.dir+"/lib.go", cfg,
+				testDeps.Dependencies, nil)
+			message, severity := result.message, result.severity
+			if (message == "") != (len(tt.wantMessages) == 0) {
+				t.Fatalf("checkAPI() message = %q", message)
+			}
+			for _, want := range tt.wantMessages {
+				if !strings.Contains(message, want) {
+					t.Errorf("message missing %q:\n%s", want, message)
+				}
+			}
+			if severity != tt.wantSeverity {
+				t.Errorf("severity = %q, want %q", severity, tt.wantSeverity)
+			}
+			if listed != tt.wantListed {
+				t.Errorf("package at HEAD listed = %v, want %v", listed, tt.wantListed)
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_APICheckedWhileBusy(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	files.files["/project/go.mod"] = []byte("module example.com/m\n")
+	files.files["/project/lib.go"] = []byte("package lib\n\nfunc Parse(s string, n int) int { return 0 }\n")
+	setupQueueProject(testDeps, func() bool { return false })
+	testDeps.MockInput.readAllFunc = func() ([]byte, error) {
+		return []byte(`{"hook_event_name":"PostToolUse","tool_name":"Edit",` +
+			`"tool_input":{"file_path":"/project/lib.go"}}`), nil
+	}
+	testDeps.MockFS.readDirFunc = func(string) ([]os.DirEntry, error) {
+		return fs.ReadDir(fstest.MapFS{"lib.go": {}}, ".")
+	}
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		switch command := strings.Join(append([]string{name}, args...), " "); {
+		case command == "git show HEAD:./lib.go":
+			return &CommandOutput{Stdout: []byte("package lib\n\nfunc Parse(s string) int { return 0 }\n")}, nil
+		case command == "git ls-tree --name-only HEAD":
+			return &CommandOutput{Stdout: []byte("lib.go\n")}, nil
+		case strings.HasPrefix(command, "go list"):
+			return &CommandOutput{}, nil
+		default:
+			return nil, errors.New("unexpected command " + command)
+		}
+	}
+
+	// Validation of an earlier edit holds the lock
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+
+	cfg := &config.ValidateConfig{
+		ValidateOptions: config.ValidateOptions{API: config.APIConfig{Enabled: true}},
+		TimeoutSeconds:  10,
+	}
+	exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+	if exitCode != ExitCodeShowMessage {
+		t.Errorf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+	}
+	if stderr := testDeps.MockStderr.String(); !strings.Contains(stderr, "Parse: changed from func(s string) int") {
+		t.Errorf("expected the API break to be reported, got %q", stderr)
+	}
+}
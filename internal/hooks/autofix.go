@@ -0,0 +1,163 @@
+package hooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// ErrNoFixer is returned when no fixer applies to the edited file.
+var ErrNoFixer = errors.New("no fixer for file")
+
+// AutofixResult describes a fixer run.
+type AutofixResult struct {
+	Command *DiscoveredCommand
+	// Modified lists the files the fixer changed, relative to the project root.
+	Modified []string
+}
+
+// Autofixer runs a linter's fixer scoped to an edited file.
+type Autofixer struct {
+	projectRoot string
+	cfg         config.AutofixConfig
+	executor    *CommandExecutor
+	deps        *Dependencies
+}
+
+// NewAutofixer creates an autofixer for the project.
+func NewAutofixer(
+	projectRoot string,
+	cfg config.AutofixConfig,
+	timeoutSecs int,
+	deps *Dependencies,
+) *Autofixer {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &Autofixer{
+		projectRoot: projectRoot,
+		cfg:         cfg,
+		executor:    NewCommandExecutor(timeoutSecs, false, deps),
+		deps:        deps,
+	}
+}
+
+// Fix runs the fixer for filePath and reports which files it changed.
+// A fixer that exits non-zero still counts, since most exit non-zero when
+// findings remain that they cannot fix.
+func (a *Autofixer) Fix(ctx context.Context, filePath string) (*AutofixResult, error) {
+	cmd := a.FixCommand(filePath)
+	if cmd == nil {
+		return nil, ErrNoFixer
+	}
+
+	before := a.snapshot(ctx, filePath)
+	execResult := a.executor.Execute(ctx, cmd)
+	if execResult.TimedOut || ctx.Err() != nil {
+		return nil, fmt.Errorf("%s did not finish: %w", cmd.String(), execResult.Error)
+	}
+	after := a.snapshot(ctx, filePath)
+
+	result := &AutofixResult{Command: cmd}
+	for path, sum := range after {
+		if previous, ok := before[path]; ok && previous == sum {
+			continue
+		}
+		rel, err := filepath.Rel(a.projectRoot, path)
+		if err != nil {
+			rel = path
+		}
+		result.Modified = append(result.Modified, filepath.ToSlash(rel))
+	}
+	slices.Sort(result.Modified)
+	return result, nil
+}
+
+// FixCommand returns the fixer for filePath, or nil if none applies. A
+// configured command for the file's extension wins over the built-in fixers.
+func (a *Autofixer) FixCommand(filePath string) *DiscoveredCommand {
+	ext := filepath.Ext(filePath)
+	dir := filepath.Dir(filePath)
+
+	if args := a.cfg.Commands[ext]; len(args) > 0 {
+		replacer := strings.NewReplacer("{file}", filePath, "{dir}", dir)
+		expanded := make([]string, len(args))
+		for i, arg := range args {
+			expanded[i] = replacer.Replace(arg)
+		}
+		return a.command(a.projectRoot, expanded[0], expanded[1:]...)
+	}
+
+	switch ext {
+	case ".go":
+		// golangci-lint needs the whole package to type-check
+		if a.installed("golangci-lint") {
+			return a.command(dir, "golangci-lint", "run", "--fix", ".")
+		}
+	case ".py", ".pyi":
+		if a.installed("ruff") {
+			return a.command(a.projectRoot, "ruff", "check", "--fix", filePath)
+		}
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".vue":
+		local := filepath.Join(a.projectRoot, "node_modules", ".bin", "eslint")
+		if _, err := a.deps.FS.Stat(local); err == nil {
+			return a.command(a.projectRoot, local, "--fix", filePath)
+		}
+		if a.installed("eslint") {
+			return a.command(a.projectRoot, "eslint", "--fix", filePath)
+		}
+	case ".rs":
+		// Clippy fixes a whole crate at a time
+		if a.installed("cargo") {
+			return a.command(dir, "cargo", "clippy", "--fix", "--allow-dirty", "--allow-staged")
+		}
+	}
+	return nil
+}
+
+func (a *Autofixer) installed(name string) bool {
+	_, err := a.deps.Runner.LookPath(name)
+	return err == nil
+}
+
+func (a *Autofixer) command(dir, name string, args ...string) *DiscoveredCommand {
+	return &DiscoveredCommand{
+		Type:       CommandTypeLint,
+		Command:    name,
+		Args:       args,
+		WorkingDir: dir,
+		Source:     "autofix",
+	}
+}
+
+// snapshot hashes the files a fixer may have touched: every modified or
+// untracked file of the git work tree, or only the edited file outside one.
+// Files a fixer changes that were clean before show up as new entries.
+func (a *Autofixer) snapshot(ctx context.Context, filePath string) map[string][sha256.Size]byte {
+	paths := []string{filePath}
+	dir := filepath.Dir(filePath)
+	if out, err := a.deps.Runner.RunContext(ctx, dir, "git", "rev-parse", "--show-toplevel"); err == nil {
+		topLevel := strings.TrimSpace(string(out.Stdout))
+		status, statusErr := a.deps.Runner.RunContext(ctx, topLevel,
+			"git", "status", "--porcelain=v1", "-z", "--untracked-files=all")
+		if statusErr == nil {
+			for _, path := range parseDirtyPaths(status.Stdout) {
+				paths = append(paths, filepath.Join(topLevel, path))
+			}
+		}
+	}
+
+	sums := make(map[string][sha256.Size]byte, len(paths))
+	for _, path := range paths {
+		if data, err := a.deps.FS.ReadFile(path); err == nil {
+			sums[path] = sha256.Sum256(data)
+		}
+	}
+	return sums
+}
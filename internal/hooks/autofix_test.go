@@ -0,0 +1,207 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestAutofixer_FixCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.AutofixConfig
+		installed []string
+		files     []string
+		path      string
+		want      string
+		wantDir   string
+	}{
+		{
+			name:      "go package",
+			installed: []string{"golangci-lint"},
+			path:      "/project/pkg/a.go",
+			want:      "golangci-lint run --fix .",
+			wantDir:   "/project/pkg",
+		},
+		{
+			name:      "python file",
+			installed: []string{"ruff"},
+			path:      "/project/app/main.py",
+			want:      "ruff check --fix /project/app/main.py",
+			wantDir:   "/project",
+		},
+		{
+			name:    "project eslint",
+			files:   []string{"/project/node_modules/.bin/eslint"},
+			path:    "/project/src/app.ts",
+			want:    "/project/node_modules/.bin/eslint --fix /project/src/app.ts",
+			wantDir: "/project",
+		},
+		{
+			name:      "rust crate",
+			installed: []string{"cargo"},
+			path:      "/project/src/lib.rs",
+			want:      "cargo clippy --fix --allow-dirty --allow-staged",
+			wantDir:   "/project/src",
+		},
+		{
+			name: "configured command",
+			cfg: config.AutofixConfig{Commands: map[string][]string{
+				".go": {"gofumpt", "-w", "{file}"},
+			}},
+			installed: []string{"golangci-lint"},
+			path:      "/project/pkg/a.go",
+			want:      "gofumpt -w /project/pkg/a.go",
+			wantDir:   "/project",
+		},
+		{
+			name: "fixer not installed",
+			path: "/project/app/main.py",
+		},
+		{
+			name:      "unknown extension",
+			installed: []string{"golangci-lint", "ruff", "eslint", "cargo"},
+			path:      "/project/README.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+				for _, name := range tt.installed {
+					if name == file {
+						return "/usr/bin/" + file, nil
+					}
+				}
+				return "", errors.New("not found")
+			}
+			testDeps.MockFS.statFunc = func(name string) (os.FileInfo, error) {
+				for _, file := range tt.files {
+					if file == name {
+						return mockFileInfo{name: name}, nil
+					}
+				}
+				return nil, os.ErrNotExist
+			}
+
+			cmd := NewAutofixer("/project", tt.cfg, 10, testDeps.Dependencies).FixCommand(tt.path)
+			if tt.want == "" {
+				if cmd != nil {
+					t.Errorf("FixCommand() = %s, want none", cmd)
+				}
+				return
+			}
+			if cmd == nil || cmd.String() != tt.want || cmd.WorkingDir != tt.wantDir {
+				t.Errorf("FixCommand() = %+v, want %q in %s", cmd, tt.want, tt.wantDir)
+			}
+		})
+	}
+}
+
+// fixableProject simulates a git project whose lint fails until the fixer
+// rewrites main.go and util.go.
+type fixableProject struct {
+	mu    sync.Mutex
+	files map[string]string
+	fixed bool
+}
+
+func setupFixableProject(deps *TestDependencies) {
+	p := &fixableProject{files: map[string]string{
+		"/project/main.go":  "package main  \n",
+		"/project/util.go":  "package main\n\nfunc  util() {}\n",
+		"/project/dirty.go": "package main // already modified\n",
+	}}
+
+	deps.MockFS.statFunc = func(path string) (os.FileInfo, error) {
+		if path == "/project/Makefile" {
+			return mockFileInfo{name: "Makefile"}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	deps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		content, ok := p.files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	}
+	deps.MockRunner.lookPathFunc = func(file string) (string, error) {
+		if file == "golangci-lint" {
+			return "/usr/bin/golangci-lint", nil
+		}
+		return "", errors.New("not found")
+	}
+	deps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		switch {
+		case name == "git" && args[0] == "rev-parse":
+			return &CommandOutput{Stdout: []byte("/project\n")}, nil
+		case name == "git" && args[0] == "status":
+			status := " M dirty.go\x00"
+			if p.fixed {
+				status += " M main.go\x00 M util.go\x00"
+			}
+			return &CommandOutput{Stdout: []byte(status)}, nil
+		case name == "golangci-lint":
+			p.fixed = true
+			p.files["/project/main.go"] = "package main\n"
+			p.files["/project/util.go"] = "package main\n\nfunc util() {}\n"
+			return &CommandOutput{}, nil
+		case name == "make" && len(args) > 2 && args[len(args)-2] == "-n":
+			return &CommandOutput{}, nil
+		case name == "make" && args[0] == "lint" && !p.fixed:
+			return &CommandOutput{Stdout: []byte("main.go:1:13: trailing whitespace")}, errors.New("exit status 1")
+		case name == "make":
+			return &CommandOutput{}, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+}
+
+func TestAutofixer_FixReportsModifiedFiles(t *testing.T) {
+	testDeps := createTestDependencies()
+	setupFixableProject(testDeps)
+
+	fix, err := NewAutofixer("/project", config.AutofixConfig{}, 10, testDeps.Dependencies).
+		Fix(context.Background(), "/project/main.go")
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+	if strings.Join(fix.Modified, ",") != "main.go,util.go" {
+		t.Errorf("Modified = %v, want main.go and util.go", fix.Modified)
+	}
+}
+
+func TestParallelValidateExecutor_Autofix(t *testing.T) {
+	testDeps := createTestDependencies()
+	setupFixableProject(testDeps)
+
+	executor := NewParallelValidateExecutor("/project", 10, false, nil, testDeps.Dependencies)
+	executor.EnableAutofix(NewAutofixer("/project", config.AutofixConfig{}, 10, testDeps.Dependencies),
+		"/project/main.go")
+
+	result, err := executor.ExecuteValidations(context.Background(), "/project", "/project")
+	if err != nil {
+		t.Fatalf("ExecuteValidations() error = %v", err)
+	}
+	if !result.BothPassed || !result.LintResult.Success {
+		t.Errorf("lint should pass after the fixer ran: %+v", result.LintResult)
+	}
+
+	message := result.FormatMessage()
+	for _, want := range []string{"Validations pass", "golangci-lint run --fix .", "main.go, util.go", "Re-read"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("FormatMessage() = %q, want to contain %q", message, want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// BaselineFile is the name of the lint baseline file in the project root.
+const BaselineFile = ".cc-tools-baseline.json"
+
+// ErrNoBaseline is returned by LoadBaseline when the project has no baseline.
+var ErrNoBaseline = errors.New("no lint baseline")
+
+// maxListedDiagnostics caps how many new diagnostics a blocking message lists.
+const maxListedDiagnostics = 10
+
+// diagnosticPattern matches the common "file:line[:col]: message" format used
+// by golangci-lint, ruff, eslint (unix formatter), gcc, shellcheck (gcc format) and others.
+var diagnosticPattern = regexp.MustCompile(`^([^\s:][^:]*):(\d+)(?::(\d+))?:\s*(.+)$`)
+
+// lintNoisePattern matches lint output lines that report nothing on their
+// own: task runner status and the summaries of golangci-lint, ruff and eslint.
+var lintNoisePattern = regexp.MustCompile(`^(make(\[\d+\])?: (\*\*\*|Entering|Leaving)|error: Recipe .* failed|` +
+	`\d+ issues?\b|\* [\w-]+: \d+$|Found \d+ errors?|\[\*\] \d+ fixable|✖ \d+ problems?|All checks passed)`)
+
+// caretPattern matches the line golangci-lint prints below a finding's source line.
+var caretPattern = regexp.MustCompile(`^\s*\^\s*$`)
+
+// Diagnostic is a single lint finding.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// String formats the diagnostic like a linter would.
+func (d Diagnostic) String() string {
+	if d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// key identifies a diagnostic independently of its position, so findings
+// survive unrelated edits that shift line numbers.
+func (d Diagnostic) key() BaselineEntry {
+	return BaselineEntry{File: d.File, Message: d.Message}
+}
+
+// ParseDiagnostics extracts diagnostics from lint output. File paths are
+// resolved against workingDir and made relative to projectRoot.
+func ParseDiagnostics(output, workingDir, projectRoot string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for line := range strings.SplitSeq(output, "\n") {
+		match := diagnosticPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+
+		file := match[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(workingDir, file)
+		}
+		if rel, err := filepath.Rel(projectRoot, file); err == nil {
+			file = rel
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			File:    filepath.ToSlash(file),
+			Line:    lineNum,
+			Column:  column,
+			Message: strings.TrimSpace(match[4]),
+		})
+	}
+	return diagnostics
+}
+
+// lintOutput returns output without the recipe lines the task runner echoed
+// before running them, so a command line is never mistaken for a finding.
+func lintOutput(output string, cmd *DiscoveredCommand) string {
+	if cmd == nil || len(cmd.Echo) == 0 {
+		return output
+	}
+	echoed := make(map[string]bool, len(cmd.Echo))
+	for _, line := range cmd.Echo {
+		echoed[line] = true
+	}
+	var kept []string
+	for line := range strings.SplitSeq(output, "\n") {
+		if !echoed[strings.TrimSpace(line)] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// unparsedLintLines returns the lines of lint output that are neither
+// findings, their source context nor known noise. Such lines, like a type
+// checking or configuration error, may be why the linter failed.
+func unparsedLintLines(output string) []string {
+	lines := strings.Split(output, "\n")
+	var unparsed []string
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.TrimSpace(line) == "", diagnosticPattern.MatchString(line), lintNoisePattern.MatchString(line),
+			caretPattern.MatchString(line):
+		case i+1 < len(lines) && caretPattern.MatchString(lines[i+1]):
+			// The source line above a caret
+		default:
+			unparsed = append(unparsed, strings.TrimSpace(line))
+		}
+	}
+	return unparsed
+}
+
+// BaselineEntry is a known diagnostic, counted per file and message.
+type BaselineEntry struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+	Count   int    `json:"count,omitempty"`
+}
+
+// Baseline records the lint findings that existed when it was captured.
+// Validation only blocks on findings beyond the baseline.
+type Baseline struct {
+	Command string          `json:"command"`
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// NewBaseline creates a baseline from the given diagnostics.
+func NewBaseline(command string, diagnostics []Diagnostic) *Baseline {
+	b := &Baseline{Command: command}
+	b.setCounts(countDiagnostics(diagnostics))
+	return b
+}
+
+// LoadBaseline reads the baseline of a project. It returns ErrNoBaseline if the project has none.
+func LoadBaseline(projectRoot string, deps *Dependencies) (*Baseline, error) {
+	data, err := deps.FS.ReadFile(filepath.Join(projectRoot, BaselineFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoBaseline
+		}
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+
+	var b Baseline
+	if unmarshalErr := json.Unmarshal(data, &b); unmarshalErr != nil {
+		return nil, fmt.Errorf("parsing baseline: %w", unmarshalErr)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to the project root.
+func (b *Baseline) Save(projectRoot string, deps *Dependencies) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal baseline: %w", err)
+	}
+	const baselineFileMode = 0644 // Meant to be committed with the project
+	path := filepath.Join(projectRoot, BaselineFile)
+	if writeErr := deps.FS.WriteFile(path, append(data, '\n'), baselineFileMode); writeErr != nil {
+		return fmt.Errorf("writing baseline: %w", writeErr)
+	}
+	return nil
+}
+
+// Size returns the number of diagnostics in the baseline.
+func (b *Baseline) Size() int {
+	total := 0
+	for _, entry := range b.Entries {
+		total += max(entry.Count, 1)
+	}
+	return total
+}
+
+// NewDiagnostics returns the diagnostics not covered by the baseline.
+// When a file has more copies of a message than the baseline allows, the
+// excess copies count as new.
+func (b *Baseline) NewDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	remaining := b.counts()
+	var fresh []Diagnostic
+	for _, d := range diagnostics {
+		key := d.key()
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		fresh = append(fresh, d)
+	}
+	return fresh
+}
+
+// Shrink drops baseline entries that no longer occur in diagnostics and never
+// adds new ones. It returns how many diagnostics were removed.
+func (b *Baseline) Shrink(diagnostics []Diagnostic) int {
+	before := b.Size()
+	current := countDiagnostics(diagnostics)
+	shrunk := make(map[BaselineEntry]int)
+	for key, count := range b.counts() {
+		if n := min(count, current[key]); n > 0 {
+			shrunk[key] = n
+		}
+	}
+	b.setCounts(shrunk)
+	return before - b.Size()
+}
+
+// counts returns the baseline as a count per diagnostic key.
+func (b *Baseline) counts() map[BaselineEntry]int {
+	counts := make(map[BaselineEntry]int, len(b.Entries))
+	for _, entry := range b.Entries {
+		counts[BaselineEntry{File: entry.File, Message: entry.Message}] += max(entry.Count, 1)
+	}
+	return counts
+}
+
+// setCounts replaces the entries with the given counts, sorted for stable diffs.
+func (b *Baseline) setCounts(counts map[BaselineEntry]int) {
+	b.Entries = make([]BaselineEntry, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		b.Entries = append(b.Entries, key)
+	}
+	sort.Slice(b.Entries, func(i, j int) bool {
+		if b.Entries[i].File != b.Entries[j].File {
+			return b.Entries[i].File < b.Entries[j].File
+		}
+		return b.Entries[i].Message < b.Entries[j].Message
+	})
+}
+
+// countDiagnostics counts diagnostics per key.
+func countDiagnostics(diagnostics []Diagnostic) map[BaselineEntry]int {
+	counts := make(map[BaselineEntry]int)
+	for _, d := range diagnostics {
+		counts[d.key()]++
+	}
+	return counts
+}
+
+// CaptureLintDiagnostics runs the project's lint command in the validation
+// environment and parses its findings.
+func CaptureLintDiagnostics(
+	ctx context.Context,
+	projectRoot string,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+) (*DiscoveredCommand, []Diagnostic, error) {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	runDeps, _ := setupEnvironment(ctx, projectRoot, cfg.Env, deps, nil)
+
+	discovery := NewCommandDiscovery(projectRoot, cfg.TimeoutSeconds, runDeps)
+	cmd, err := discovery.DiscoverCommand(ctx, CommandTypeLint, projectRoot)
+	if err != nil || cmd == nil {
+		return nil, nil, fmt.Errorf("no lint command found in %s", projectRoot)
+	}
+
+	result := NewCommandExecutor(cfg.TimeoutSeconds, false, runDeps).Execute(ctx, cmd)
+	if result.TimedOut {
+		return cmd, nil, fmt.Errorf("%s: %w", cmd.String(), result.Error)
+	}
+
+	diagnostics := ParseDiagnostics(lintOutput(result.Stdout+"\n"+result.Stderr, cmd), cmd.WorkingDir, projectRoot)
+	if !result.Success && len(diagnostics) == 0 {
+		return cmd, nil, fmt.Errorf("%s failed without output in file:line: message format", cmd.String())
+	}
+	return cmd, diagnostics, nil
+}
+
+// applyBaseline lets lint pass when every finding is already in the project's
+// baseline and the output holds nothing else that could explain the failure.
+// Output that cannot be parsed leaves the failure in place.
+func applyBaseline(result *ValidationResult, projectRoot string, baseline *Baseline) {
+	if result == nil || result.Success || baseline == nil {
+		return
+	}
+
+	output := lintOutput(result.Output, result.Command)
+	diagnostics := ParseDiagnostics(output, result.Command.WorkingDir, projectRoot)
+	if len(diagnostics) == 0 {
+		return
+	}
+
+	fresh := baseline.NewDiagnostics(diagnostics)
+	result.Baselined = len(diagnostics) - len(fresh)
+	result.NewDiagnostics = fresh
+	result.UnparsedLint = unparsedLintLines(output)
+	if len(fresh) == 0 && len(result.UnparsedLint) == 0 {
+		result.Success = true
+	}
+}
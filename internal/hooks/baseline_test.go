@@ -0,0 +1,200 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	output := `main.go:12:5: exported function Foo should have comment (revive)
+/project/pkg/util.go:3: line too long
+make: *** [Makefile:4: lint] Error 1
+level=warning msg="something"
+`
+	got := ParseDiagnostics(output, "/project", "/project")
+	want := []Diagnostic{
+		{File: "main.go", Line: 12, Column: 5, Message: "exported function Foo should have comment (revive)"},
+		{File: "pkg/util.go", Line: 3, Message: "line too long"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDiagnostics() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDiagnostics_SkipsEchoedRecipeLines(t *testing.T) {
+	cmd := &DiscoveredCommand{Type: CommandTypeLint, Command: "make", Args: []string{"lint"}, Echo: []string{
+		"./scripts/check.sh:1: lint",
+	}}
+	output := "  ./scripts/check.sh:1: lint\nmain.go:3: unused variable x\n"
+
+	got := ParseDiagnostics(lintOutput(output, cmd), "/project", "/project")
+	want := []Diagnostic{{File: "main.go", Line: 3, Message: "unused variable x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDiagnostics() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBaseline_NewDiagnostics(t *testing.T) {
+	known := []Diagnostic{
+		{File: "a.go", Line: 1, Message: "unused variable x"},
+		{File: "a.go", Line: 9, Message: "unused variable x"},
+		{File: "b.go", Line: 4, Message: "error not checked"},
+	}
+	baseline := NewBaseline("make lint", known)
+
+	tests := []struct {
+		name    string
+		current []Diagnostic
+		want    int
+	}{
+		{
+			name: "shifted lines are still known",
+			current: []Diagnostic{
+				{File: "a.go", Line: 3, Message: "unused variable x"},
+				{File: "b.go", Line: 40, Message: "error not checked"},
+			},
+			want: 0,
+		},
+		{
+			name: "extra copy of a known message is new",
+			current: append(append([]Diagnostic{}, known...),
+				Diagnostic{File: "a.go", Line: 20, Message: "unused variable x"}),
+			want: 1,
+		},
+		{
+			name:    "finding in another file is new",
+			current: []Diagnostic{{File: "c.go", Line: 1, Message: "error not checked"}},
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := baseline.NewDiagnostics(tt.current); len(got) != tt.want {
+				t.Errorf("NewDiagnostics() = %v, want %d new", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseline_Shrink(t *testing.T) {
+	baseline := NewBaseline("make lint", []Diagnostic{
+		{File: "a.go", Message: "unused variable x"},
+		{File: "a.go", Message: "unused variable x"},
+		{File: "b.go", Message: "error not checked"},
+	})
+
+	removed := baseline.Shrink([]Diagnostic{
+		{File: "a.go", Message: "unused variable x"},
+		{File: "c.go", Message: "brand new"},
+	})
+
+	if removed != 2 || baseline.Size() != 1 {
+		t.Errorf("Shrink() removed %d, size %d; want 2 removed, size 1", removed, baseline.Size())
+	}
+	if len(baseline.Entries) != 1 || baseline.Entries[0].File != "a.go" {
+		t.Errorf("unexpected entries after shrink: %+v", baseline.Entries)
+	}
+}
+
+func TestBaseline_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	deps := NewDefaultDependencies()
+
+	if _, err := LoadBaseline(dir, deps); !errors.Is(err, ErrNoBaseline) {
+		t.Fatalf("LoadBaseline() error = %v, want ErrNoBaseline", err)
+	}
+
+	baseline := NewBaseline("make lint", []Diagnostic{{File: "a.go", Line: 1, Message: "m"}})
+	if err := baseline.Save(dir, deps); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadBaseline(dir, deps)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, baseline) {
+		t.Errorf("LoadBaseline() = %+v, want %+v", loaded, baseline)
+	}
+}
+
+func TestValidation_BaselineOnlyBlocksNewFindings(t *testing.T) {
+	tests := []struct {
+		name       string
+		lintOutput string
+		wantPass   bool
+		wantText   string
+	}{
+		{
+			name:       "known findings pass",
+			lintOutput: "main.go:10:2: ineffectual assignment to err\n",
+			wantPass:   true,
+			wantText:   "1 known lint finding(s) ignored",
+		},
+		{
+			name:       "new finding blocks and is listed",
+			lintOutput: "main.go:10:2: ineffectual assignment to err\nnew.go:1:1: missing return\n",
+			wantPass:   false,
+			wantText:   "new.go:1:1: missing return",
+		},
+		{
+			name: "known findings with context and summary pass",
+			lintOutput: "main.go:10:2: ineffectual assignment to err\n\terr = run()\n\t^\n" +
+				"1 issues:\n* ineffassign: 1\nmake: *** [Makefile:4: lint] Error 1\n",
+			wantPass: true,
+			wantText: "1 known lint finding(s) ignored",
+		},
+		{
+			name: "known findings next to a typecheck error block",
+			lintOutput: "main.go:10:2: ineffectual assignment to err\n" +
+				"level=error msg=\"Running error: context loading failed: no go files to analyze\"\n",
+			wantPass: false,
+			wantText: "Lint output that is not a finding:\n  level=error msg=\"Running error",
+		},
+		{
+			name:       "unparseable failure still blocks",
+			lintOutput: "something went wrong\n",
+			wantPass:   false,
+			wantText:   "to fix lint failures",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			baselineData := `{"command":"make lint","entries":[{"file":"main.go","message":"ineffectual assignment to err"}]}`
+			testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+				if name == "/project/"+BaselineFile {
+					return []byte(baselineData), nil
+				}
+				return nil, os.ErrNotExist
+			}
+			setupQueueProject(testDeps, func() bool { return false })
+			baseRunner := testDeps.MockRunner.runContextFunc
+			testDeps.MockRunner.runContextFunc = func(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error) {
+				if name == "make" && len(args) == 1 && args[0] == "lint" {
+					return &CommandOutput{Stdout: []byte(tt.lintOutput)}, errors.New("exit status 1")
+				}
+				return baseRunner(ctx, dir, name, args...)
+			}
+
+			exitCode := RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+			if exitCode != ExitCodeShowMessage {
+				t.Fatalf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+			}
+
+			stderr := testDeps.MockStderr.String()
+			if passed := strings.Contains(stderr, "Validations pass"); passed != tt.wantPass {
+				t.Errorf("passed = %v, want %v; output %q", passed, tt.wantPass, stderr)
+			}
+			if !strings.Contains(stderr, tt.wantText) {
+				t.Errorf("expected %q in output, got %q", tt.wantText, stderr)
+			}
+		})
+	}
+}
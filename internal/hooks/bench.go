@@ -0,0 +1,389 @@
+package hooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// ErrNoBenchmarks is returned when no benchmark exercises an edited file.
+var ErrNoBenchmarks = errors.New("no benchmarks")
+
+// ErrNoBenchBaseline is returned when no recent commit has a benchmark baseline.
+var ErrNoBenchBaseline = errors.New("no benchmark baseline")
+
+// benchBaselineDepth is how many commits back from HEAD a baseline is looked for.
+const benchBaselineDepth = 50
+
+// shortCommitLength is the length of abbreviated commit hashes in messages.
+const shortCommitLength = 7
+
+// BenchBaseline is the benchmark results of a project at a commit, keyed by
+// package import path and benchmark name.
+type BenchBaseline struct {
+	Commit   string                              `json:"commit"`
+	Packages map[string]map[string]*BenchSamples `json:"packages"`
+}
+
+// BenchGuard runs the benchmarks that exercise an edited Go file and compares
+// them with the baseline of the nearest commit that has one.
+type BenchGuard struct {
+	projectRoot string
+	cfg         config.BenchConfig
+	executor    *CommandExecutor
+	deps        *Dependencies
+	severity    string
+}
+
+// NewBenchGuard creates a benchmark guard for the project.
+func NewBenchGuard(
+	projectRoot string,
+	cfg config.BenchConfig,
+	timeoutSecs int,
+	deps *Dependencies,
+) *BenchGuard {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &BenchGuard{
+		projectRoot: projectRoot,
+		cfg:         cfg,
+		executor:    NewCommandExecutor(timeoutSecs, false, deps),
+		deps:        deps,
+		severity:    config.SeverityBlock,
+	}
+}
+
+// Check runs the benchmarks of the edited file's package that exercise it and
+// returns a failure message if any of them regressed significantly.
+func (g *BenchGuard) Check(ctx context.Context, filePath string) (string, error) {
+	benchmarks, err := g.benchmarksFor(filePath)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(filePath)
+	moduleRoot, modulePath, err := findGoModule(g.deps.FS, g.projectRoot, dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(moduleRoot, dir)
+	if err != nil {
+		return "", fmt.Errorf("relative path: %w", err)
+	}
+	importPath := path.Join(modulePath, filepath.ToSlash(rel))
+
+	baseline, err := g.LoadBaseline(ctx)
+	if err != nil {
+		return "", err
+	}
+	base := baseline.Packages[importPath]
+	// Only run benchmarks that can be compared
+	benchmarks = slices.DeleteFunc(benchmarks, func(name string) bool {
+		for recorded := range base {
+			if top, _, _ := strings.Cut(recorded, "/"); top == name {
+				return false
+			}
+		}
+		return true
+	})
+	if len(benchmarks) == 0 {
+		return "", fmt.Errorf("%s in baseline of %s: %w", importPath, baseline.Commit, ErrNoBenchBaseline)
+	}
+
+	cmd := benchCommand(g.cfg, dir, "^("+strings.Join(benchmarks, "|")+")$", ".")
+	result := g.executor.Execute(ctx, cmd)
+	if !result.Success {
+		return "", fmt.Errorf("%s: %w", cmd.String(), result.Error)
+	}
+
+	current := ParseBenchOutput(result.Stdout)[importPath]
+	regressions := compareBenchmarks(base, current, g.cfg.GetThreshold(), g.cfg.GetAlpha())
+	if len(regressions) == 0 {
+		return "", nil
+	}
+	lines := make([]string, 0, len(regressions))
+	for _, regression := range regressions {
+		lines = append(lines, "  "+regression.String())
+	}
+	return formatFailure(g.severity, "Benchmarks of %s regressed against the baseline of %s:\n%s",
+		importPath, baseline.Commit[:min(len(baseline.Commit), shortCommitLength)], strings.Join(lines, "\n")), nil
+}
+
+// benchmarksFor returns the benchmarks in the package of a Go file that
+// exercise it: those referring to a name it declares, or all benchmarks in it
+// when it is a test file itself.
+func (g *BenchGuard) benchmarksFor(filePath string) ([]string, error) {
+	if filepath.Ext(filePath) != ".go" {
+		return nil, ErrNoBenchmarks
+	}
+	fset := token.NewFileSet()
+	edited, err := g.parse(fset, filePath)
+	if err != nil {
+		return nil, err
+	}
+	declared := declaredNames(edited)
+
+	dir := filepath.Dir(filePath)
+	entries, err := g.deps.FS.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read package directory: %w", err)
+	}
+	var benchmarks []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		testPath := filepath.Join(dir, entry.Name())
+		file := edited
+		if testPath != filePath {
+			if file, err = g.parse(fset, testPath); err != nil {
+				continue
+			}
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil || !strings.HasPrefix(fn.Name.Name, "Benchmark") {
+				continue
+			}
+			if testPath == filePath || refersTo(fn.Body, declared) {
+				benchmarks = append(benchmarks, fn.Name.Name)
+			}
+		}
+	}
+	if len(benchmarks) == 0 {
+		return nil, fmt.Errorf("%s: %w", filePath, ErrNoBenchmarks)
+	}
+	slices.Sort(benchmarks)
+	return benchmarks, nil
+}
+
+// parse parses a Go file through the filesystem dependency.
+func (g *BenchGuard) parse(fset *token.FileSet, filePath string) (*ast.File, error) {
+	data, err := g.deps.FS.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filePath, err)
+	}
+	file, err := parser.ParseFile(fset, filePath, data, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filePath, err)
+	}
+	return file, nil
+}
+
+// declaredNames returns the names of the top-level functions, methods, types,
+// variables and constants of a file.
+func declaredNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	add := func(ident *ast.Ident) {
+		if ident.Name != "_" && ident.Name != "init" {
+			names[ident.Name] = true
+		}
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			add(decl.Name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						add(name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// refersTo reports whether a node mentions any of the names. Matching by name
+// alone over-selects when a local shadows a declaration, which only costs a
+// benchmark run.
+func refersTo(node ast.Node, names map[string]bool) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && names[ident.Name] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// LoadBaseline returns the baseline of the nearest commit from HEAD that has one.
+func (g *BenchGuard) LoadBaseline(ctx context.Context) (*BenchBaseline, error) {
+	out, err := g.deps.Runner.RunContext(ctx, g.projectRoot, "git", "rev-list",
+		"--max-count="+strconv.Itoa(benchBaselineDepth), "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git rev-list: %w", err)
+	}
+	for _, commit := range strings.Fields(string(out.Stdout)) {
+		baseline, loadErr := loadBenchBaseline(g.cfg, g.projectRoot, commit, g.deps)
+		if loadErr == nil {
+			return baseline, nil
+		}
+	}
+	return nil, ErrNoBenchBaseline
+}
+
+// CaptureBenchBaseline runs the benchmarks of the project as committed at HEAD,
+// in a temporary worktree so uncommitted edits do not leak into the baseline,
+// and stores them as the baseline of that commit. Without packages it runs
+// every package; otherwise only the given packages are replaced in an
+// existing baseline of the commit.
+func CaptureBenchBaseline(
+	ctx context.Context,
+	projectRoot string,
+	cfg config.BenchConfig,
+	timeoutSecs int,
+	packages []string,
+	deps *Dependencies,
+) (*BenchBaseline, error) {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	out, err := deps.Runner.RunContext(ctx, projectRoot, "git", "rev-parse", "--show-toplevel", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("git rev-parse: %w", err)
+	}
+	fields := strings.Fields(string(out.Stdout))
+	const revParseFields = 2
+	if len(fields) != revParseFields {
+		return nil, fmt.Errorf("unexpected git rev-parse output %q", out.Stdout)
+	}
+	topLevel, commit := fields[0], fields[1]
+	rel, err := filepath.Rel(topLevel, projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("relative path: %w", err)
+	}
+
+	worktree := filepath.Join(deps.FS.TempDir(),
+		fmt.Sprintf("cc-tools-bench-%d-%d", deps.Process.GetPID(), deps.Clock.Now().UnixNano()))
+	if _, addErr := deps.Runner.RunContext(ctx, projectRoot, "git", "worktree", "add", "--detach",
+		worktree, commit); addErr != nil {
+		return nil, fmt.Errorf("git worktree add: %w", addErr)
+	}
+	defer func() {
+		_, _ = deps.Runner.RunContext(context.WithoutCancel(ctx), projectRoot, "git", "worktree", "remove",
+			"--force", worktree)
+	}()
+
+	partial := len(packages) > 0
+	if !partial {
+		packages = []string{"./..."}
+	}
+	cmd := benchCommand(cfg, filepath.Join(worktree, rel), ".", packages...)
+	result := NewCommandExecutor(timeoutSecs, false, deps).Execute(ctx, cmd)
+	if !result.Success {
+		return nil, fmt.Errorf("%s: %w", cmd.String(), result.Error)
+	}
+	measured := ParseBenchOutput(result.Stdout)
+	if len(measured) == 0 {
+		return nil, fmt.Errorf("%s: %w", cmd.String(), ErrNoBenchmarks)
+	}
+
+	baseline := &BenchBaseline{Commit: commit, Packages: measured}
+	if existing, loadErr := loadBenchBaseline(cfg, projectRoot, commit, deps); loadErr == nil && partial {
+		for importPath, benchmarks := range measured {
+			existing.Packages[importPath] = benchmarks
+		}
+		baseline = existing
+	}
+	if saveErr := saveBenchBaseline(cfg, projectRoot, baseline, deps); saveErr != nil {
+		return nil, saveErr
+	}
+	return baseline, nil
+}
+
+// benchCommand builds a go test command that runs only the matching benchmarks.
+func benchCommand(cfg config.BenchConfig, dir, pattern string, packages ...string) *DiscoveredCommand {
+	args := []string{
+		"test", "-run", "^$", "-bench", pattern, "-benchmem",
+		"-count", strconv.Itoa(cfg.GetCount()), "-benchtime", cfg.GetBenchtime(),
+	}
+	return &DiscoveredCommand{
+		Type:       CommandTypeTest,
+		Command:    "go",
+		Args:       append(args, packages...),
+		WorkingDir: dir,
+		Source:     "bench",
+	}
+}
+
+// benchBaselinePath returns the baseline file of a project at a commit.
+func benchBaselinePath(cfg config.BenchConfig, projectRoot, commit string) string {
+	hash := sha256.Sum256([]byte(projectRoot))
+	return filepath.Join(cfg.GetDir(), fmt.Sprintf("%x", hash[:8]), commit+".json")
+}
+
+// loadBenchBaseline reads the baseline of a project at a commit.
+func loadBenchBaseline(cfg config.BenchConfig, projectRoot, commit string, deps *Dependencies) (*BenchBaseline, error) {
+	data, err := deps.FS.ReadFile(benchBaselinePath(cfg, projectRoot, commit))
+	if err != nil {
+		return nil, fmt.Errorf("read benchmark baseline: %w", err)
+	}
+	var baseline BenchBaseline
+	if unmarshalErr := json.Unmarshal(data, &baseline); unmarshalErr != nil {
+		return nil, fmt.Errorf("parse benchmark baseline: %w", unmarshalErr)
+	}
+	if baseline.Packages == nil {
+		baseline.Packages = make(map[string]map[string]*BenchSamples)
+	}
+	return &baseline, nil
+}
+
+// saveBenchBaseline writes the baseline of a project at its commit.
+func saveBenchBaseline(cfg config.BenchConfig, projectRoot string, baseline *BenchBaseline, deps *Dependencies) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal benchmark baseline: %w", err)
+	}
+	file := benchBaselinePath(cfg, projectRoot, baseline.Commit)
+	if mkdirErr := deps.FS.MkdirAll(filepath.Dir(file), cacheDirMode); mkdirErr != nil {
+		return fmt.Errorf("create benchmark baseline dir: %w", mkdirErr)
+	}
+	if writeErr := deps.FS.WriteFile(file, data, lockFileMode); writeErr != nil {
+		return fmt.Errorf("write benchmark baseline: %w", writeErr)
+	}
+	return nil
+}
+
+// checkBenchmarks runs the benchmark guard for the edited file when enabled
+// and the tests passed. It returns a failure message, or "" when nothing
+// regressed or there was nothing to compare.
+func checkBenchmarks(
+	ctx context.Context,
+	projectRoot, filePath string,
+	cfg *config.ValidateConfig,
+	result *ValidateResult,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) string {
+	if !cfg.Bench.Enabled || result.TestResult == nil || !result.TestResult.Success {
+		return ""
+	}
+
+	guard := NewBenchGuard(projectRoot, cfg.Bench, cfg.TimeoutSeconds, deps)
+	guard.severity = cfg.Severity.Get(config.CheckBench)
+	message, err := guard.Check(ctx, filePath)
+	if err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "checking benchmarks")
+	}
+	return message
+}
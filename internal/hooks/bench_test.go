@@ -0,0 +1,231 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// benchProject is a Go module on a fake filesystem with benchmarks for parser.go only.
+type benchProject struct {
+	mu       sync.Mutex
+	files    fstest.MapFS
+	commands []string
+	// benchOutput is the output of go test -bench.
+	benchOutput string
+}
+
+func setupBenchProject(deps *TestDependencies) *benchProject {
+	p := &benchProject{files: fstest.MapFS{
+		"project/go.mod":                {Data: []byte("module example.com/m\n")},
+		"project/parser/parser.go":      {Data: []byte("package parser\n\nfunc Parse(s string) int { return len(s) }\n")},
+		"project/parser/lex.go":         {Data: []byte("package parser\n\nfunc Lex() {}\n")},
+		"project/parser/parser_test.go": {Data: []byte(parserBenchmarks)},
+	}}
+
+	path := func(name string) string { return strings.TrimPrefix(name, "/") }
+	deps.MockFS.readDirFunc = func(name string) ([]os.DirEntry, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return fs.ReadDir(p.files, path(name))
+	}
+	deps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return fs.ReadFile(p.files, path(name))
+	}
+	deps.MockFS.writeFileFunc = func(name string, data []byte, _ os.FileMode) error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.files[path(name)] = &fstest.MapFile{Data: data}
+		return nil
+	}
+	deps.MockRunner.runContextFunc = func(_ context.Context, dir, name string, args ...string) (*CommandOutput, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		command := strings.Join(append([]string{name}, args...), " ")
+		p.commands = append(p.commands, dir+": "+command)
+		switch {
+		case strings.HasPrefix(command, "git rev-list"):
+			return &CommandOutput{Stdout: []byte("c3\nc2\nc1\n")}, nil
+		case strings.HasPrefix(command, "git rev-parse"):
+			return &CommandOutput{Stdout: []byte("/repo\nc3\n")}, nil
+		case strings.HasPrefix(command, "git worktree"):
+			return &CommandOutput{}, nil
+		case strings.HasPrefix(command, "go test"):
+			return &CommandOutput{Stdout: []byte(p.benchOutput)}, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+	return p
+}
+
+const parserBenchmarks = `package parser
+
+import "testing"
+
+func BenchmarkParse(b *testing.B) {
+	for b.Loop() {
+		Parse("input")
+	}
+}
+
+func BenchmarkUnrelated(b *testing.B) {
+	for b.Loop() {
+	}
+}
+`
+
+// parserBenchOutput is go test -bench output of BenchmarkParse taking ns nanoseconds.
+func parserBenchOutput(ns ...string) string {
+	output := "pkg: example.com/m/parser\n"
+	for _, v := range ns {
+		output += "BenchmarkParse-8   1000   " + v + " ns/op   16 B/op   1 allocs/op\n"
+	}
+	return output
+}
+
+func (p *benchProject) storeBaseline(cfg config.BenchConfig, commit string, output string) {
+	data, _ := json.Marshal(&BenchBaseline{Commit: commit, Packages: ParseBenchOutput(output)})
+	p.files[strings.TrimPrefix(benchBaselinePath(cfg, "/project", commit), "/")] = &fstest.MapFile{Data: data}
+}
+
+func TestBenchGuard_Check(t *testing.T) {
+	baseOutput := parserBenchOutput("100", "101", "102", "103", "104", "105")
+	tests := []struct {
+		name         string
+		file         string
+		severity     string
+		baseline     bool
+		current      string
+		wantErr      error
+		wantMessages []string
+	}{
+		{
+			name:         "regression blocks",
+			file:         "/project/parser/parser.go",
+			severity:     config.SeverityBlock,
+			baseline:     true,
+			current:      parserBenchOutput("130", "131", "132", "133", "134", "135"),
+			wantMessages: []string{"BLOCKING", "example.com/m/parser", "baseline of c2", "BenchmarkParse ns/op"},
+		},
+		{
+			name:         "regression warns",
+			file:         "/project/parser/parser_test.go",
+			severity:     config.SeverityWarn,
+			baseline:     true,
+			current:      parserBenchOutput("130", "131", "132", "133", "134", "135"),
+			wantMessages: []string{"ADVISORY", "BenchmarkParse ns/op: 102.5 → 132.5"},
+		},
+		{
+			name:     "noise passes",
+			file:     "/project/parser/parser.go",
+			severity: config.SeverityBlock,
+			baseline: true,
+			current:  parserBenchOutput("101", "103", "99", "104", "102", "100"),
+		},
+		{
+			name:     "no baseline",
+			file:     "/project/parser/parser.go",
+			severity: config.SeverityBlock,
+			wantErr:  ErrNoBenchBaseline,
+		},
+		{
+			name:     "no benchmark exercises the file",
+			file:     "/project/parser/lex.go",
+			severity: config.SeverityBlock,
+			baseline: true,
+			wantErr:  ErrNoBenchmarks,
+		},
+		{
+			name:     "not a Go file",
+			file:     "/project/README.md",
+			severity: config.SeverityBlock,
+			baseline: true,
+			wantErr:  ErrNoBenchmarks,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			project := setupBenchProject(testDeps)
+			project.benchOutput = tt.current
+			cfg := config.BenchConfig{Enabled: true, Dir: "/cache/bench"}
+			if tt.baseline {
+				project.storeBaseline(cfg, "c2", baseOutput)
+			}
+
+			guard := NewBenchGuard("/project", cfg, 10, testDeps.Dependencies)
+			guard.severity = tt.severity
+			message, err := guard.Check(context.Background(), tt.file)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if (message == "") != (len(tt.wantMessages) == 0) {
+				t.Fatalf("Check() message = %q", message)
+			}
+			for _, want := range tt.wantMessages {
+				if !strings.Contains(message, want) {
+					t.Errorf("message missing %q:\n%s", want, message)
+				}
+			}
+			if tt.current != "" {
+				want := "/project/parser: go test -run ^$ -bench ^(BenchmarkParse)$ -benchmem -count 6 -benchtime 100ms ."
+				if got := project.commands[len(project.commands)-1]; got != want {
+					t.Errorf("ran %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCaptureBenchBaseline(t *testing.T) {
+	testDeps := createTestDependencies()
+	project := setupBenchProject(testDeps)
+	project.benchOutput = parserBenchOutput("100", "110") + "pkg: example.com/m/lexer\nBenchmarkLex-8  10  50 ns/op\n"
+	cfg := config.BenchConfig{Dir: "/cache/bench", Count: 2}
+
+	// Project root /repo/project inside the repository /repo
+	baseline, err := CaptureBenchBaseline(context.Background(), "/repo/project", cfg, 10, nil, testDeps.Dependencies)
+	if err != nil {
+		t.Fatalf("CaptureBenchBaseline() error = %v", err)
+	}
+	if baseline.Commit != "c3" || len(baseline.Packages) != 2 {
+		t.Errorf("baseline = %+v, want both packages at c3", baseline)
+	}
+
+	worktree := "/tmp/cc-tools-bench-12345-"
+	commands := strings.Join(project.commands, "\n")
+	for _, want := range []string{
+		"/repo/project: git worktree add --detach " + worktree,
+		"/project: go test -run ^$ -bench . -benchmem -count 2 -benchtime 100ms ./...",
+		"/repo/project: git worktree remove --force " + worktree,
+	} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("commands missing %q:\n%s", want, commands)
+		}
+	}
+
+	// Refreshing one package keeps the others
+	project.benchOutput = parserBenchOutput("90", "95")
+	if _, err = CaptureBenchBaseline(context.Background(), "/repo/project", cfg, 10, []string{"./parser"},
+		testDeps.Dependencies); err != nil {
+		t.Fatalf("CaptureBenchBaseline(./parser) error = %v", err)
+	}
+	stored, err := loadBenchBaseline(cfg, "/repo/project", "c3", testDeps.Dependencies)
+	if err != nil {
+		t.Fatalf("loadBenchBaseline() error = %v", err)
+	}
+	if len(stored.Packages) != 2 || stored.Packages["example.com/m/parser"]["BenchmarkParse"].NsPerOp[0] != 90 {
+		t.Errorf("stored baseline = %+v, want refreshed parser and kept lexer", stored.Packages)
+	}
+}
@@ -0,0 +1,286 @@
+package hooks
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Benchmark metrics compared against the baseline.
+const (
+	MetricNsPerOp     = "ns/op"
+	MetricAllocsPerOp = "allocs/op"
+)
+
+// half appears in medians, average ranks and the moments of U.
+const half = 2
+
+// uVarianceDivisor is the divisor of the variance of U without ties.
+const uVarianceDivisor = 12
+
+// continuityCorrection adjusts the discrete U statistic for the normal approximation.
+const continuityCorrection = 0.5
+
+// maxExactSamples bounds the sample sizes for which the exact distribution of
+// the Mann-Whitney U statistic is computed instead of its normal approximation.
+const maxExactSamples = 50
+
+// BenchSamples holds the measurements of one benchmark across runs.
+type BenchSamples struct {
+	NsPerOp     []float64 `json:"ns_per_op"`
+	AllocsPerOp []float64 `json:"allocs_per_op,omitempty"`
+}
+
+// metric returns the samples of a metric.
+func (s *BenchSamples) metric(name string) []float64 {
+	if name == MetricAllocsPerOp {
+		return s.AllocsPerOp
+	}
+	return s.NsPerOp
+}
+
+// BenchRegression is a significant slowdown of a benchmark metric.
+type BenchRegression struct {
+	Benchmark string
+	Metric    string
+	Base      float64
+	Current   float64
+	// P is the p-value of the Mann-Whitney U test between the two sample sets.
+	P float64
+}
+
+// Delta returns the change of the median in percent.
+func (r BenchRegression) Delta() float64 {
+	const percent = 100
+	if r.Base == 0 {
+		return math.Inf(1)
+	}
+	return (r.Current - r.Base) / r.Base * percent
+}
+
+// String formats the regression as "BenchmarkX allocs/op: 2 → 5 (+150.0%, p=0.002)".
+func (r BenchRegression) String() string {
+	delta := "new"
+	if !math.IsInf(r.Delta(), 0) {
+		delta = fmt.Sprintf("%+.1f%%", r.Delta())
+	}
+	return fmt.Sprintf("%s %s: %s → %s (%s, p=%.3f)", r.Benchmark, r.Metric,
+		strconv.FormatFloat(r.Base, 'g', -1, 64), strconv.FormatFloat(r.Current, 'g', -1, 64), delta, r.P)
+}
+
+// ParseBenchOutput extracts benchmark results from `go test -bench -benchmem`
+// output, keyed by package import path and benchmark name. Result lines read
+// "BenchmarkParse-8  1000  1234 ns/op  512 B/op  7 allocs/op"; the GOMAXPROCS
+// suffix is dropped so results compare across machines.
+func ParseBenchOutput(output string) map[string]map[string]*BenchSamples {
+	results := make(map[string]map[string]*BenchSamples)
+	pkg := ""
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(name)
+			continue
+		}
+		fields := strings.Fields(line)
+		// Name, iterations and at least one value/unit pair
+		const minBenchFields = 4
+		if len(fields) < minBenchFields || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		if results[pkg] == nil {
+			results[pkg] = make(map[string]*BenchSamples)
+		}
+		samples := results[pkg][name]
+		if samples == nil {
+			samples = &BenchSamples{}
+			results[pkg][name] = samples
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case MetricNsPerOp:
+				samples.NsPerOp = append(samples.NsPerOp, value)
+			case MetricAllocsPerOp:
+				samples.AllocsPerOp = append(samples.AllocsPerOp, value)
+			}
+		}
+	}
+	return results
+}
+
+// compareBenchmarks returns the metrics of benchmarks whose median grew by
+// more than threshold percent with a p-value below alpha. Benchmarks missing
+// from either side are not compared.
+func compareBenchmarks(base, current map[string]*BenchSamples, threshold, alpha float64) []BenchRegression {
+	var regressions []BenchRegression
+	for _, name := range sortedKeys(current) {
+		old, ok := base[name]
+		if !ok {
+			continue
+		}
+		for _, metric := range []string{MetricNsPerOp, MetricAllocsPerOp} {
+			x, y := old.metric(metric), current[name].metric(metric)
+			if len(x) == 0 || len(y) == 0 {
+				continue
+			}
+			regression := BenchRegression{Benchmark: name, Metric: metric, Base: median(x), Current: median(y)}
+			if regression.Current <= regression.Base || regression.Delta() <= threshold {
+				continue
+			}
+			regression.P = mannWhitneyU(x, y)
+			if regression.P < alpha {
+				regressions = append(regressions, regression)
+			}
+		}
+	}
+	return regressions
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// median returns the median of a non-empty sample.
+func median(sample []float64) float64 {
+	sorted := slices.Sorted(slices.Values(sample))
+	mid := len(sorted) / half
+	if len(sorted)%half == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / half
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test that
+// x and y come from the same distribution. Like benchstat, it uses the exact
+// distribution of U for small samples without ties and a normal approximation
+// with tie correction otherwise.
+func mannWhitneyU(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	type ranked struct {
+		value float64
+		fromX bool
+	}
+	all := make([]ranked, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, ranked{v, true})
+	}
+	for _, v := range y {
+		all = append(all, ranked{v, false})
+	}
+	slices.SortFunc(all, func(a, b ranked) int {
+		switch {
+		case a.value < b.value:
+			return -1
+		case a.value > b.value:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// Sum the ranks of x, giving tied values the average of their ranks
+	rankSum, tieTerm := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		// Values i..j-1 share the average of ranks i+1..j
+		rank := float64(i+j+1) / half
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+	u := rankSum - float64(n1*(n1+1))/half
+	// Test the smaller of U and its mirror so one tail suffices
+	u = math.Min(u, float64(n1*n2)-u)
+
+	if tieTerm == 0 && n1 <= maxExactSamples && n2 <= maxExactSamples {
+		// Two-sided: the distribution of U is symmetric
+		return math.Min(1, half*exactUCDF(int(u), n1, n2))
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / half
+	variance := float64(n1*n2) / uVarianceDivisor * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (mean - u - continuityCorrection) / math.Sqrt(variance)
+	if z <= 0 {
+		return 1
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactUCDF returns P(U <= u) for samples of sizes n1 and n2 without ties, by
+// counting the orderings of the two samples that give each value of U.
+func exactUCDF(u, n1, n2 int) float64 {
+	// counts[j][k] is the number of orderings of i x-values and j y-values
+	// with U == k, built up one x-value at a time
+	counts := make([][]float64, n2+1)
+	for j := range counts {
+		counts[j] = make([]float64, u+1)
+		counts[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		next := make([][]float64, n2+1)
+		next[0] = make([]float64, u+1)
+		next[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			next[j] = make([]float64, u+1)
+			for k := 0; k <= u; k++ {
+				// The largest value is either an x, which exceeds all j y-values,
+				// or a y, which exceeds nothing
+				next[j][k] = next[j-1][k]
+				if k >= j {
+					next[j][k] += counts[j][k-j]
+				}
+			}
+		}
+		counts = next
+	}
+
+	total := 0.0
+	for _, c := range counts[n2] {
+		total += c
+	}
+	return total / binomial(n1+n2, n1)
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package hooks
+
+import (
+	"math"
+	"testing"
+)
+
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: example.com/m/parser
+cpu: AMD EPYC
+BenchmarkParse-8         	   10000	      1200 ns/op	     512 B/op	       7 allocs/op
+BenchmarkParse-8         	   10000	      1250 ns/op	     512 B/op	       7 allocs/op
+BenchmarkParse/large-8   	     100	    120000 ns/op
+PASS
+ok  	example.com/m/parser	1.234s
+pkg: example.com/m/lexer
+BenchmarkLex-16          	   50000	       300 ns/op
+--- FAIL: BenchmarkBroken
+`
+
+func TestParseBenchOutput(t *testing.T) {
+	results := ParseBenchOutput(benchOutput)
+
+	parse := results["example.com/m/parser"]["BenchmarkParse"]
+	if parse == nil || len(parse.NsPerOp) != 2 || parse.NsPerOp[1] != 1250 || len(parse.AllocsPerOp) != 2 {
+		t.Errorf("BenchmarkParse = %+v", parse)
+	}
+	if large := results["example.com/m/parser"]["BenchmarkParse/large"]; large == nil || large.AllocsPerOp != nil {
+		t.Errorf("BenchmarkParse/large = %+v, want ns/op only", large)
+	}
+	if lex := results["example.com/m/lexer"]["BenchmarkLex"]; lex == nil || lex.NsPerOp[0] != 300 {
+		t.Errorf("BenchmarkLex = %+v", lex)
+	}
+	if len(results["example.com/m/lexer"]) != 1 {
+		t.Errorf("lexer results = %v, want only BenchmarkLex", results["example.com/m/lexer"])
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+	}{
+		{
+			// 2 of the C(12,6) = 924 orderings are this extreme
+			name: "separated samples without ties",
+			x:    []float64{1, 2, 3, 4, 5, 6},
+			y:    []float64{7, 8, 9, 10, 11, 12},
+			want: 2.0 / 924,
+		},
+		{
+			name: "identical samples",
+			x:    []float64{5, 5, 5},
+			y:    []float64{5, 5, 5},
+			want: 1,
+		},
+		{
+			name: "interleaved samples",
+			x:    []float64{1, 3, 5, 7},
+			y:    []float64{2, 4, 6, 8},
+			want: 0.686,
+		},
+		{
+			// Normal approximation with tie correction
+			name: "separated samples with ties",
+			x:    []float64{7, 7, 7, 7, 7, 7},
+			y:    []float64{8, 8, 8, 8, 8, 8},
+			want: 0.0013,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mannWhitneyU(tt.x, tt.y)
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("mannWhitneyU() = %.4f, want %.4f", got, tt.want)
+			}
+			if mirrored := mannWhitneyU(tt.y, tt.x); math.Abs(mirrored-got) > 1e-9 {
+				t.Errorf("mannWhitneyU() is not symmetric: %.4f vs %.4f", got, mirrored)
+			}
+		})
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	base := map[string]*BenchSamples{
+		"BenchmarkFast":  {NsPerOp: []float64{100, 101, 102, 103, 104, 105}, AllocsPerOp: []float64{0, 0, 0, 0, 0, 0}},
+		"BenchmarkNoisy": {NsPerOp: []float64{100, 150, 90, 160, 95, 140}},
+		"BenchmarkSame":  {NsPerOp: []float64{100, 101, 102, 103, 104, 105}},
+		"BenchmarkGone":  {NsPerOp: []float64{100}},
+	}
+	current := map[string]*BenchSamples{
+		"BenchmarkFast":  {NsPerOp: []float64{130, 131, 132, 133, 134, 135}, AllocsPerOp: []float64{1, 1, 1, 1, 1, 1}},
+		"BenchmarkNoisy": {NsPerOp: []float64{110, 160, 95, 170, 100, 150}},
+		"BenchmarkSame":  {NsPerOp: []float64{101, 102, 103, 104, 105, 106}},
+		"BenchmarkNew":   {NsPerOp: []float64{1000}},
+	}
+
+	regressions := compareBenchmarks(base, current, 5, 0.05)
+	if len(regressions) != 2 {
+		t.Fatalf("regressions = %v, want ns/op and allocs/op of BenchmarkFast", regressions)
+	}
+	if got := regressions[0].String(); got != "BenchmarkFast ns/op: 102.5 → 132.5 (+29.3%, p=0.002)" {
+		t.Errorf("first regression = %q", got)
+	}
+	if got := regressions[1].String(); got != "BenchmarkFast allocs/op: 0 → 1 (new, p=0.001)" {
+		t.Errorf("second regression = %q", got)
+	}
+}
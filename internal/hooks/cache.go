@@ -0,0 +1,216 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+const cacheDirMode = 0700
+
+// cacheEntry is a stored validation result.
+type cacheEntry struct {
+	Command   string `json:"command"`
+	Success   bool   `json:"success"`
+	ExitCode  int    `json:"exit_code"`
+	Output    string `json:"output,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// ResultCache stores pass/fail results keyed by command and tree content, so
+// a tree that was already validated is not validated again.
+type ResultCache struct {
+	dir        string
+	maxEntries int
+	maxAge     time.Duration
+	// inputs identifies what decides a result besides the tree
+	inputs string
+	deps   *Dependencies
+}
+
+// NewResultCache creates a result cache from the configuration.
+func NewResultCache(cfg config.CacheConfig, deps *Dependencies) *ResultCache {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &ResultCache{
+		dir:        cfg.GetDir(),
+		maxEntries: cfg.GetMaxEntries(),
+		maxAge:     cfg.GetMaxAge(),
+		deps:       deps,
+	}
+}
+
+// SetInputs makes results depend on the environment commands run in, which
+// the tree does not capture: the loader configuration, the variables loaded
+// from files such as a gitignored .env, and the sandbox settings.
+func (c *ResultCache) SetInputs(env config.EnvConfig, diff *EnvDiff, sandbox config.SandboxConfig) {
+	hash := sha256.New()
+	// fmt prints maps sorted by key, so equal inputs hash equally
+	_, _ = fmt.Fprintf(hash, "%+v\x00%+v\x00%+v", env, diff, sandbox)
+	c.inputs = hex.EncodeToString(hash.Sum(nil))
+}
+
+// TreeHash identifies the content of the git work tree containing dir: the
+// HEAD tree hash plus the contents of every modified or untracked file.
+// Ignored files are not part of the hash.
+func (c *ResultCache) TreeHash(ctx context.Context, dir string) (string, error) {
+	out, err := c.deps.Runner.RunContext(ctx, dir, "git", "rev-parse", "--show-toplevel", "HEAD^{tree}")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %w", err)
+	}
+	fields := strings.Fields(string(out.Stdout))
+	const revParseFields = 2
+	if len(fields) != revParseFields {
+		return "", fmt.Errorf("unexpected git rev-parse output %q", out.Stdout)
+	}
+	topLevel, tree := fields[0], fields[1]
+
+	status, err := c.deps.Runner.RunContext(ctx, topLevel,
+		"git", "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return "", fmt.Errorf("git status: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(tree))
+	for _, path := range parseDirtyPaths(status.Stdout) {
+		hash.Write([]byte{0})
+		hash.Write([]byte(path))
+		hash.Write([]byte{0})
+		data, readErr := c.deps.FS.ReadFile(filepath.Join(topLevel, path))
+		if readErr != nil {
+			hash.Write([]byte("-"))
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hash.Write(sum[:])
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// parseDirtyPaths extracts the paths from `git status --porcelain=v1 -z` output, sorted.
+func parseDirtyPaths(data []byte) []string {
+	var paths []string
+	records := bytes.Split(data, []byte{0})
+	const statusPrefix = 3 // "XY "
+	for i := 0; i < len(records); i++ {
+		record := string(records[i])
+		if len(record) <= statusPrefix {
+			continue
+		}
+		status := record[:2]
+		paths = append(paths, record[statusPrefix:])
+		// Renames and copies are followed by the original path
+		if strings.ContainsAny(status, "RC") && i+1 < len(records) {
+			i++
+			paths = append(paths, string(records[i]))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Lookup returns the cached result of cmd on the given tree, if present and fresh.
+func (c *ResultCache) Lookup(cmd *DiscoveredCommand, tree string) (*cacheEntry, bool) {
+	data, err := c.deps.FS.ReadFile(c.entryPath(cmd, tree))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if unmarshalErr := json.Unmarshal(data, &entry); unmarshalErr != nil {
+		return nil, false
+	}
+	if c.deps.Clock.Now().Sub(time.Unix(entry.CreatedAt, 0)) > c.maxAge {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Store records the result of cmd on the given tree and evicts old entries.
+func (c *ResultCache) Store(cmd *DiscoveredCommand, tree string, result *ValidationResult) error {
+	entry := cacheEntry{
+		Command:   cmd.String(),
+		Success:   result.Success,
+		ExitCode:  result.ExitCode,
+		Output:    result.Output,
+		CreatedAt: c.deps.Clock.Now().Unix(),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	if mkdirErr := c.deps.FS.MkdirAll(c.dir, cacheDirMode); mkdirErr != nil {
+		return fmt.Errorf("create cache dir: %w", mkdirErr)
+	}
+	if writeErr := c.deps.FS.WriteFile(c.entryPath(cmd, tree), data, lockFileMode); writeErr != nil {
+		return fmt.Errorf("write cache entry: %w", writeErr)
+	}
+	return c.evict()
+}
+
+// entryPath returns the file holding the result of cmd on the given tree.
+func (c *ResultCache) entryPath(cmd *DiscoveredCommand, tree string) string {
+	key := sha256.Sum256([]byte(cmd.WorkingDir + "\x00" + cmd.String() + "\x00" + tree + "\x00" + c.inputs))
+	return filepath.Join(c.dir, hex.EncodeToString(key[:16])+".json")
+}
+
+// evict removes entries past the maximum age, then the oldest entries beyond the limit.
+func (c *ResultCache) evict() error {
+	return pruneDir(c.deps, c.dir, ".json", c.maxAge, c.maxEntries)
+}
+
+// pruneDir removes files with the given suffix that are older than maxAge,
+// then the oldest ones beyond maxFiles.
+func pruneDir(deps *Dependencies, dir, suffix string, maxAge time.Duration, maxFiles int) error {
+	entries, err := deps.FS.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read dir %s: %w", dir, err)
+	}
+
+	type stored struct {
+		path    string
+		modTime time.Time
+	}
+	var files []stored
+	now := deps.Clock.Now()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if now.Sub(info.ModTime()) > maxAge {
+			_ = deps.FS.Remove(path)
+			continue
+		}
+		files = append(files, stored{path: path, modTime: info.ModTime()})
+	}
+
+	if len(files) <= maxFiles {
+		return nil
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files[:len(files)-maxFiles] {
+		_ = deps.FS.Remove(file.path)
+	}
+	return nil
+}
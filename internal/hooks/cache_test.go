@@ -0,0 +1,175 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestParseDirtyPaths(t *testing.T) {
+	data := []byte(" M b.go\x00?? new/file.txt\x00R  renamed.go\x00original.go\x00 D gone.go\x00")
+
+	got := parseDirtyPaths(data)
+	want := []string{"b.go", "gone.go", "new/file.txt", "original.go", "renamed.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDirtyPaths() = %v, want %v", got, want)
+	}
+}
+
+func setupGitTree(deps *TestDependencies, status string, contents map[string]string) {
+	deps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		if name != "git" {
+			return nil, errors.New("unexpected command")
+		}
+		switch args[0] {
+		case "rev-parse":
+			return &CommandOutput{Stdout: []byte("/repo\nabc123\n")}, nil
+		case "status":
+			return &CommandOutput{Stdout: []byte(status)}, nil
+		}
+		return nil, errors.New("unexpected git command")
+	}
+	deps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		if content, ok := contents[name]; ok {
+			return []byte(content), nil
+		}
+		return nil, os.ErrNotExist
+	}
+}
+
+func TestResultCache_TreeHash(t *testing.T) {
+	hashFor := func(status string, contents map[string]string) string {
+		testDeps := createTestDependencies()
+		setupGitTree(testDeps, status, contents)
+		cache := NewResultCache(config.CacheConfig{Dir: "/cache"}, testDeps.Dependencies)
+		hash, err := cache.TreeHash(context.Background(), "/repo/pkg")
+		if err != nil {
+			t.Fatalf("TreeHash() error = %v", err)
+		}
+		return hash
+	}
+
+	clean := hashFor("", nil)
+	edited := hashFor(" M main.go\x00", map[string]string{"/repo/main.go": "package main // v1"})
+	reedited := hashFor(" M main.go\x00", map[string]string{"/repo/main.go": "package main // v2"})
+	revertedEdit := hashFor(" M main.go\x00", map[string]string{"/repo/main.go": "package main // v1"})
+
+	if clean == edited || edited == reedited {
+		t.Error("different tree contents should hash differently")
+	}
+	if edited != revertedEdit {
+		t.Error("identical tree contents should hash identically")
+	}
+
+	testDeps := createTestDependencies()
+	cache := NewResultCache(config.CacheConfig{Dir: "/cache"}, testDeps.Dependencies)
+	if _, err := cache.TreeHash(context.Background(), "/not-git"); err == nil {
+		t.Error("TreeHash() should fail outside a git work tree")
+	}
+}
+
+func TestParallelValidateExecutor_Cache(t *testing.T) {
+	cacheDir := t.TempDir()
+	testDeps := createTestDependencies()
+	testDeps.Dependencies.FS = &realFileSystem{}
+
+	lintRuns := 0
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		switch {
+		case name == "git" && args[0] == "rev-parse":
+			return &CommandOutput{Stdout: []byte("/repo\nabc123\n")}, nil
+		case name == "git" && args[0] == "status":
+			return &CommandOutput{}, nil
+		case name == "make" && len(args) == 1 && args[0] == "lint":
+			lintRuns++
+			return &CommandOutput{}, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+
+	lintCmd := &DiscoveredCommand{Type: CommandTypeLint, Command: "make", Args: []string{"lint"}, WorkingDir: "/repo"}
+	envConfig := config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv}}
+	diff := &EnvDiff{Set: map[string]string{"DATABASE_URL": "postgres://localhost/v1"}}
+	newExecutor := func() *ParallelValidateExecutor {
+		executor := NewParallelValidateExecutor("/repo", 10, false, nil, testDeps.Dependencies)
+		cache := NewResultCache(config.CacheConfig{Dir: cacheDir}, testDeps.Dependencies)
+		cache.SetInputs(envConfig, diff, config.SandboxConfig{})
+		executor.EnableCache(cache)
+		executor.tree, _ = executor.cache.TreeHash(context.Background(), "/repo")
+		return executor
+	}
+
+	first := newExecutor().executeParallel(context.Background(), lintCmd, nil)
+	if first.LintResult.Cached || lintRuns != 1 {
+		t.Fatalf("first run: cached = %v, runs = %d", first.LintResult.Cached, lintRuns)
+	}
+
+	second := newExecutor().executeParallel(context.Background(), lintCmd, nil)
+	if !second.LintResult.Cached || !second.LintResult.Success || lintRuns != 1 {
+		t.Fatalf("second run: cached = %v, runs = %d", second.LintResult.Cached, lintRuns)
+	}
+
+	// A changed .env is not part of the tree, but changes the result
+	diff = &EnvDiff{Set: map[string]string{"DATABASE_URL": "postgres://localhost/v2"}}
+	if third := newExecutor().executeParallel(context.Background(), lintCmd, nil); third.LintResult.Cached ||
+		lintRuns != 2 {
+		t.Fatalf("run with a changed environment: cached = %v, runs = %d", third.LintResult.Cached, lintRuns)
+	}
+
+	second.BothPassed = true
+	message := second.FormatMessage()
+	if !strings.Contains(message, "Validations pass") || !strings.Contains(message, "Cached lint result") {
+		t.Errorf("expected cache hit to be labelled, got %q", message)
+	}
+}
+
+func TestResultCache_Eviction(t *testing.T) {
+	cacheDir := t.TempDir()
+	deps := NewDefaultDependencies()
+	cache := NewResultCache(config.CacheConfig{Dir: cacheDir, MaxEntries: 2, MaxAgeHours: 1}, deps)
+
+	cmd := &DiscoveredCommand{Command: "make", Args: []string{"test"}, WorkingDir: "/repo"}
+	result := &ValidationResult{Success: true}
+
+	// An entry past the maximum age is evicted regardless of the limit
+	if err := cache.Store(cmd, "stale", result); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cache.entryPath(cmd, "stale"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tree := range []string{"a", "b", "c"} {
+		if err := cache.Store(cmd, tree, result); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+		modTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		if err := os.Chtimes(cache.entryPath(cmd, tree), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.evict(); err != nil {
+		t.Fatalf("evict() error = %v", err)
+	}
+
+	entries, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries after eviction, got %d", len(entries))
+	}
+	for _, tree := range []string{"stale", "a"} {
+		if _, ok := cache.Lookup(cmd, tree); ok {
+			t.Errorf("entry %q should have been evicted", tree)
+		}
+	}
+	if _, ok := cache.Lookup(cmd, "c"); !ok {
+		t.Error("newest entry should be kept")
+	}
+}
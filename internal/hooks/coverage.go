@@ -0,0 +1,445 @@
+package hooks
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// ErrNoCoverage is returned when coverage cannot be measured for a file.
+var ErrNoCoverage = errors.New("no coverage available")
+
+// ErrNoGoModule is returned when a file is not inside a Go module.
+var ErrNoGoModule = errors.New("no Go module")
+
+// LineRange is an inclusive range of source lines.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// String formats the range as "12" or "12-15".
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// FileCoverage is the line coverage of a single source file.
+type FileCoverage struct {
+	File      string
+	Covered   int
+	Total     int
+	Uncovered []LineRange
+}
+
+// Percent returns the share of coverable lines that were executed.
+func (c *FileCoverage) Percent() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	const percent = 100
+	return float64(c.Covered) * percent / float64(c.Total)
+}
+
+// newFileCoverage builds file coverage from a map of coverable line to whether it ran.
+func newFileCoverage(file string, lines map[int]bool) *FileCoverage {
+	coverage := &FileCoverage{File: file, Total: len(lines)}
+
+	numbers := make([]int, 0, len(lines))
+	for line, covered := range lines {
+		if covered {
+			coverage.Covered++
+			continue
+		}
+		numbers = append(numbers, line)
+	}
+	sort.Ints(numbers)
+
+	for _, line := range numbers {
+		last := len(coverage.Uncovered) - 1
+		if last >= 0 && coverage.Uncovered[last].End == line-1 {
+			coverage.Uncovered[last].End = line
+			continue
+		}
+		coverage.Uncovered = append(coverage.Uncovered, LineRange{Start: line, End: line})
+	}
+	return coverage
+}
+
+// formatRanges joins line ranges for display, eliding long lists.
+func formatRanges(ranges []LineRange) string {
+	const maxRanges = 15
+	parts := make([]string, 0, min(len(ranges), maxRanges))
+	for i, r := range ranges {
+		if i == maxRanges {
+			parts = append(parts, fmt.Sprintf("... (%d more)", len(ranges)-i))
+			break
+		}
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CoverageChecker measures coverage of edited files and compares it with history.
+type CoverageChecker struct {
+	projectRoot string
+	cfg         config.CoverageConfig
+	executor    *CommandExecutor
+	deps        *Dependencies
+	severity    string
+}
+
+// NewCoverageChecker creates a coverage checker for the project.
+func NewCoverageChecker(
+	projectRoot string,
+	cfg config.CoverageConfig,
+	timeoutSecs int,
+	deps *Dependencies,
+) *CoverageChecker {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &CoverageChecker{
+		projectRoot: projectRoot,
+		cfg:         cfg,
+		executor:    NewCommandExecutor(timeoutSecs, false, deps),
+		deps:        deps,
+		severity:    config.SeverityBlock,
+	}
+}
+
+// Check measures coverage of the edited file and returns a failure message if
+// it is below the floor or dropped too far. Passing values are recorded as the
+// new reference for the file.
+func (c *CoverageChecker) Check(ctx context.Context, filePath string) (string, error) {
+	coverage, err := c.Collect(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	history := c.loadHistory()
+	previous, hasPrevious := history[coverage.File]
+	current := coverage.Percent()
+
+	var problems []string
+	if c.cfg.Floor > 0 && current < c.cfg.Floor {
+		problems = append(problems, fmt.Sprintf("%.1f%% is below the %.1f%% floor", current, c.cfg.Floor))
+	}
+	if c.cfg.MaxDrop > 0 && hasPrevious && previous-current > c.cfg.MaxDrop {
+		problems = append(problems, fmt.Sprintf("fell from %.1f%% to %.1f%% (more than %.1f points)",
+			previous, current, c.cfg.MaxDrop))
+	}
+
+	if len(problems) > 0 {
+		return formatFailure(c.severity, "Coverage of %s %s. Uncovered lines: %s",
+			coverage.File, strings.Join(problems, " and "), formatRanges(coverage.Uncovered)), nil
+	}
+
+	history[coverage.File] = current
+	return "", c.saveHistory(history)
+}
+
+// Collect runs the coverage tool that matches the file's language and returns
+// the coverage of that file. Test files themselves are not measured.
+func (c *CoverageChecker) Collect(ctx context.Context, filePath string) (*FileCoverage, error) {
+	base := filepath.Base(filePath)
+	switch filepath.Ext(filePath) {
+	case ".go":
+		if strings.HasSuffix(base, "_test.go") {
+			return nil, ErrNoCoverage
+		}
+		return c.collectGo(ctx, filePath)
+	case ".py":
+		if strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py") {
+			return nil, ErrNoCoverage
+		}
+		return c.collectPython(ctx, filePath)
+	case ".rs":
+		return c.collectRust(ctx, filePath)
+	default:
+		return nil, ErrNoCoverage
+	}
+}
+
+// collectGo runs the tests of the edited file's package with a cover profile.
+func (c *CoverageChecker) collectGo(ctx context.Context, filePath string) (*FileCoverage, error) {
+	moduleRoot, modulePath, err := findGoModule(c.deps.FS, c.projectRoot, filepath.Dir(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoCoverage, err)
+	}
+	rel, err := filepath.Rel(moduleRoot, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("relative path: %w", err)
+	}
+
+	profile := c.tempPath("go.out")
+	defer func() { _ = c.deps.FS.Remove(profile) }()
+
+	data, err := c.run(ctx, filepath.Dir(filePath), profile, "go", "test", "-coverprofile="+profile, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	lines := parseGoCoverProfile(data, modulePath+"/"+filepath.ToSlash(rel))
+	return c.fileCoverage(filePath, lines)
+}
+
+// findGoModule walks up from dir, stopping at the project root, to the
+// nearest go.mod and returns its directory and module path.
+func findGoModule(fs FileSystem, projectRoot, dir string) (string, string, error) {
+	for {
+		data, err := fs.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for line := range strings.SplitSeq(string(data), "\n") {
+				if modulePath, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return dir, strings.Trim(strings.TrimSpace(modulePath), `"`), nil
+				}
+			}
+			return "", "", fmt.Errorf("no module directive in %s/go.mod: %w", dir, ErrNoGoModule)
+		}
+		parent := filepath.Dir(dir)
+		if dir == projectRoot || parent == dir {
+			return "", "", fmt.Errorf("no go.mod above %s: %w", dir, ErrNoGoModule)
+		}
+		dir = parent
+	}
+}
+
+// collectPython runs pytest with coverage.py and a JSON report.
+func (c *CoverageChecker) collectPython(ctx context.Context, filePath string) (*FileCoverage, error) {
+	if _, err := c.deps.Runner.LookPath("pytest"); err != nil {
+		return nil, fmt.Errorf("pytest not found: %w", ErrNoCoverage)
+	}
+	rel, err := filepath.Rel(c.projectRoot, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("relative path: %w", err)
+	}
+
+	report := c.tempPath("py.json")
+	defer func() { _ = c.deps.FS.Remove(report) }()
+
+	data, err := c.run(ctx, c.projectRoot, report, "pytest", "-q", "--cov=.", "--cov-report=json:"+report)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := parseCoveragePyJSON(data, filepath.ToSlash(rel))
+	if err != nil {
+		return nil, err
+	}
+	return c.fileCoverage(filePath, lines)
+}
+
+// collectRust runs cargo llvm-cov with an LCOV report, if it is installed.
+func (c *CoverageChecker) collectRust(ctx context.Context, filePath string) (*FileCoverage, error) {
+	if _, err := c.deps.Runner.LookPath("cargo-llvm-cov"); err != nil {
+		return nil, fmt.Errorf("cargo-llvm-cov not found: %w", ErrNoCoverage)
+	}
+
+	report := c.tempPath("lcov.info")
+	defer func() { _ = c.deps.FS.Remove(report) }()
+
+	data, err := c.run(ctx, c.projectRoot, report, "cargo", "llvm-cov", "--lcov", "--output-path", report)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.fileCoverage(filePath, parseLCOV(data, filePath))
+}
+
+// run executes a coverage command and returns the report it wrote.
+func (c *CoverageChecker) run(ctx context.Context, dir, report, name string, args ...string) ([]byte, error) {
+	cmd := &DiscoveredCommand{Type: CommandTypeTest, Command: name, Args: args, WorkingDir: dir}
+	result := c.executor.Execute(ctx, cmd)
+	if !result.Success {
+		return nil, fmt.Errorf("%s: %w", cmd.String(), result.Error)
+	}
+
+	data, err := c.deps.FS.ReadFile(report)
+	if err != nil {
+		return nil, fmt.Errorf("reading coverage report: %w", err)
+	}
+	return data, nil
+}
+
+// fileCoverage converts measured lines into coverage keyed by the project-relative path.
+func (c *CoverageChecker) fileCoverage(filePath string, lines map[int]bool) (*FileCoverage, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s not in coverage report: %w", filePath, ErrNoCoverage)
+	}
+	rel, err := filepath.Rel(c.projectRoot, filePath)
+	if err != nil {
+		rel = filePath
+	}
+	return newFileCoverage(filepath.ToSlash(rel), lines), nil
+}
+
+// tempPath returns a unique path for a coverage report.
+func (c *CoverageChecker) tempPath(suffix string) string {
+	name := fmt.Sprintf("cc-tools-cover-%d-%d-%s", c.deps.Process.GetPID(), c.deps.Clock.Now().UnixNano(), suffix)
+	return filepath.Join(c.deps.FS.TempDir(), name)
+}
+
+// historyPath returns the coverage history file of the project.
+func (c *CoverageChecker) historyPath() string {
+	hash := sha256.Sum256([]byte(c.projectRoot))
+	return filepath.Join(c.cfg.GetHistoryDir(), fmt.Sprintf("%x.json", hash[:8]))
+}
+
+// loadHistory reads the last recorded coverage per file.
+func (c *CoverageChecker) loadHistory() map[string]float64 {
+	history := make(map[string]float64)
+	if data, err := c.deps.FS.ReadFile(c.historyPath()); err == nil {
+		_ = json.Unmarshal(data, &history)
+	}
+	return history
+}
+
+// saveHistory writes the coverage history of the project.
+func (c *CoverageChecker) saveHistory(history map[string]float64) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal coverage history: %w", err)
+	}
+	if mkdirErr := c.deps.FS.MkdirAll(c.cfg.GetHistoryDir(), cacheDirMode); mkdirErr != nil {
+		return fmt.Errorf("create coverage history dir: %w", mkdirErr)
+	}
+	if writeErr := c.deps.FS.WriteFile(c.historyPath(), data, lockFileMode); writeErr != nil {
+		return fmt.Errorf("write coverage history: %w", writeErr)
+	}
+	return nil
+}
+
+// checkCoverage runs the coverage check for the edited file when enabled and
+// the tests passed. It returns a failure message, or "" when coverage is fine
+// or could not be measured.
+func checkCoverage(
+	ctx context.Context,
+	projectRoot, filePath string,
+	cfg *config.ValidateConfig,
+	result *ValidateResult,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) string {
+	if !cfg.Coverage.Enabled || result.TestResult == nil || !result.TestResult.Success {
+		return ""
+	}
+
+	checker := NewCoverageChecker(projectRoot, cfg.Coverage, cfg.TimeoutSeconds, deps)
+	checker.severity = cfg.Severity.Get(config.CheckCoverage)
+	message, err := checker.Check(ctx, filePath)
+	if err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "checking coverage")
+	}
+	return message
+}
+
+// parseGoCoverProfile extracts line coverage of one file from a Go cover profile.
+// Each block line reads "import/path/file.go:12.5,14.2 3 1".
+func parseGoCoverProfile(data []byte, importPath string) map[int]bool {
+	lines := make(map[int]bool)
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		name, block, found := strings.Cut(scanner.Text(), ":")
+		if !found || name != importPath {
+			continue
+		}
+		fields := strings.Fields(block)
+		const blockFields = 3
+		if len(fields) != blockFields {
+			continue
+		}
+		start, end, ok := parseGoBlockRange(fields[0])
+		statements, _ := strconv.Atoi(fields[1])
+		count, _ := strconv.Atoi(fields[2])
+		if !ok || statements == 0 {
+			continue
+		}
+		for line := start; line <= end; line++ {
+			lines[line] = lines[line] || count > 0
+		}
+	}
+	return lines
+}
+
+// parseGoBlockRange parses "12.5,14.2" into its start and end lines.
+func parseGoBlockRange(s string) (int, int, bool) {
+	from, to, found := strings.Cut(s, ",")
+	if !found {
+		return 0, 0, false
+	}
+	startLine, _, _ := strings.Cut(from, ".")
+	endLine, _, _ := strings.Cut(to, ".")
+	start, startErr := strconv.Atoi(startLine)
+	end, endErr := strconv.Atoi(endLine)
+	if startErr != nil || endErr != nil {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// parseCoveragePyJSON extracts line coverage of one file from a coverage.py JSON report.
+func parseCoveragePyJSON(data []byte, relPath string) (map[int]bool, error) {
+	var report struct {
+		Files map[string]struct {
+			ExecutedLines []int `json:"executed_lines"`
+			MissingLines  []int `json:"missing_lines"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse coverage report: %w", err)
+	}
+
+	lines := make(map[int]bool)
+	file, ok := report.Files[relPath]
+	if !ok {
+		return lines, nil
+	}
+	for _, line := range file.ExecutedLines {
+		lines[line] = true
+	}
+	for _, line := range file.MissingLines {
+		lines[line] = false
+	}
+	return lines, nil
+}
+
+// parseLCOV extracts line coverage of one file from an LCOV report.
+func parseLCOV(data []byte, filePath string) map[int]bool {
+	lines := make(map[int]bool)
+	inFile := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			inFile = filepath.Clean(strings.TrimPrefix(line, "SF:")) == filepath.Clean(filePath)
+		case line == "end_of_record":
+			inFile = false
+		case inFile && strings.HasPrefix(line, "DA:"):
+			lineNum, hits, found := strings.Cut(strings.TrimPrefix(line, "DA:"), ",")
+			if !found {
+				continue
+			}
+			num, numErr := strconv.Atoi(lineNum)
+			count, countErr := strconv.Atoi(strings.Split(hits, ",")[0])
+			if numErr != nil || countErr != nil {
+				continue
+			}
+			lines[num] = lines[num] || count > 0
+		}
+	}
+	return lines
+}
@@ -0,0 +1,268 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestNewFileCoverage(t *testing.T) {
+	lines := map[int]bool{
+		1: true, 2: false, 3: false, 4: true, 5: false, 7: false, 8: false,
+	}
+
+	coverage := newFileCoverage("pkg/a.go", lines)
+
+	if coverage.Total != 7 || coverage.Covered != 2 {
+		t.Errorf("got %d/%d covered, want 2/7", coverage.Covered, coverage.Total)
+	}
+	if got := formatRanges(coverage.Uncovered); got != "2-3, 5, 7-8" {
+		t.Errorf("uncovered = %q, want %q", got, "2-3, 5, 7-8")
+	}
+}
+
+func TestFileCoveragePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		coverage FileCoverage
+		want     float64
+	}{
+		{"half", FileCoverage{Covered: 5, Total: 10}, 50},
+		{"full", FileCoverage{Covered: 3, Total: 3}, 100},
+		{"no coverable lines", FileCoverage{}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coverage.Percent(); got != tt.want {
+				t.Errorf("Percent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGoCoverProfile(t *testing.T) {
+	profile := `mode: set
+example.com/m/pkg/a.go:3.14,5.2 2 1
+example.com/m/pkg/a.go:5.2,7.3 1 0
+example.com/m/pkg/a.go:9.1,9.10 0 0
+example.com/m/pkg/b.go:1.1,2.2 1 0
+`
+
+	lines := parseGoCoverProfile([]byte(profile), "example.com/m/pkg/a.go")
+
+	want := map[int]bool{3: true, 4: true, 5: true, 6: false, 7: false}
+	if len(lines) != len(want) {
+		t.Fatalf("got %v, want %v", lines, want)
+	}
+	for line, covered := range want {
+		if lines[line] != covered {
+			t.Errorf("line %d covered = %v, want %v", line, lines[line], covered)
+		}
+	}
+}
+
+func TestParseCoveragePyJSON(t *testing.T) {
+	report := `{"files": {"pkg/mod.py": {"executed_lines": [1, 2, 4], "missing_lines": [3, 5]}}}`
+
+	lines, err := parseCoveragePyJSON([]byte(report), "pkg/mod.py")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	coverage := newFileCoverage("pkg/mod.py", lines)
+	if coverage.Covered != 3 || coverage.Total != 5 {
+		t.Errorf("got %d/%d covered, want 3/5", coverage.Covered, coverage.Total)
+	}
+
+	missing, err := parseCoveragePyJSON([]byte(report), "other.py")
+	if err != nil || len(missing) != 0 {
+		t.Errorf("expected no lines for unknown file, got %v, %v", missing, err)
+	}
+
+	if _, err := parseCoveragePyJSON([]byte("not json"), "pkg/mod.py"); err == nil {
+		t.Error("expected error for invalid report")
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	report := `SF:/project/src/lib.rs
+DA:1,4
+DA:2,0
+DA:3,1
+end_of_record
+SF:/project/src/main.rs
+DA:1,0
+end_of_record
+`
+
+	lines := parseLCOV([]byte(report), "/project/src/lib.rs")
+
+	want := map[int]bool{1: true, 2: false, 3: true}
+	if len(lines) != len(want) {
+		t.Fatalf("got %v, want %v", lines, want)
+	}
+	for line, covered := range want {
+		if lines[line] != covered {
+			t.Errorf("line %d covered = %v, want %v", line, lines[line], covered)
+		}
+	}
+}
+
+// setupCoverageProject fakes a Go module at /project whose tests write the given profile.
+func setupCoverageProject(deps *TestDependencies, files *memFiles, profile func() string) {
+	files.files["/project/go.mod"] = []byte("module example.com/m\n\ngo 1.24\n")
+	deps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		if name == "go" && len(args) > 1 {
+			path := strings.TrimPrefix(args[1], "-coverprofile=")
+			files.files[path] = []byte(profile())
+			return &CommandOutput{}, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+}
+
+func TestCoverageChecker_Check(t *testing.T) {
+	covered := "mode: set\nexample.com/m/pkg/a.go:1.1,10.2 4 1\n"
+	// Lines 1-10 coverable, 6-10 not run
+	halfCovered := "mode: set\nexample.com/m/pkg/a.go:1.1,5.2 2 1\nexample.com/m/pkg/a.go:6.1,10.2 2 0\n"
+
+	tests := []struct {
+		name        string
+		cfg         config.CoverageConfig
+		history     string
+		profile     string
+		wantBlock   bool
+		wantMessage []string
+	}{
+		{
+			name:    "above floor passes",
+			cfg:     config.CoverageConfig{Enabled: true, Floor: 80},
+			profile: covered,
+		},
+		{
+			name:        "below floor blocks",
+			cfg:         config.CoverageConfig{Enabled: true, Floor: 80},
+			profile:     halfCovered,
+			wantBlock:   true,
+			wantMessage: []string{"pkg/a.go", "50.0% is below the 80.0% floor", "Uncovered lines: 6-10"},
+		},
+		{
+			name:        "drop beyond limit blocks",
+			cfg:         config.CoverageConfig{Enabled: true, MaxDrop: 5},
+			history:     `{"pkg/a.go": 100}`,
+			profile:     halfCovered,
+			wantBlock:   true,
+			wantMessage: []string{"fell from 100.0% to 50.0%"},
+		},
+		{
+			name:    "drop within limit passes",
+			cfg:     config.CoverageConfig{Enabled: true, MaxDrop: 60},
+			history: `{"pkg/a.go": 100}`,
+			profile: halfCovered,
+		},
+		{
+			name:    "no history never counts as a drop",
+			cfg:     config.CoverageConfig{Enabled: true, MaxDrop: 5},
+			profile: halfCovered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			setupCoverageProject(testDeps, files, func() string { return tt.profile })
+
+			tt.cfg.HistoryDir = "/history"
+			checker := NewCoverageChecker("/project", tt.cfg, 10, testDeps.Dependencies)
+			if tt.history != "" {
+				files.files[checker.historyPath()] = []byte(tt.history)
+			}
+
+			message, err := checker.Check(context.Background(), "/project/pkg/a.go")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (message != "") != tt.wantBlock {
+				t.Fatalf("message = %q, wantBlock %v", message, tt.wantBlock)
+			}
+			for _, want := range tt.wantMessage {
+				if !strings.Contains(message, want) {
+					t.Errorf("message %q missing %q", message, want)
+				}
+			}
+
+			// Only passing coverage becomes the new reference
+			history := string(files.files[checker.historyPath()])
+			if tt.wantBlock && history != tt.history {
+				t.Errorf("blocked run changed history to %q", history)
+			}
+			if !tt.wantBlock && history == tt.history {
+				t.Error("passing run did not record coverage")
+			}
+
+			// The profile is removed after reading
+			for path := range files.files {
+				if strings.Contains(filepath.Base(path), "cc-tools-cover-") {
+					t.Errorf("coverage profile %s left behind", path)
+				}
+			}
+		})
+	}
+}
+
+func TestCoverageChecker_Unsupported(t *testing.T) {
+	testDeps := createTestDependencies()
+	newMemFiles(testDeps)
+	testDeps.MockRunner.lookPathFunc = func(string) (string, error) { return "", os.ErrNotExist }
+	checker := NewCoverageChecker("/project", config.CoverageConfig{Enabled: true}, 10, testDeps.Dependencies)
+
+	for _, path := range []string{
+		"/project/README.md",
+		"/project/pkg/a_test.go",
+		"/project/tests/test_mod.py",
+		"/project/pkg/a.go", // No go.mod
+		"/project/src/lib.rs",
+	} {
+		if _, err := checker.Collect(context.Background(), path); !errors.Is(err, ErrNoCoverage) {
+			t.Errorf("Collect(%s) error = %v, want ErrNoCoverage", path, err)
+		}
+	}
+}
+
+func TestCheckCoverage_OnlyAfterPassingTests(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	runs := 0
+	setupCoverageProject(testDeps, files, func() string {
+		runs++
+		return "mode: set\nexample.com/m/pkg/a.go:1.1,2.2 1 0\n"
+	})
+
+	cfg := &config.ValidateConfig{TimeoutSeconds: 10}
+	cfg.Coverage = config.CoverageConfig{Enabled: true, Floor: 50, HistoryDir: "/history"}
+
+	failed := &ValidateResult{TestResult: &ValidationResult{Success: false}}
+	if msg := checkCoverage(context.Background(), "/project", "/project/pkg/a.go", cfg, failed,
+		testDeps.Dependencies, nil); msg != "" || runs != 0 {
+		t.Errorf("coverage ran after failing tests: %q (%d runs)", msg, runs)
+	}
+
+	passed := &ValidateResult{TestResult: &ValidationResult{Success: true}}
+	if msg := checkCoverage(context.Background(), "/project", "/project/pkg/a.go", cfg, passed,
+		testDeps.Dependencies, nil); !strings.Contains(msg, "below the 50.0% floor") {
+		t.Errorf("expected floor violation, got %q", msg)
+	}
+
+	cfg.Coverage.Enabled = false
+	runs = 0
+	if msg := checkCoverage(context.Background(), "/project", "/project/pkg/a.go", cfg, passed,
+		testDeps.Dependencies, nil); msg != "" || runs != 0 {
+		t.Errorf("coverage ran while disabled: %q (%d runs)", msg, runs)
+	}
+}
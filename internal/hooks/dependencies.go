@@ -6,7 +6,10 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"time"
+
+	"github.com/Veraticus/cc-tools/internal/lsp"
 )
 
 // FileSystem provides filesystem operations.
@@ -14,9 +17,28 @@ type FileSystem interface {
 	Stat(name string) (os.FileInfo, error)
 	ReadFile(name string) ([]byte, error)
 	WriteFile(name string, data []byte, perm os.FileMode) error
+	// AppendFile appends data to the named file, creating it if needed.
+	AppendFile(name string, data []byte, perm os.FileMode) error
 	TempDir() string
-	CreateExclusive(name string, data []byte, perm os.FileMode) error
+	ReadDir(name string) ([]os.DirEntry, error)
+	MkdirAll(path string, perm os.FileMode) error
 	Remove(name string) error
+	// Rename atomically replaces newpath with oldpath.
+	Rename(oldpath, newpath string) error
+}
+
+// LockedFile is a file held under an exclusive advisory lock.
+type LockedFile interface {
+	ReadAll() ([]byte, error)
+	Replace(data []byte) error
+	Unlock() error
+}
+
+// FileLocker acquires exclusive advisory locks on files.
+type FileLocker interface {
+	// TryLock locks the named file without blocking, creating it and its
+	// directory if needed. It returns ErrLockHeld if another process holds the lock.
+	TryLock(name string) (LockedFile, error)
 }
 
 // CommandOutput contains the output from a command execution.
@@ -29,6 +51,9 @@ type CommandOutput struct {
 type CommandRunner interface {
 	RunContext(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error)
 	LookPath(file string) (string, error)
+	// WithEnv returns a runner whose commands get env, in "KEY=value" form,
+	// instead of the environment of cc-tools.
+	WithEnv(env []string) CommandRunner
 }
 
 // ProcessManager manages system processes.
@@ -36,6 +61,9 @@ type ProcessManager interface {
 	GetPID() int
 	FindProcess(pid int) (*os.Process, error)
 	ProcessExists(pid int) bool
+	Signal(pid int, sig os.Signal) error
+	// NotifyContext returns a copy of ctx that is canceled when the process receives sig.
+	NotifyContext(ctx context.Context, sig os.Signal) (context.Context, context.CancelFunc)
 }
 
 // Clock provides time operations.
@@ -54,15 +82,23 @@ type OutputWriter interface {
 	io.Writer
 }
 
+// LanguageServers collects diagnostics from language servers that keep
+// running between hook invocations.
+type LanguageServers interface {
+	Diagnose(ctx context.Context, server lsp.Server, req lsp.Request) ([]lsp.Diagnostic, error)
+}
+
 // Dependencies holds all external dependencies.
 type Dependencies struct {
 	FS      FileSystem
 	Runner  CommandRunner
 	Process ProcessManager
+	Locker  FileLocker
 	Clock   Clock
 	Input   InputReader
 	Stdout  OutputWriter
 	Stderr  OutputWriter
+	LSP     LanguageServers
 }
 
 // Production implementations
@@ -92,23 +128,36 @@ func (r *realFileSystem) WriteFile(name string, data []byte, perm os.FileMode) e
 	return nil
 }
 
+func (r *realFileSystem) AppendFile(name string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, perm) // #nosec G304 - path from config
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	if _, writeErr := file.Write(data); writeErr != nil {
+		_ = file.Close()
+		return fmt.Errorf("append to %s: %w", name, writeErr)
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		return fmt.Errorf("close %s: %w", name, closeErr)
+	}
+	return nil
+}
+
 func (r *realFileSystem) TempDir() string {
 	return os.TempDir()
 }
 
-func (r *realFileSystem) CreateExclusive(name string, data []byte, perm os.FileMode) error {
-	// Use O_EXCL to atomically create the file only if it doesn't exist
-	// #nosec G304 - file path is from trusted source
-	file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+func (r *realFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(name)
 	if err != nil {
-		return fmt.Errorf("create exclusive %s: %w", name, err)
+		return nil, fmt.Errorf("read dir %s: %w", name, err)
 	}
-	defer func() { _ = file.Close() }()
+	return entries, nil
+}
 
-	if _, writeErr := file.Write(data); writeErr != nil {
-		// Try to clean up on write failure
-		_ = os.Remove(name)
-		return fmt.Errorf("write exclusive %s: %w", name, writeErr)
+func (r *realFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	if err := os.MkdirAll(path, perm); err != nil {
+		return fmt.Errorf("mkdir %s: %w", path, err)
 	}
 	return nil
 }
@@ -120,11 +169,22 @@ func (r *realFileSystem) Remove(name string) error {
 	return nil
 }
 
-type realCommandRunner struct{}
+func (r *realFileSystem) Rename(oldpath, newpath string) error {
+	if err := os.Rename(oldpath, newpath); err != nil {
+		return fmt.Errorf("rename %s: %w", oldpath, err)
+	}
+	return nil
+}
+
+type realCommandRunner struct {
+	// env replaces the environment of commands when it is not nil
+	env []string
+}
 
 func (r *realCommandRunner) RunContext(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error) {
 	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = dir
+	cmd.Env = r.env
 
 	// Capture stdout and stderr separately
 	var stdout, stderr []byte
@@ -174,6 +234,10 @@ func (r *realCommandRunner) LookPath(file string) (string, error) {
 	return path, nil
 }
 
+func (r *realCommandRunner) WithEnv(env []string) CommandRunner {
+	return &realCommandRunner{env: env}
+}
+
 type realProcessManager struct{}
 
 func (r *realProcessManager) GetPID() int {
@@ -198,12 +262,45 @@ func (r *realProcessManager) ProcessExists(pid int) bool {
 	return err == nil
 }
 
+func (r *realProcessManager) Signal(pid int, sig os.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("find process %d: %w", pid, err)
+	}
+	if signalErr := process.Signal(sig); signalErr != nil {
+		return fmt.Errorf("signal process %d: %w", pid, signalErr)
+	}
+	return nil
+}
+
+func (r *realProcessManager) NotifyContext(
+	ctx context.Context,
+	sig os.Signal,
+) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, sig)
+}
+
 type realClock struct{}
 
 func (r *realClock) Now() time.Time {
 	return time.Now()
 }
 
+// lspDaemons starts language server daemons by re-executing this binary.
+type lspDaemons struct{}
+
+func (d *lspDaemons) Diagnose(ctx context.Context, server lsp.Server, req lsp.Request) ([]lsp.Diagnostic, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locating language server daemon: %w", err)
+	}
+	diagnostics, err := lsp.Diagnose(ctx, executable, server, req)
+	if err != nil {
+		return nil, fmt.Errorf("diagnosing %s: %w", req.Path, err)
+	}
+	return diagnostics, nil
+}
+
 type stdinReader struct{}
 
 func (s *stdinReader) ReadAll() ([]byte, error) {
@@ -225,9 +322,11 @@ func NewDefaultDependencies() *Dependencies {
 		FS:      &realFileSystem{},
 		Runner:  &realCommandRunner{},
 		Process: &realProcessManager{},
+		Locker:  &flockLocker{},
 		Clock:   &realClock{},
 		Input:   &stdinReader{},
 		Stdout:  os.Stdout,
 		Stderr:  os.Stderr,
+		LSP:     &lspDaemons{},
 	}
 }
@@ -25,6 +25,8 @@ type DiscoveredCommand struct {
 	Args       []string
 	WorkingDir string
 	Source     string // Where it was found (e.g., "Makefile", "package.json")
+	// Echo lists the recipe lines a task runner may print before running them.
+	Echo []string
 }
 
 // CommandDiscovery handles discovering project commands with injected dependencies.
@@ -117,7 +119,7 @@ func (cd *CommandDiscovery) checkMakefile(
 		target := string(cmdType)
 		// Check if target exists using make -n (dry run)
 		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(cd.timeout)*time.Second)
-		_, err := cd.deps.Runner.RunContext(timeoutCtx, dir, "make", "-f", path, "-n", target)
+		out, err := cd.deps.Runner.RunContext(timeoutCtx, dir, "make", "-f", path, "-n", target)
 		cancel()
 		if err == nil {
 			return &DiscoveredCommand{
@@ -126,6 +128,7 @@ func (cd *CommandDiscovery) checkMakefile(
 				Args:       []string{target},
 				WorkingDir: dir,
 				Source:     makefile,
+				Echo:       echoLines(out),
 			}
 		}
 	}
@@ -150,7 +153,7 @@ func (cd *CommandDiscovery) checkJustfile(
 		recipe := string(cmdType)
 		// Check if recipe exists using just --show
 		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(cd.timeout)*time.Second)
-		_, err := cd.deps.Runner.RunContext(timeoutCtx, dir, "just", "--justfile", path, "--show", recipe)
+		out, err := cd.deps.Runner.RunContext(timeoutCtx, dir, "just", "--justfile", path, "--show", recipe)
 		cancel()
 		if err == nil {
 			return &DiscoveredCommand{
@@ -159,6 +162,7 @@ func (cd *CommandDiscovery) checkJustfile(
 				Args:       []string{recipe},
 				WorkingDir: dir,
 				Source:     justfile,
+				Echo:       echoLines(out),
 			}
 		}
 	}
@@ -166,6 +170,20 @@ func (cd *CommandDiscovery) checkJustfile(
 	return nil
 }
 
+// echoLines returns the non-empty lines of a dry run's output, trimmed.
+func echoLines(out *CommandOutput) []string {
+	if out == nil {
+		return nil
+	}
+	var lines []string
+	for line := range strings.SplitSeq(string(out.Stdout), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
 // checkPackageJSON checks for npm/yarn/pnpm scripts.
 func (cd *CommandDiscovery) checkPackageJSON(
 	ctx context.Context,
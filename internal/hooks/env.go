@@ -0,0 +1,380 @@
+package hooks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// EnvDiff describes how the environment of validation commands differs from
+// the environment cc-tools was started with.
+type EnvDiff struct {
+	Set   map[string]string
+	Unset []string
+}
+
+// IsEmpty reports whether the diff changes nothing.
+func (d *EnvDiff) IsEmpty() bool {
+	return d == nil || (len(d.Set) == 0 && len(d.Unset) == 0)
+}
+
+// apply merges another diff on top of this one.
+func (d *EnvDiff) apply(other *EnvDiff) {
+	if other == nil {
+		return
+	}
+	for _, key := range other.Unset {
+		delete(d.Set, key)
+		if !slices.Contains(d.Unset, key) {
+			d.Unset = append(d.Unset, key)
+		}
+	}
+	for key, value := range other.Set {
+		d.Set[key] = value
+		d.Unset = slices.DeleteFunc(d.Unset, func(k string) bool { return k == key })
+	}
+}
+
+// Environ applies the diff to base, an environment in "KEY=value" form such
+// as os.Environ returns.
+func (d *EnvDiff) Environ(base []string) []string {
+	env := slices.DeleteFunc(slices.Clone(base), func(entry string) bool {
+		key, _, _ := strings.Cut(entry, "=")
+		_, set := d.Set[key]
+		return set || slices.Contains(d.Unset, key)
+	})
+	keys := make([]string, 0, len(d.Set))
+	for key := range d.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+d.Set[key])
+	}
+	return env
+}
+
+// Lines returns a sorted, human-readable representation of the diff: +KEY for
+// each variable set and -KEY for each unset. Values are left out, since
+// loaders commonly set credentials.
+func (d *EnvDiff) Lines() []string {
+	if d.IsEmpty() {
+		return nil
+	}
+	lines := make([]string, 0, len(d.Set)+len(d.Unset))
+	for key := range d.Set {
+		lines = append(lines, "+"+key)
+	}
+	for _, key := range d.Unset {
+		lines = append(lines, "-"+key)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// EnvLoader resolves the environment for validation commands in a project.
+type EnvLoader struct {
+	projectRoot string
+	cfg         config.EnvConfig
+	deps        *Dependencies
+}
+
+// NewEnvLoader creates a new environment loader for the given project.
+func NewEnvLoader(projectRoot string, cfg config.EnvConfig, deps *Dependencies) *EnvLoader {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &EnvLoader{
+		projectRoot: projectRoot,
+		cfg:         cfg,
+		deps:        deps,
+	}
+}
+
+// Load evaluates the configured .env and direnv loaders and returns the combined diff.
+// Loaders that are unavailable or fail are reported through the returned error,
+// but the diff still contains whatever the remaining loaders produced.
+func (l *EnvLoader) Load(ctx context.Context) (*EnvDiff, error) {
+	diff := &EnvDiff{Set: make(map[string]string)}
+	var errs []string
+
+	for _, loader := range l.cfg.Loaders {
+		switch loader {
+		case config.EnvLoaderDotenv:
+			for _, name := range l.cfg.GetDotenvFiles() {
+				fileDiff, err := l.loadDotenv(name)
+				if err != nil {
+					errs = append(errs, err.Error())
+					continue
+				}
+				diff.apply(fileDiff)
+			}
+		case config.EnvLoaderDirenv:
+			direnvDiff, err := l.loadDirenv(ctx)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			diff.apply(direnvDiff)
+		case config.EnvLoaderNix:
+			// nix develop wraps commands instead of producing a diff
+		default:
+			errs = append(errs, fmt.Sprintf("unknown env loader %q", loader))
+		}
+	}
+
+	if len(errs) > 0 {
+		return diff, fmt.Errorf("loading environment: %s", strings.Join(errs, "; "))
+	}
+	return diff, nil
+}
+
+// Runner wraps a command runner so that every command sees the loaded environment.
+// Values are passed in the environment of the commands rather than on their
+// command lines, where every user of the machine could read them.
+func (l *EnvLoader) Runner(inner CommandRunner, diff *EnvDiff) CommandRunner {
+	wrapper := l.wrapper()
+	if diff.IsEmpty() && len(wrapper) == 0 {
+		return inner
+	}
+	if !diff.IsEmpty() {
+		inner = inner.WithEnv(diff.Environ(os.Environ()))
+	}
+
+	return &envCommandRunner{
+		inner:   inner,
+		fs:      l.deps.FS,
+		diff:    diff,
+		wrapper: wrapper,
+	}
+}
+
+// wrapper returns the argv prefix that runs a command in the loaded
+// environment, such as `nix develop -c`, or nil when commands run directly.
+func (l *EnvLoader) wrapper() []string {
+	if !l.cfg.HasLoader(config.EnvLoaderNix) {
+		return nil
+	}
+	flake := l.cfg.NixFlake
+	if flake == "" {
+		flake = l.projectRoot
+	}
+	return []string{"nix", "develop", flake, "-c"}
+}
+
+// loadDotenv parses a .env file relative to the project root.
+func (l *EnvLoader) loadDotenv(name string) (*EnvDiff, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(l.projectRoot, name)
+	}
+
+	data, err := l.deps.FS.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &EnvDiff{}, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	set, err := parseDotenv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return &EnvDiff{Set: set}, nil
+}
+
+// loadDirenv evaluates `direnv export json` in the project root.
+func (l *EnvLoader) loadDirenv(ctx context.Context) (*EnvDiff, error) {
+	if _, err := l.deps.Runner.LookPath("direnv"); err != nil {
+		return nil, fmt.Errorf("direnv not found: %w", err)
+	}
+
+	out, err := l.deps.Runner.RunContext(ctx, l.projectRoot, "direnv", "export", "json")
+	if err != nil {
+		detail := ""
+		if out != nil {
+			detail = strings.TrimSpace(string(out.Stderr))
+		}
+		return nil, fmt.Errorf("direnv export: %w %s", err, detail)
+	}
+
+	return parseDirenvJSON(out.Stdout)
+}
+
+// parseDirenvJSON parses the output of `direnv export json`.
+// Variables direnv wants removed are reported as null.
+func parseDirenvJSON(data []byte) (*EnvDiff, error) {
+	diff := &EnvDiff{Set: make(map[string]string)}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return diff, nil
+	}
+
+	var exported map[string]*string
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("parse direnv output: %w", err)
+	}
+
+	for key, value := range exported {
+		// direnv's own bookkeeping variables are meaningless to child processes
+		if strings.HasPrefix(key, "DIRENV_") {
+			continue
+		}
+		if value == nil {
+			diff.Unset = append(diff.Unset, key)
+			continue
+		}
+		diff.Set[key] = *value
+	}
+	sort.Strings(diff.Unset)
+	return diff, nil
+}
+
+// parseDotenv parses KEY=VALUE lines in the common .env format.
+// It supports comments, an optional "export" prefix, and single or double quotes.
+func parseDotenv(content string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: missing '='", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNum, key)
+		}
+
+		values[key] = unquoteDotenvValue(strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	return values, nil
+}
+
+// unquoteDotenvValue strips quotes and trailing comments from a .env value.
+func unquoteDotenvValue(value string) string {
+	const minQuoted = 2
+	if len(value) >= minQuoted {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			inner := value[1 : len(value)-1]
+			inner = strings.ReplaceAll(inner, `\n`, "\n")
+			return strings.ReplaceAll(inner, `\"`, `"`)
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return value[1 : len(value)-1]
+		}
+	}
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return value
+}
+
+// envCommandRunner runs commands through a wrapper such as `nix develop -c`
+// and resolves executables against the PATH of an environment diff. The diff
+// itself is already applied by the inner runner.
+type envCommandRunner struct {
+	inner   CommandRunner
+	fs      FileSystem
+	diff    *EnvDiff
+	wrapper []string
+}
+
+func (r *envCommandRunner) RunContext(
+	ctx context.Context,
+	dir, name string,
+	args ...string,
+) (*CommandOutput, error) {
+	if len(r.wrapper) == 0 {
+		return r.inner.RunContext(ctx, dir, name, args...)
+	}
+	argv := append(slices.Clone(r.wrapper[1:]), name)
+	return r.inner.RunContext(ctx, dir, r.wrapper[0], append(argv, args...)...)
+}
+
+// LookPath resolves executables against the loaded PATH when one was exported.
+func (r *envCommandRunner) LookPath(file string) (string, error) {
+	path, ok := r.diff.lookupSet("PATH")
+	if !ok || strings.Contains(file, "/") {
+		return r.inner.LookPath(file)
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		candidate := filepath.Join(dir, file)
+		if info, err := r.fs.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return candidate, nil
+		}
+	}
+	return r.inner.LookPath(file)
+}
+
+func (r *envCommandRunner) WithEnv(env []string) CommandRunner {
+	return &envCommandRunner{inner: r.inner.WithEnv(env), fs: r.fs, diff: r.diff, wrapper: r.wrapper}
+}
+
+// lookupSet returns a variable set by the diff.
+func (d *EnvDiff) lookupSet(key string) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	value, ok := d.Set[key]
+	return value, ok
+}
+
+// setupEnvironment loads the project environment and wraps the command runner with it.
+// It also returns the environment changes, which are nil without loaders.
+func setupEnvironment(
+	ctx context.Context,
+	projectRoot string,
+	cfg config.EnvConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) (*Dependencies, *EnvDiff) {
+	if len(cfg.Loaders) == 0 {
+		return deps, nil
+	}
+
+	loader := NewEnvLoader(projectRoot, cfg, deps)
+	diff, err := loader.Load(ctx)
+
+	if logger != nil && logger.IsEnabled() {
+		logger.LogSection("Environment")
+		logger.Log("Loaders: %s", strings.Join(cfg.Loaders, ", "))
+		if err != nil {
+			logger.LogError(err, "loading environment")
+		}
+		lines := diff.Lines()
+		if len(lines) == 0 {
+			logger.Log("No environment changes")
+		}
+		for _, line := range lines {
+			logger.Log("  %s", line)
+		}
+	}
+
+	wrapped := *deps
+	wrapped.Runner = loader.Runner(deps.Runner, diff)
+	return &wrapped, diff
+}
@@ -0,0 +1,217 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := `# comment
+FOO=bar
+export BAZ="quoted value"
+SINGLE='it''s raw'
+TRAILING=value # comment
+EMPTY=
+`
+	got, err := parseDotenv(content)
+	if err != nil {
+		t.Fatalf("parseDotenv() error = %v", err)
+	}
+
+	want := map[string]string{
+		"FOO":      "bar",
+		"BAZ":      "quoted value",
+		"SINGLE":   "it''s raw",
+		"TRAILING": "value",
+		"EMPTY":    "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDotenv() = %v, want %v", got, want)
+	}
+
+	if _, err := parseDotenv("NOT A VALID LINE"); err == nil {
+		t.Error("parseDotenv() expected error for line without '='")
+	}
+}
+
+func TestParseDirenvJSON(t *testing.T) {
+	data := []byte(`{"PATH":"/nix/bin:/usr/bin","OLD":null,"DIRENV_DIFF":"xyz"}`)
+
+	diff, err := parseDirenvJSON(data)
+	if err != nil {
+		t.Fatalf("parseDirenvJSON() error = %v", err)
+	}
+	if diff.Set["PATH"] != "/nix/bin:/usr/bin" {
+		t.Errorf("PATH = %q", diff.Set["PATH"])
+	}
+	if _, ok := diff.Set["DIRENV_DIFF"]; ok {
+		t.Error("DIRENV_ variables should be ignored")
+	}
+	if !reflect.DeepEqual(diff.Unset, []string{"OLD"}) {
+		t.Errorf("Unset = %v, want [OLD]", diff.Unset)
+	}
+
+	empty, err := parseDirenvJSON(nil)
+	if err != nil || !empty.IsEmpty() {
+		t.Errorf("parseDirenvJSON(nil) = %v, %v; want empty diff", empty, err)
+	}
+}
+
+func TestEnvLoader_Load(t *testing.T) {
+	testDeps := createTestDependencies()
+	testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		if name == "/project/.env" {
+			return []byte("FOO=from-dotenv\nKEEP=1\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, dir, name string, args ...string) (*CommandOutput, error) {
+		if dir != "/project" || name != "direnv" || strings.Join(args, " ") != "export json" {
+			return nil, errors.New("unexpected command")
+		}
+		return &CommandOutput{Stdout: []byte(`{"FOO":"from-direnv","GONE":null}`)}, nil
+	}
+
+	cfg := config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv, config.EnvLoaderDirenv}}
+	loader := NewEnvLoader("/project", cfg, testDeps.Dependencies)
+
+	diff, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := &EnvDiff{Set: map[string]string{"FOO": "from-direnv", "KEEP": "1"}, Unset: []string{"GONE"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Load() = %+v, want %+v", diff, want)
+	}
+	wantLines := []string{"+FOO", "+KEEP", "-GONE"}
+	if !reflect.DeepEqual(diff.Lines(), wantLines) {
+		t.Errorf("Lines() = %v, want %v", diff.Lines(), wantLines)
+	}
+}
+
+func TestEnvLoader_LoadReportsFailures(t *testing.T) {
+	testDeps := createTestDependencies()
+	testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
+		return []byte("A=1\n"), nil
+	}
+
+	cfg := config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv, config.EnvLoaderDirenv}}
+	loader := NewEnvLoader("/project", cfg, testDeps.Dependencies)
+
+	diff, err := loader.Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "direnv") {
+		t.Errorf("Load() error = %v, want direnv failure", err)
+	}
+	if diff.Set["A"] != "1" {
+		t.Error("Load() should keep results from loaders that succeeded")
+	}
+}
+
+func TestEnvLoader_Runner(t *testing.T) {
+	var gotName string
+	var gotArgs []string
+	testDeps := createTestDependencies()
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		gotName = name
+		gotArgs = args
+		return &CommandOutput{}, nil
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.EnvConfig
+		diff     *EnvDiff
+		wantName string
+		wantArgs []string
+		wantEnv  []string
+	}{
+		{
+			name:     "no changes returns inner runner",
+			cfg:      config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv}},
+			diff:     &EnvDiff{},
+			wantName: "make",
+			wantArgs: []string{"lint"},
+		},
+		{
+			name:     "diff is applied to the environment",
+			cfg:      config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv}},
+			diff:     &EnvDiff{Set: map[string]string{"B": "2", "A": "1"}, Unset: []string{"OLD"}},
+			wantName: "make",
+			wantArgs: []string{"lint"},
+			wantEnv:  []string{"KEEP=yes", "A=1", "B=2"},
+		},
+		{
+			name:     "nix develop wraps the command",
+			cfg:      config.EnvConfig{Loaders: []string{config.EnvLoaderNix}},
+			diff:     &EnvDiff{},
+			wantName: "nix",
+			wantArgs: []string{"develop", "/project", "-c", "make", "lint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OLD", "1")
+			t.Setenv("KEEP", "yes")
+			loader := NewEnvLoader("/project", tt.cfg, testDeps.Dependencies)
+			runner := loader.Runner(testDeps.MockRunner, tt.diff)
+
+			if _, err := runner.RunContext(context.Background(), "/project", "make", "lint"); err != nil {
+				t.Fatalf("RunContext() error = %v", err)
+			}
+			if gotName != tt.wantName || !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("ran %s %v, want %s %v", gotName, gotArgs, tt.wantName, tt.wantArgs)
+			}
+			if tt.wantEnv == nil {
+				return
+			}
+			env := runner.(*envCommandRunner).inner.(*mockCommandRunner).env
+			for _, entry := range env {
+				if strings.HasPrefix(entry, "OLD=") {
+					t.Errorf("environment keeps unset variable %q", entry)
+				}
+			}
+			if kept := slices.DeleteFunc(slices.Clone(env), func(entry string) bool {
+				return !slices.Contains(tt.wantEnv, entry)
+			}); !reflect.DeepEqual(kept, tt.wantEnv) {
+				t.Errorf("environment = %q, want it to end with %q", kept, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestEnvCommandRunner_LookPath(t *testing.T) {
+	testDeps := createTestDependencies()
+	testDeps.MockFS.statFunc = func(name string) (os.FileInfo, error) {
+		if name == "/nix/store/gopls/bin/gopls" {
+			return mockFileInfo{name: "gopls", mode: 0o755}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+
+	loader := NewEnvLoader("/project", config.EnvConfig{Loaders: []string{config.EnvLoaderDirenv}},
+		testDeps.Dependencies)
+	runner := loader.Runner(testDeps.MockRunner,
+		&EnvDiff{Set: map[string]string{"PATH": "/nix/store/missing/bin:/nix/store/gopls/bin"}})
+
+	if path, err := runner.LookPath("gopls"); err != nil || path != "/nix/store/gopls/bin/gopls" {
+		t.Errorf("LookPath(gopls) = %q, %v, want the loaded PATH entry", path, err)
+	}
+	if path, err := runner.LookPath("make"); err != nil || path != "/usr/bin/make" {
+		t.Errorf("LookPath(make) = %q, %v, want the inner runner's result", path, err)
+	}
+}
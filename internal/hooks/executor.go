@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/Veraticus/cc-tools/internal/config"
 	debuglog "github.com/Veraticus/cc-tools/internal/debug"
 	"github.com/Veraticus/cc-tools/internal/output"
 	"github.com/Veraticus/cc-tools/internal/shared"
@@ -19,6 +20,13 @@ const (
 	ExitCodeShowMessage = 2
 )
 
+// Failure reasons distinguish failures that were not the command's own verdict.
+const (
+	FailureReasonTimeout            = "timeout"
+	FailureReasonSandboxViolation   = "sandbox_violation"
+	FailureReasonSandboxUnavailable = "sandbox_unavailable"
+)
+
 // ExecutorResult represents the result of executing a command.
 type ExecutorResult struct {
 	Success  bool
@@ -27,6 +35,8 @@ type ExecutorResult struct {
 	Stderr   string
 	Error    error
 	TimedOut bool
+	// Reason is one of the FailureReason constants, or empty for ordinary results.
+	Reason string
 }
 
 // CommandExecutor handles executing discovered commands.
@@ -78,6 +88,7 @@ func (ce *CommandExecutor) Execute(ctx context.Context, cmd *DiscoveredCommand)
 			Stderr:   stderr,
 			Error:    fmt.Errorf("command timed out after %v", ce.timeout),
 			TimedOut: true,
+			Reason:   FailureReasonTimeout,
 		}
 	}
 
@@ -105,6 +116,19 @@ func (ce *CommandExecutor) Execute(ctx context.Context, cmd *DiscoveredCommand)
 		Stderr:   stderr,
 		Error:    err,
 		TimedOut: false,
+		Reason:   failureReason(err),
+	}
+}
+
+// failureReason classifies errors the sandbox runner marked.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrSandboxViolation):
+		return FailureReasonSandboxViolation
+	case errors.Is(err, ErrSandboxUnavailable):
+		return FailureReasonSandboxUnavailable
+	default:
+		return ""
 	}
 }
 
@@ -208,12 +232,14 @@ func processHookInput(deps *Dependencies, logger *debuglog.Logger, debug bool) (
 }
 
 // RunSmartHook is the main entry point for smart-lint and smart-test hooks.
+// The file filters are the user's, overridden by the project's .cc-tools.json.
 func RunSmartHook(
 	ctx context.Context,
 	hookType CommandType,
 	debug bool,
 	timeoutSecs int,
 	cooldownSecs int,
+	filters config.FilterConfig,
 	deps *Dependencies,
 ) int {
 	if deps == nil {
@@ -235,14 +261,6 @@ func RunSmartHook(
 		return 0
 	}
 
-	// Check if file should be skipped
-	if shared.ShouldSkipFile(filePath) {
-		if logger != nil && logger.IsEnabled() {
-			logger.Log("File skipped by filter: %s", filePath)
-		}
-		return 0
-	}
-
 	// Find project root
 	fileDir := filepath.Dir(filePath)
 	projectRoot, err := shared.FindProjectRoot(fileDir, nil)
@@ -260,6 +278,16 @@ func RunSmartHook(
 		logger.Log("Project root: %s", projectRoot)
 	}
 
+	cfg := loadProjectConfig(&config.ValidateConfig{ValidateOptions: config.ValidateOptions{Filters: filters}},
+		projectRoot, deps, logger)
+	lintFile, testFile := NewFileFilter(projectRoot, cfg.Filters, deps).Triggers(ctx, filePath)
+	if (hookType == CommandTypeLint && !lintFile) || (hookType == CommandTypeTest && !testFile) {
+		if logger != nil && logger.IsEnabled() {
+			logger.Log("File does not trigger %s: %s", hookType, filePath)
+		}
+		return 0
+	}
+
 	// Acquire lock
 	lockMgr := NewLockManager(projectRoot, string(hookType), cooldownSecs, deps)
 	if !acquireLock(lockMgr, debug, deps.Stderr, logger) {
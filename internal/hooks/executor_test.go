@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
 )
 
 func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with many scenarios
@@ -17,7 +19,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 		// Setup no input
 		testDeps.MockInput.isTerminalFunc = func() bool { return true }
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -32,7 +35,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			return []byte(`{"hook_event_name": "PreToolUse", "tool_name": "Edit"}`), nil
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -47,7 +51,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			return []byte(`{"hook_event_name": "PostToolUse", "tool_name": "Bash"}`), nil
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -66,7 +71,33 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			}`), nil
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
+		if exitCode != 0 {
+			t.Errorf("Expected exit code 0, got %d", exitCode)
+		}
+	})
+
+	t.Run("exit code 0 when configured filters exclude the file", func(t *testing.T) {
+		testDeps := createTestDependencies()
+
+		testDeps.MockInput.isTerminalFunc = func() bool { return false }
+		testDeps.MockInput.readAllFunc = func() ([]byte, error) {
+			return []byte(`{
+				"hook_event_name": "PostToolUse",
+				"tool_name": "Edit",
+				"tool_input": {"file_path": "/project/main.go"}
+			}`), nil
+		}
+		testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, _ ...string) (*CommandOutput, error) {
+			if name != "git" {
+				t.Errorf("unexpected command %s for an excluded file", name)
+			}
+			return nil, fmt.Errorf("exit status 1")
+		}
+
+		filters := config.FilterConfig{Lint: config.CommandFilter{Exclude: []string{"*.go"}}}
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, filters, testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -105,7 +136,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			return pid == 12345 // Another process holds lock
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -151,7 +183,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			return "", fmt.Errorf("not found")
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 0 {
 			t.Errorf("Expected exit code 0, got %d", exitCode)
 		}
@@ -206,7 +239,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			return nil, fmt.Errorf("command not found")
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 2 {
 			t.Errorf("Expected exit code 2, got %d", exitCode)
 		}
@@ -264,7 +298,8 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 			return nil, fmt.Errorf("command not found")
 		}
 
-		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, testDeps.Dependencies)
+		exitCode := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 5, config.FilterConfig{},
+			testDeps.Dependencies)
 		if exitCode != 2 {
 			t.Errorf("Expected exit code 2, got %d", exitCode)
 		}
@@ -323,7 +358,7 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 		exitCode := RunSmartHook(
 			context.Background(),
 			CommandTypeTest,
-			false, 20, 5,
+			false, 20, 5, config.FilterConfig{},
 			testDeps.Dependencies,
 		)
 		if exitCode != 2 {
@@ -391,7 +426,7 @@ func TestRunSmartHook(t *testing.T) { //nolint:cyclop // table-driven test with
 		exitCode := RunSmartHook(
 			context.Background(),
 			CommandTypeLint,
-			false, 1, 5,
+			false, 1, 5, config.FilterConfig{},
 			testDeps.Dependencies,
 		)
 		if exitCode != 2 {
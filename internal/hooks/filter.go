@@ -0,0 +1,234 @@
+package hooks
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// IgnoreFile is the optional project file listing, in .gitignore syntax,
+// files whose edits never trigger validation.
+const IgnoreFile = ".cc-tools-ignore"
+
+// generatedHeaderBytes bounds how much of a file is searched for a generated-code header.
+const generatedHeaderBytes = 4096
+
+// generatedPattern matches the standard "Code generated ... DO NOT EDIT." header
+// (https://go.dev/s/generatedcode) in any common line comment style.
+var generatedPattern = regexp.MustCompile(`(?m)^\s*(?://|#|--|/\*+|\*)\s*Code generated .*DO NOT EDIT\.?`)
+
+// defaultExclude keeps dependency, build and cache directories and files generated
+// by name out of every command. An include pattern naming one of them opts back in.
+var defaultExclude = parseIgnorePatterns(`vendor/
+node_modules/
+build/
+.git/
+dist/
+__pycache__/
+.cache/
+target/
+.next/
+*.generated.go
+*.pb.go
+*.gen.go
+*_gen.go
+`)
+
+// FileFilter decides which validation commands an edited file triggers.
+type FileFilter struct {
+	projectRoot string
+	cfg         config.FilterConfig
+	ignore      []ignorePattern
+	deps        *Dependencies
+}
+
+// NewFileFilter creates a file filter for the project, reading its ignore file if present.
+func NewFileFilter(projectRoot string, cfg config.FilterConfig, deps *Dependencies) *FileFilter {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	filter := &FileFilter{projectRoot: projectRoot, cfg: cfg, deps: deps}
+	if data, err := deps.FS.ReadFile(filepath.Join(projectRoot, IgnoreFile)); err == nil {
+		filter.ignore = parseIgnorePatterns(string(data))
+	}
+	return filter
+}
+
+// Triggers reports whether an edit of filePath should run lint and test.
+// Files ignored by git or the project ignore file, generated files and, unless
+// an include pattern names them, files matching the default excludes trigger neither.
+func (f *FileFilter) Triggers(ctx context.Context, filePath string) (bool, bool) {
+	rel, err := filepath.Rel(f.projectRoot, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(filePath)
+	}
+	rel = filepath.ToSlash(rel)
+
+	if matchIgnore(f.ignore, rel) || f.gitIgnored(ctx, filePath) || f.isGenerated(filePath) {
+		return false, false
+	}
+
+	lint := commandMatches(rel, f.cfg.Lint.Include, f.cfg.GetLintExclude())
+	test := commandMatches(rel, f.cfg.Test.Include, f.cfg.Test.Exclude)
+	return lint, test
+}
+
+// gitIgnored asks git whether the file is ignored. Files outside a git work tree never are.
+func (f *FileFilter) gitIgnored(ctx context.Context, filePath string) bool {
+	out, err := f.deps.Runner.RunContext(ctx, f.projectRoot, "git", "check-ignore", "--", filePath)
+	// check-ignore prints the paths that are ignored and exits non-zero when none are
+	return err == nil && out != nil && strings.TrimSpace(string(out.Stdout)) != ""
+}
+
+// isGenerated reports whether the file starts with a generated-code header.
+func (f *FileFilter) isGenerated(filePath string) bool {
+	data, err := f.deps.FS.ReadFile(filePath)
+	if err != nil {
+		return false
+	}
+	return generatedPattern.Match(data[:min(len(data), generatedHeaderBytes)])
+}
+
+// commandMatches applies the default excludes and a command's include and
+// exclude patterns to a project-relative path.
+func commandMatches(rel string, include, exclude []string) bool {
+	included := parseIgnorePatterns(strings.Join(include, "\n"))
+	if len(included) > 0 && !matchIgnore(included, rel) {
+		return false
+	}
+	if !optedIn(included, rel) {
+		return false
+	}
+	return !matchIgnore(parseIgnorePatterns(strings.Join(exclude, "\n")), rel)
+}
+
+// optedIn reports whether every default exclude matching rel is named by an
+// include pattern that matches it, so that "internal/build/" re-enables that
+// directory while "*.go" leaves vendor/ excluded.
+func optedIn(include []ignorePattern, rel string) bool {
+	segments := strings.Split(rel, "/")
+	for _, excluded := range defaultExclude {
+		if !excluded.matches(segments) {
+			continue
+		}
+		named := slices.ContainsFunc(include, func(p ignorePattern) bool {
+			return !p.negate && p.matches(segments) && slices.ContainsFunc(p.segments, func(segment string) bool {
+				ok, _ := path.Match(excluded.segments[0], segment)
+				return ok
+			})
+		})
+		if !named {
+			return false
+		}
+	}
+	return true
+}
+
+// filterSkipConfig adds the commands a file does not trigger to the skip configuration.
+func filterSkipConfig(skipConfig *SkipConfig, lint, test bool) *SkipConfig {
+	filtered := &SkipConfig{SkipLint: !lint, SkipTest: !test}
+	if skipConfig != nil {
+		filtered.SkipLint = filtered.SkipLint || skipConfig.SkipLint
+		filtered.SkipTest = filtered.SkipTest || skipConfig.SkipTest
+	}
+	return filtered
+}
+
+// ignorePattern is a single line of a .gitignore-style file.
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// parseIgnorePatterns parses .gitignore syntax: comments, blank lines,
+// "!" negation, a trailing "/" for directories and "**" for any depth.
+func parseIgnorePatterns(data string) []ignorePattern {
+	var patterns []ignorePattern
+	for line := range strings.SplitSeq(data, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			p.negate = true
+			line = rest
+		}
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			p.dirOnly = true
+			line = rest
+		}
+		// A slash anywhere but the end anchors the pattern to the project root
+		p.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		p.segments = strings.Split(line, "/")
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// matchIgnore reports whether the last pattern matching rel excludes it.
+func matchIgnore(patterns []ignorePattern, rel string) bool {
+	segments := strings.Split(rel, "/")
+	ignored := false
+	for _, p := range patterns {
+		if p.matches(segments) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// matches reports whether the pattern matches the path or one of its parent directories.
+func (p ignorePattern) matches(segments []string) bool {
+	for end := 1; end <= len(segments); end++ {
+		// Directory patterns only match parents, never the file itself
+		if p.dirOnly && end == len(segments) {
+			break
+		}
+		prefix := segments[:end]
+		if p.anchored {
+			if matchSegments(p.segments, prefix) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p.segments[0], prefix[len(prefix)-1]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where "**" matches any number of segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(segments); skip++ {
+			if matchSegments(pattern[1:], segments[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,163 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestMatchIgnore(t *testing.T) {
+	patterns := parseIgnorePatterns(`# comment
+*.log
+build/
+/root-only.txt
+docs/**/*.md
+!keep.log
+`)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"nested/dir/app.log", true},
+		{"keep.log", false},
+		{"build/out.js", true},
+		{"src/build/out.js", true},
+		{"build", false}, // Directory patterns never match a file of that name
+		{"root-only.txt", true},
+		{"sub/root-only.txt", false},
+		{"docs/guide.md", true},
+		{"docs/a/b/guide.md", true},
+		{"src/docs/guide.md", false},
+		{"main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := matchIgnore(patterns, tt.path); got != tt.want {
+				t.Errorf("matchIgnore(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileFilter_Triggers(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.FilterConfig
+		files      map[string]string
+		gitIgnored string
+		path       string
+		wantLint   bool
+		wantTest   bool
+	}{
+		{
+			name:     "source file triggers both",
+			path:     "/project/main.go",
+			wantLint: true,
+			wantTest: true,
+		},
+		{
+			name:     "test file triggers tests only by default",
+			path:     "/project/pkg/main_test.go",
+			wantTest: true,
+		},
+		{
+			name:     "empty lint exclude lints test files",
+			cfg:      config.FilterConfig{Lint: config.CommandFilter{Exclude: []string{}}},
+			path:     "/project/pkg/main_test.go",
+			wantLint: true,
+			wantTest: true,
+		},
+		{
+			name:     "include limits a command",
+			cfg:      config.FilterConfig{Test: config.CommandFilter{Include: []string{"src/"}}},
+			path:     "/project/scripts/deploy.py",
+			wantLint: true,
+		},
+		{
+			name: "default excludes",
+			path: "/project/node_modules/left-pad/index.js",
+		},
+		{
+			name:     "include naming a default exclude opts in",
+			cfg:      config.FilterConfig{Lint: config.CommandFilter{Include: []string{"internal/build/"}}},
+			path:     "/project/internal/build/plan.go",
+			wantLint: true,
+		},
+		{
+			name: "include not naming a default exclude",
+			cfg:  config.FilterConfig{Lint: config.CommandFilter{Include: []string{"*.go"}}},
+			path: "/project/vendor/lib/lib.go",
+		},
+		{
+			name:  "project ignore file",
+			files: map[string]string{"/project/" + IgnoreFile: "fixtures/\n"},
+			path:  "/project/fixtures/data.go",
+		},
+		{
+			name:       "ignored by git",
+			gitIgnored: "/project/local.go",
+			path:       "/project/local.go",
+		},
+		{
+			name:  "generated header",
+			files: map[string]string{"/project/api.go": "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n"},
+			path:  "/project/api.go",
+		},
+		{
+			name:  "generated header in hash comment",
+			files: map[string]string{"/project/schema.py": "# Code generated by sqlc. DO NOT EDIT.\n"},
+			path:  "/project/schema.py",
+		},
+		{
+			name:     "mention of generated code in body does not count",
+			files:    map[string]string{"/project/gen.go": "package gen\n\nvar s = \"Code generated by x. DO NOT EDIT.\"\n"},
+			path:     "/project/gen.go",
+			wantLint: true,
+			wantTest: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+				if content, ok := tt.files[name]; ok {
+					return []byte(content), nil
+				}
+				return nil, os.ErrNotExist
+			}
+			testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+				if name == "git" && args[0] == "check-ignore" && args[len(args)-1] == tt.gitIgnored {
+					return &CommandOutput{Stdout: []byte(tt.gitIgnored + "\n")}, nil
+				}
+				return &CommandOutput{}, errors.New("exit status 1")
+			}
+
+			filter := NewFileFilter("/project", tt.cfg, testDeps.Dependencies)
+			lint, test := filter.Triggers(context.Background(), tt.path)
+			if lint != tt.wantLint || test != tt.wantTest {
+				t.Errorf("Triggers(%s) = lint %v, test %v; want lint %v, test %v",
+					tt.path, lint, test, tt.wantLint, tt.wantTest)
+			}
+		})
+	}
+}
+
+func TestFilterSkipConfig(t *testing.T) {
+	got := filterSkipConfig(nil, false, true)
+	if !got.SkipLint || got.SkipTest {
+		t.Errorf("filterSkipConfig(nil, false, true) = %+v", got)
+	}
+
+	// Directory skips from the registry still apply
+	got = filterSkipConfig(&SkipConfig{SkipTest: true}, true, true)
+	if got.SkipLint || !got.SkipTest {
+		t.Errorf("filterSkipConfig(skip test, true, true) = %+v", got)
+	}
+}
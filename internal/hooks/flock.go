@@ -0,0 +1,130 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const lockDirMode = 0700 // Lock directory is private to the user
+
+// ErrUnsafeLockDir is returned when the lock directory could be tampered with by another user.
+var ErrUnsafeLockDir = errors.New("lock directory is not private to the current user")
+
+// flockLocker implements FileLocker with flock(2), or LockFileEx on Windows.
+// The kernel drops the lock when the holding process exits, however it exits.
+type flockLocker struct{}
+
+func (f *flockLocker) TryLock(name string) (LockedFile, error) {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, lockDirMode); err != nil {
+		return nil, fmt.Errorf("create lock dir: %w", err)
+	}
+	// MkdirAll accepts directories that already exist, whoever made them
+	if err := checkLockDir(dir); err != nil {
+		return nil, err
+	}
+
+	// #nosec G304 - lock path is derived from the lock directory and a hash
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, lockFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file %s: %w", name, err)
+	}
+
+	if lockErr := lockFile(file); lockErr != nil {
+		_ = file.Close()
+		if errors.Is(lockErr, ErrLockHeld) {
+			return nil, ErrLockHeld
+		}
+		return nil, fmt.Errorf("flock %s: %w", name, lockErr)
+	}
+
+	return &flockFile{file: file}, nil
+}
+
+// lockDirChain returns the directories between the shared temp dir and the
+// lock directory, excluding both, when the lock directory lies in it. Whoever
+// owns one of them can replace the lock directory.
+func lockDirChain(dir string) []string {
+	tempDir := filepath.Clean(os.TempDir())
+	rel, err := filepath.Rel(tempDir, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	var parents []string
+	for parent := filepath.Dir(dir); parent != tempDir; parent = filepath.Dir(parent) {
+		parents = append(parents, parent)
+	}
+	return parents
+}
+
+// checkLockDir refuses a lock directory that is a symlink, not owned by the
+// current user or accessible to others, and one in the shared temp dir below
+// a directory that other users own or can write to.
+func checkLockDir(dir string) error {
+	if err := checkDirOwner(dir, true); err != nil {
+		return err
+	}
+	for _, parent := range lockDirChain(dir) {
+		if err := checkDirOwner(parent, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDirOwner refuses a directory that is a symlink, owned by another user
+// or writable by others. A private directory must have mode lockDirMode.
+func checkDirOwner(dir string, private bool) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("inspect lock dir: %w", err)
+	}
+	if !info.IsDir() || !ownedByCurrentUser(info, private) {
+		if private {
+			return fmt.Errorf("%s must be a directory owned by the current user with mode %#o: %w",
+				dir, lockDirMode, ErrUnsafeLockDir)
+		}
+		return fmt.Errorf("%s must be a directory owned by the current user that only they can write to: %w",
+			dir, ErrUnsafeLockDir)
+	}
+	return nil
+}
+
+// flockFile is an open lock file holding an exclusive flock.
+type flockFile struct {
+	file *os.File
+}
+
+func (f *flockFile) ReadAll() ([]byte, error) {
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek lock file: %w", err)
+	}
+	data, err := io.ReadAll(f.file)
+	if err != nil {
+		return nil, fmt.Errorf("read lock file: %w", err)
+	}
+	return data, nil
+}
+
+func (f *flockFile) Replace(data []byte) error {
+	if err := f.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate lock file: %w", err)
+	}
+	if _, err := f.file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("write lock file: %w", err)
+	}
+	return nil
+}
+
+func (f *flockFile) Unlock() error {
+	// Closing the descriptor releases the flock; the file stays behind so its
+	// completion time can drive the cooldown
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close lock file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix && !windows
+
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+func lockFile(*os.File) error {
+	return fmt.Errorf("file locks require unix or Windows: %w", errors.ErrUnsupported)
+}
+
+// ownedByCurrentUser reports whether the file belongs to the current user and
+// no other user can write to it. Without file locks the lock directory is
+// never used, so no directory is refused.
+func ownedByCurrentUser(os.FileInfo, bool) bool {
+	return true
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package hooks
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on file without blocking. It returns
+// ErrLockHeld if another process holds it.
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrLockHeld
+	}
+	return err
+}
+
+// ownedByCurrentUser reports whether the file belongs to the current user and
+// no other user can write to it. A private file must have mode lockDirMode.
+func ownedByCurrentUser(info os.FileInfo, private bool) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != os.Getuid() {
+		return false
+	}
+	if private {
+		return info.Mode().Perm() == lockDirMode
+	}
+	return info.Mode().Perm()&0o022 == 0
+}
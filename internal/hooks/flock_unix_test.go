@@ -0,0 +1,39 @@
+//go:build unix
+
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlockLocker_RefusesSharedLockDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "locks")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	locker := &flockLocker{}
+	path := filepath.Join(dir, "test.lock")
+
+	for _, mode := range []os.FileMode{0o755, 0o770} {
+		if err := os.Chmod(dir, mode); err != nil {
+			t.Fatalf("Chmod() error = %v", err)
+		}
+		if _, err := locker.TryLock(path); !errors.Is(err, ErrUnsafeLockDir) {
+			t.Errorf("TryLock() in %#o dir error = %v, want ErrUnsafeLockDir", mode, err)
+		}
+	}
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Chmod(dir, lockDirMode); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	if _, err := locker.TryLock(filepath.Join(link, "test.lock")); !errors.Is(err, ErrUnsafeLockDir) {
+		t.Errorf("TryLock() through symlink error = %v, want ErrUnsafeLockDir", err)
+	}
+}
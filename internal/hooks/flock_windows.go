@@ -0,0 +1,30 @@
+//go:build windows
+
+package hooks
+
+import (
+	"errors"
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on file without blocking. It returns
+// ErrLockHeld if another process holds it.
+func lockFile(file *os.File) error {
+	err := windows.LockFileEx(windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, math.MaxUint32, math.MaxUint32, new(windows.Overlapped))
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return ErrLockHeld
+	}
+	return err
+}
+
+// ownedByCurrentUser reports whether the file belongs to the current user and
+// no other user can write to it. Windows guards files with ACLs, and those of
+// the temp and profile directories already keep other users out.
+func ownedByCurrentUser(os.FileInfo, bool) bool {
+	return true
+}
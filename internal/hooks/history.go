@@ -0,0 +1,356 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// History status filters.
+const (
+	HistoryStatusPass    = "pass"
+	HistoryStatusFail    = "fail"
+	HistoryStatusSkipped = "skipped"
+)
+
+// Reasons recorded for invocations that did not validate.
+const (
+	// HistorySkipChecks means lint and test were both skipped for the edited file.
+	HistorySkipChecks = "checks"
+	// HistorySkipBusy means another run held the lock or was cooling down.
+	HistorySkipBusy = "busy"
+	// HistorySkipQueued means the edit was left for the lock holder to validate.
+	HistorySkipQueued = "queued"
+)
+
+// HistoryCommand is one command of a recorded validation run.
+type HistoryCommand struct {
+	Type       CommandType `json:"type"`
+	Command    string      `json:"command,omitempty"`
+	Success    bool        `json:"success"`
+	ExitCode   int         `json:"exit_code"`
+	DurationMS int64       `json:"duration_ms"`
+	Cached     bool        `json:"cached,omitempty"`
+	Skipped    bool        `json:"skipped,omitempty"`
+}
+
+// Duration returns how long the command ran.
+func (c HistoryCommand) Duration() time.Duration {
+	return time.Duration(c.DurationMS) * time.Millisecond
+}
+
+// HistoryRecord is a recorded validation run.
+type HistoryRecord struct {
+	Time      time.Time        `json:"time"`
+	SessionID string           `json:"session_id,omitempty"`
+	Project   string           `json:"project"`
+	File      string           `json:"file"`
+	Passed    bool             `json:"passed"`
+	Commands  []HistoryCommand `json:"commands"`
+	// Skipped is why the invocation did not validate. Such records have no commands.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Duration returns the wall time of the run. Commands run in parallel, so
+// this is the duration of the slowest one.
+func (r HistoryRecord) Duration() time.Duration {
+	var longest time.Duration
+	for _, cmd := range r.Commands {
+		longest = max(longest, cmd.Duration())
+	}
+	return longest
+}
+
+// NewHistoryRecord builds a history record from a validation result.
+func NewHistoryRecord(
+	now time.Time,
+	sessionID, projectRoot, filePath string,
+	result *ValidateResult,
+	skipConfig *SkipConfig,
+	passed bool,
+) HistoryRecord {
+	record := HistoryRecord{
+		Time:      now,
+		SessionID: sessionID,
+		Project:   projectRoot,
+		File:      historyFile(projectRoot, filePath),
+		Passed:    passed,
+	}
+	add := func(cmdType CommandType, vr *ValidationResult, skipped bool) {
+		switch {
+		case vr != nil:
+			record.Commands = append(record.Commands, HistoryCommand{
+				Type:       cmdType,
+				Command:    vr.Command.String(),
+				Success:    vr.Success,
+				ExitCode:   vr.ExitCode,
+				DurationMS: vr.Duration.Milliseconds(),
+				Cached:     vr.Cached,
+			})
+		case skipped:
+			record.Commands = append(record.Commands, HistoryCommand{Type: cmdType, Success: true, Skipped: true})
+		}
+	}
+	add(CommandTypeLint, result.LintResult, skipConfig != nil && skipConfig.SkipLint)
+	add(CommandTypeTest, result.TestResult, skipConfig != nil && skipConfig.SkipTest)
+	return record
+}
+
+// NewSkippedHistoryRecord builds a history record for an invocation that did
+// not validate, with the reason it did not.
+func NewSkippedHistoryRecord(now time.Time, sessionID, projectRoot, filePath, reason string) HistoryRecord {
+	return HistoryRecord{
+		Time:      now,
+		SessionID: sessionID,
+		Project:   projectRoot,
+		File:      historyFile(projectRoot, filePath),
+		Skipped:   reason,
+	}
+}
+
+// historyFile returns the path of the edited file relative to the project root.
+func historyFile(projectRoot, filePath string) string {
+	if rel, err := filepath.Rel(projectRoot, filePath); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filePath
+}
+
+// History is an append-only store of validation runs, one JSON record per line.
+type History struct {
+	path     string
+	maxBytes int64
+	deps     *Dependencies
+}
+
+// NewHistory creates a history store from the configuration.
+func NewHistory(cfg config.HistoryConfig, deps *Dependencies) *History {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &History{path: cfg.GetPath(), maxBytes: cfg.GetMaxBytes(), deps: deps}
+}
+
+// Append records a run, dropping the oldest half of the history when the
+// file grows beyond its size limit.
+func (h *History) Append(record HistoryRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal history record: %w", err)
+	}
+	if mkdirErr := h.deps.FS.MkdirAll(filepath.Dir(h.path), cacheDirMode); mkdirErr != nil {
+		return fmt.Errorf("create history dir: %w", mkdirErr)
+	}
+	if appendErr := h.deps.FS.AppendFile(h.path, append(data, '\n'), lockFileMode); appendErr != nil {
+		return fmt.Errorf("append history record: %w", appendErr)
+	}
+
+	if info, statErr := h.deps.FS.Stat(h.path); statErr == nil && info.Size() > h.maxBytes {
+		return h.trim()
+	}
+	return nil
+}
+
+// trim keeps the newest half of the recorded runs.
+func (h *History) trim() error {
+	data, err := h.deps.FS.ReadFile(h.path)
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+	lines := bytes.SplitAfter(bytes.TrimRight(data, "\n"), []byte("\n"))
+	kept := bytes.Join(lines[len(lines)/2:], nil)
+	if len(kept) > 0 && kept[len(kept)-1] != '\n' {
+		kept = append(kept, '\n')
+	}
+	if writeErr := h.deps.FS.WriteFile(h.path, kept, lockFileMode); writeErr != nil {
+		return fmt.Errorf("write history: %w", writeErr)
+	}
+	return nil
+}
+
+// Load returns all recorded runs, oldest first. Unreadable lines are skipped.
+func (h *History) Load() ([]HistoryRecord, error) {
+	data, err := h.deps.FS.ReadFile(h.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+
+	var records []HistoryRecord
+	for line := range bytes.SplitSeq(data, []byte("\n")) {
+		var record HistoryRecord
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &record) != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// recordHistory appends a validation run to the history unless it is
+// disabled, the run was canceled, or no command ran in a run that was not skipped.
+func recordHistory(
+	ctx context.Context,
+	cfg config.HistoryConfig,
+	record HistoryRecord,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) {
+	if cfg.Disabled || ctx.Err() != nil || (len(record.Commands) == 0 && record.Skipped == "") {
+		return
+	}
+	if err := NewHistory(cfg, deps).Append(record); err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "recording validation history")
+	}
+}
+
+// HistoryFilter selects recorded runs.
+type HistoryFilter struct {
+	Project string
+	Session string
+	Status  string
+}
+
+// Matches reports whether a run passes the filter.
+func (f HistoryFilter) Matches(record HistoryRecord) bool {
+	if f.Project != "" && record.Project != f.Project {
+		return false
+	}
+	if f.Session != "" && record.SessionID != f.Session {
+		return false
+	}
+	switch f.Status {
+	case HistoryStatusPass:
+		return record.Passed
+	case HistoryStatusFail:
+		return !record.Passed && record.Skipped == ""
+	case HistoryStatusSkipped:
+		return record.Skipped != ""
+	}
+	return true
+}
+
+// FilterHistory returns the runs matching the filter, keeping their order.
+func FilterHistory(records []HistoryRecord, filter HistoryFilter) []HistoryRecord {
+	var matched []HistoryRecord
+	for _, record := range records {
+		if filter.Matches(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// CommandStats aggregates the runs of one command.
+type CommandStats struct {
+	Type     CommandType
+	Command  string
+	Runs     int
+	Failures int
+	Cached   int
+	P50      time.Duration
+	P95      time.Duration
+}
+
+// HistoryStats aggregates recorded runs. Skipped invocations are counted apart from runs.
+type HistoryStats struct {
+	Runs     int
+	Passed   int
+	Skipped  int
+	Commands []CommandStats
+}
+
+// PassRate returns the share of runs that passed, in percent.
+func (s HistoryStats) PassRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	const percent = 100
+	return float64(s.Passed) * percent / float64(s.Runs)
+}
+
+// MostFailing returns the commands that failed at least once, most failures first.
+func (s HistoryStats) MostFailing() []CommandStats {
+	var failing []CommandStats
+	for _, cmd := range s.Commands {
+		if cmd.Failures > 0 {
+			failing = append(failing, cmd)
+		}
+	}
+	sort.SliceStable(failing, func(i, j int) bool { return failing[i].Failures > failing[j].Failures })
+	return failing
+}
+
+// SummarizeHistory computes pass rate and per-command duration percentiles.
+// Cached results do not count towards durations, skipped commands are ignored
+// and skipped invocations are only counted.
+func SummarizeHistory(records []HistoryRecord) HistoryStats {
+	var stats HistoryStats
+	byCommand := make(map[string]*CommandStats)
+	durations := make(map[string][]time.Duration)
+	var order []string
+
+	for _, record := range records {
+		if record.Skipped != "" {
+			stats.Skipped++
+			continue
+		}
+		stats.Runs++
+		if record.Passed {
+			stats.Passed++
+		}
+		for _, cmd := range record.Commands {
+			if cmd.Skipped {
+				continue
+			}
+			key := string(cmd.Type) + "\x00" + cmd.Command
+			entry, ok := byCommand[key]
+			if !ok {
+				entry = &CommandStats{Type: cmd.Type, Command: cmd.Command}
+				byCommand[key] = entry
+				order = append(order, key)
+			}
+			entry.Runs++
+			if !cmd.Success {
+				entry.Failures++
+			}
+			if cmd.Cached {
+				entry.Cached++
+				continue
+			}
+			durations[key] = append(durations[key], cmd.Duration())
+		}
+	}
+
+	for _, key := range order {
+		entry := byCommand[key]
+		entry.P50 = percentile(durations[key], 50)
+		entry.P95 = percentile(durations[key], 95)
+		stats.Commands = append(stats.Commands, *entry)
+	}
+	sort.SliceStable(stats.Commands, func(i, j int) bool { return stats.Commands[i].Runs > stats.Commands[j].Runs })
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of the durations.
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	const percent = 100
+	rank := (p*len(sorted) + percent - 1) / percent
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,196 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestNewHistoryRecord(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := &ValidateResult{
+		TestResult: &ValidationResult{
+			Success:  false,
+			ExitCode: 1,
+			Command:  &DiscoveredCommand{Command: "go", Args: []string{"test", "./..."}},
+			Duration: 1500 * time.Millisecond,
+			Cached:   true,
+		},
+	}
+
+	record := NewHistoryRecord(now, "session-1", "/repo", "/repo/pkg/a.go", result, &SkipConfig{SkipLint: true}, false)
+
+	if record.File != "pkg/a.go" || record.Project != "/repo" || record.SessionID != "session-1" {
+		t.Errorf("unexpected record identity: %+v", record)
+	}
+	if len(record.Commands) != 2 {
+		t.Fatalf("expected lint and test commands, got %+v", record.Commands)
+	}
+	if lint := record.Commands[0]; lint.Type != CommandTypeLint || !lint.Skipped {
+		t.Errorf("lint should be recorded as skipped: %+v", lint)
+	}
+	test := record.Commands[1]
+	if test.Command != "go test ./..." || test.Success || test.ExitCode != 1 || !test.Cached || test.DurationMS != 1500 {
+		t.Errorf("unexpected test command: %+v", test)
+	}
+	if record.Duration() != 1500*time.Millisecond {
+		t.Errorf("Duration() = %v", record.Duration())
+	}
+}
+
+func TestHistory_AppendLoadTrim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	history := NewHistory(config.HistoryConfig{Path: path}, NewDefaultDependencies())
+
+	for i := range 3 {
+		record := HistoryRecord{Project: "/repo", File: string(rune('a' + i)), Passed: true}
+		if err := history.Append(record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	records, err := history.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(records) != 3 || records[0].File != "a" || records[2].File != "c" {
+		t.Fatalf("Load() = %+v", records)
+	}
+
+	// A tiny limit trims the oldest half on every append
+	history.maxBytes = 1
+	if appendErr := history.Append(HistoryRecord{Project: "/repo", File: "d"}); appendErr != nil {
+		t.Fatalf("Append() error = %v", appendErr)
+	}
+	records, err = history.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(records) != 2 || records[0].File != "c" || records[1].File != "d" {
+		t.Errorf("after trim got %+v", records)
+	}
+}
+
+func TestHistory_LoadMissing(t *testing.T) {
+	history := NewHistory(config.HistoryConfig{Path: filepath.Join(t.TempDir(), "none.jsonl")}, nil)
+	records, err := history.Load()
+	if err != nil || len(records) != 0 {
+		t.Errorf("Load() = %v, %v; want no records", records, err)
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	records := []HistoryRecord{
+		{Project: "/a", SessionID: "s1", Passed: true},
+		{Project: "/a", SessionID: "s2", Passed: false},
+		{Project: "/b", SessionID: "s1", Passed: false},
+		{Project: "/b", SessionID: "s1", Skipped: HistorySkipBusy},
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   int
+	}{
+		{"no filter", HistoryFilter{}, 4},
+		{"project", HistoryFilter{Project: "/a"}, 2},
+		{"session", HistoryFilter{Session: "s1"}, 3},
+		{"failing", HistoryFilter{Status: HistoryStatusFail}, 2},
+		{"skipped", HistoryFilter{Status: HistoryStatusSkipped}, 1},
+		{"passing in project", HistoryFilter{Project: "/a", Status: HistoryStatusPass}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterHistory(records, tt.filter); len(got) != tt.want {
+				t.Errorf("FilterHistory() returned %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeHistory(t *testing.T) {
+	lint := func(ms int64, success bool) HistoryCommand {
+		return HistoryCommand{Type: CommandTypeLint, Command: "make lint", Success: success, DurationMS: ms}
+	}
+	test := HistoryCommand{Type: CommandTypeTest, Command: "make test", Success: false, DurationMS: 9000}
+
+	var records []HistoryRecord
+	for i := int64(1); i <= 10; i++ {
+		records = append(records, HistoryRecord{Passed: i > 2, Commands: []HistoryCommand{lint(i*100, i > 1)}})
+	}
+	records = append(records,
+		HistoryRecord{Commands: []HistoryCommand{test, {Type: CommandTypeLint, Skipped: true}}},
+		HistoryRecord{Commands: []HistoryCommand{test}},
+		HistoryRecord{Commands: []HistoryCommand{{Type: CommandTypeLint, Command: "make lint", Success: true, Cached: true}}},
+		HistoryRecord{Skipped: HistorySkipChecks},
+	)
+
+	stats := SummarizeHistory(records)
+	if stats.Runs != 13 || stats.Passed != 8 || stats.Skipped != 1 {
+		t.Errorf("runs/passed/skipped = %d/%d/%d, want 13/8/1", stats.Runs, stats.Passed, stats.Skipped)
+	}
+	if len(stats.Commands) != 2 {
+		t.Fatalf("expected two commands, got %+v", stats.Commands)
+	}
+
+	lintStats := stats.Commands[0]
+	if lintStats.Runs != 11 || lintStats.Failures != 1 || lintStats.Cached != 1 {
+		t.Errorf("unexpected lint stats: %+v", lintStats)
+	}
+	// Cached runs do not skew the percentiles
+	if lintStats.P50 != 500*time.Millisecond || lintStats.P95 != time.Second {
+		t.Errorf("lint p50/p95 = %v/%v, want 500ms/1s", lintStats.P50, lintStats.P95)
+	}
+
+	failing := stats.MostFailing()
+	if len(failing) != 2 || failing[0].Command != "make test" || failing[0].Failures != 2 {
+		t.Errorf("MostFailing() = %+v", failing)
+	}
+}
+
+func TestRunValidateHook_RecordsSkippedInvocations(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		skip   *SkipConfig
+		held   bool
+		want   string
+	}{
+		{name: "lint and test skipped", skip: &SkipConfig{SkipLint: true, SkipTest: true}, want: HistorySkipChecks},
+		{name: "lock held", policy: config.OnBusyDrop, held: true, want: HistorySkipBusy},
+		{name: "queued behind the holder", policy: config.OnBusyQueue, held: true, want: HistorySkipQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			setupQueueProject(testDeps, func() bool { return false })
+			var appended []byte
+			testDeps.MockFS.appendFunc = func(_ string, data []byte, _ os.FileMode) error {
+				appended = append(appended, data...)
+				return nil
+			}
+			if tt.held {
+				files.hold(NewLockManager("/project", "validate", 0, testDeps.Dependencies).lockFile)
+			}
+
+			cfg := queueConfig()
+			cfg.OnBusy = tt.policy
+			_ = RunValidateHookWithConfig(context.Background(), false, cfg, tt.skip, testDeps.Dependencies)
+
+			var record HistoryRecord
+			if err := json.Unmarshal(appended, &record); err != nil {
+				t.Fatalf("history record %q: %v", appended, err)
+			}
+			if record.Skipped != tt.want || record.File != "main.go" || len(record.Commands) != 0 {
+				t.Errorf("record = %+v, want skipped %q for main.go", record, tt.want)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/Veraticus/cc-tools/internal/shared"
+	"github.com/Veraticus/cc-tools/internal/config"
 )
 
 // TestHookInputParsing tests parsing of hook input JSON.
@@ -200,8 +200,8 @@ func TestIsEditToolOld(t *testing.T) {
 func TestLockManager(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	// Override temp dir for testing
-	t.Setenv("TMPDIR", tmpDir)
+	// Keep lock files inside the test directory
+	t.Setenv("XDG_RUNTIME_DIR", tmpDir)
 
 	t.Run("acquire and release lock", func(t *testing.T) {
 		lm := NewLockManager("/test/project", "test", 2, nil)
@@ -361,7 +361,7 @@ func TestRunSmartHookBasic(t *testing.T) {
 	t.Run("exits early when disabled", func(t *testing.T) {
 		t.Setenv("CLAUDE_HOOKS_LINT_ENABLED", "false")
 
-		code := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 2, nil)
+		code := RunSmartHook(context.Background(), CommandTypeLint, false, 20, 2, config.FilterConfig{}, nil)
 		if code != 0 {
 			t.Errorf("Expected exit code 0 when disabled, got %d", code)
 		}
@@ -433,27 +433,49 @@ func TestFileSkipping(t *testing.T) {
 			shouldSkip: true,
 		},
 		{
-			name:       "skip test files",
+			name:       "keep test files so they trigger tests",
 			filePath:   "/project/main_test.go",
-			shouldSkip: true,
+			shouldSkip: false,
 		},
 		{
 			name:       "skip generated files",
 			filePath:   "/project/api.generated.go",
 			shouldSkip: true,
 		},
+		{
+			name:       "skip build output",
+			filePath:   "/project/build/output.js",
+			shouldSkip: true,
+		},
+		{
+			name:       "skip rust target",
+			filePath:   "/project/target/release/main.rs",
+			shouldSkip: true,
+		},
+		{
+			name:       "skip protobuf files",
+			filePath:   "/project/api/service.pb.go",
+			shouldSkip: true,
+		},
 		{
 			name:       "process regular files",
 			filePath:   "/project/main.go",
 			shouldSkip: false,
 		},
+		{
+			name:       "generated in name but not suffix",
+			filePath:   "/project/generated_utils.go",
+			shouldSkip: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			shouldSkip := shared.ShouldSkipFile(tt.filePath)
-			if shouldSkip != tt.shouldSkip {
-				t.Errorf("ShouldSkipFile(%s) = %v, want %v", tt.filePath, shouldSkip, tt.shouldSkip)
+			testDeps := createTestDependencies()
+			lint, test := NewFileFilter("/project", config.FilterConfig{}, testDeps.Dependencies).
+				Triggers(context.Background(), tt.filePath)
+			if shouldSkip := !lint && !test; shouldSkip != tt.shouldSkip {
+				t.Errorf("Triggers(%s) = lint %v, test %v; want skipped %v", tt.filePath, lint, test, tt.shouldSkip)
 			}
 		})
 	}
@@ -480,7 +502,7 @@ func BenchmarkDiscovery(b *testing.B) {
 // BenchmarkLockManager benchmarks lock operations.
 func BenchmarkLockManager(b *testing.B) {
 	tmpDir := b.TempDir()
-	b.Setenv("TMPDIR", tmpDir)
+	b.Setenv("XDG_RUNTIME_DIR", tmpDir)
 
 	b.ResetTimer()
 	for i := range b.N {
@@ -0,0 +1,392 @@
+package hooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// goListPackage is a package in `go list -deps -test -json` output.
+type goListPackage struct {
+	ImportPath string
+	Dir        string
+	// ForTest names the package whose test binary this variant belongs to.
+	ForTest  string
+	Standard bool
+	Module   *struct {
+		Main bool
+	}
+	Imports      []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+// importGraph is the import graph of the packages of a Go module.
+type importGraph struct {
+	// Key identifies go.mod and the set of Go files the graph was built from.
+	Key      string                   `json:"key"`
+	Packages map[string]*graphPackage `json:"packages"`
+}
+
+// graphPackage is a package of the module. Its imports include the imports
+// of its tests and are limited to packages of the module.
+type graphPackage struct {
+	Dir      string   `json:"dir"`
+	Imports  []string `json:"imports,omitempty"`
+	HasTests bool     `json:"has_tests,omitempty"`
+}
+
+// ImpactSelector selects the tests affected by an edit to a Go package: those
+// of the package itself and of every package that transitively imports it.
+type ImpactSelector struct {
+	cfg         config.ImpactConfig
+	projectRoot string
+	executor    *CommandExecutor
+	deps        *Dependencies
+}
+
+// NewImpactSelector creates an impact selector for the project.
+func NewImpactSelector(
+	projectRoot string,
+	cfg config.ImpactConfig,
+	timeoutSecs int,
+	deps *Dependencies,
+) *ImpactSelector {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &ImpactSelector{
+		cfg:         cfg,
+		projectRoot: projectRoot,
+		executor:    NewCommandExecutor(timeoutSecs, false, deps),
+		deps:        deps,
+	}
+}
+
+// TestCommand returns a go test command for the packages affected by edits
+// to filePaths, or nil when one of the files is not part of a package of the
+// same Go module.
+func (s *ImpactSelector) TestCommand(ctx context.Context, filePaths ...string) *DiscoveredCommand {
+	var (
+		moduleRoot string
+		graph      *importGraph
+		edited     []string
+		refreshed  bool
+	)
+	for _, filePath := range filePaths {
+		if filepath.Ext(filePath) != ".go" {
+			return nil
+		}
+		root := s.moduleRoot(filepath.Dir(filePath))
+		if root == "" || (graph != nil && root != moduleRoot) {
+			return nil
+		}
+		if graph == nil {
+			var err error
+			if graph, err = s.graph(ctx, root); err != nil {
+				return nil
+			}
+			moduleRoot = root
+		}
+		pkg := graph.packageInDir(filepath.Dir(filePath))
+		if pkg == "" {
+			return nil
+		}
+
+		// Edits reach the hook one at a time, so refreshing the imports of each
+		// edited package keeps the cached graph current between rebuilds
+		if s.refreshImports(graph, pkg) {
+			refreshed = true
+		}
+		edited = append(edited, pkg)
+	}
+	if graph == nil {
+		return nil
+	}
+	if refreshed {
+		_ = s.save(moduleRoot, graph)
+	}
+
+	return &DiscoveredCommand{
+		Type:       CommandTypeTest,
+		Command:    "go",
+		Args:       append([]string{"test"}, graph.impacted(edited, s.cfg.GetMaxPackages())...),
+		WorkingDir: moduleRoot,
+		Source:     "impact",
+	}
+}
+
+// moduleRoot returns the nearest directory from dir up to the project root that has a go.mod.
+func (s *ImpactSelector) moduleRoot(dir string) string {
+	for {
+		if _, err := s.deps.FS.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if dir == s.projectRoot || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// graph returns the module's import graph, from the cache when go.mod and
+// the set of Go files are unchanged.
+func (s *ImpactSelector) graph(ctx context.Context, moduleRoot string) (*importGraph, error) {
+	key, err := s.graphKey(moduleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, readErr := s.deps.FS.ReadFile(s.cachePath(moduleRoot)); readErr == nil {
+		var cached importGraph
+		if json.Unmarshal(data, &cached) == nil && cached.Key == key {
+			return &cached, nil
+		}
+	}
+
+	cmd := &DiscoveredCommand{
+		Command:    "go",
+		Args:       []string{"list", "-e", "-deps", "-test", "-json", "./..."},
+		WorkingDir: moduleRoot,
+	}
+	result := s.executor.Execute(ctx, cmd)
+	if !result.Success {
+		return nil, fmt.Errorf("go list: %w", result.Error)
+	}
+	graph, err := parseGoList(result.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	graph.Key = key
+	_ = s.save(moduleRoot, graph)
+	return graph, nil
+}
+
+// graphKey hashes go.mod and the paths of the module's Go files, so adding or
+// removing a file or changing dependencies invalidates the cached graph.
+func (s *ImpactSelector) graphKey(moduleRoot string) (string, error) {
+	goMod, err := s.deps.FS.ReadFile(filepath.Join(moduleRoot, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("read go.mod: %w", err)
+	}
+	hash := sha256.New()
+	hash.Write(goMod)
+	for _, path := range s.goFiles(moduleRoot, "") {
+		hash.Write([]byte{0})
+		hash.Write([]byte(path))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// goFiles lists the Go files below dir, relative to it, skipping directories
+// the go command ignores and nested modules.
+func (s *ImpactSelector) goFiles(moduleRoot, rel string) []string {
+	entries, err := s.deps.FS.ReadDir(filepath.Join(moduleRoot, rel))
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(rel, name)
+		if !entry.IsDir() {
+			if strings.HasSuffix(name, ".go") {
+				files = append(files, path)
+			}
+			continue
+		}
+		if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "testdata" || name == "vendor" || name == "node_modules" {
+			continue
+		}
+		if _, statErr := s.deps.FS.Stat(filepath.Join(moduleRoot, path, "go.mod")); statErr == nil {
+			continue
+		}
+		files = append(files, s.goFiles(moduleRoot, path)...)
+	}
+	return files
+}
+
+// refreshImports re-reads the imports of a package from its files and
+// reports whether they changed.
+func (s *ImpactSelector) refreshImports(graph *importGraph, importPath string) bool {
+	pkg := graph.Packages[importPath]
+	entries, err := s.deps.FS.ReadDir(pkg.Dir)
+	if err != nil {
+		return false
+	}
+
+	fset := token.NewFileSet()
+	imports := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		path := filepath.Join(pkg.Dir, entry.Name())
+		data, readErr := s.deps.FS.ReadFile(path)
+		if readErr != nil {
+			return false
+		}
+		file, parseErr := parser.ParseFile(fset, path, data, parser.ImportsOnly)
+		if parseErr != nil {
+			// A file being edited may not parse yet; keep what go list saw
+			return false
+		}
+		for _, spec := range file.Imports {
+			if imported, unquoteErr := strconv.Unquote(spec.Path.Value); unquoteErr == nil {
+				imports[imported] = true
+			}
+		}
+	}
+
+	refreshed := graph.moduleImports(importPath, imports)
+	if slices.Equal(refreshed, pkg.Imports) {
+		return false
+	}
+	pkg.Imports = refreshed
+	return true
+}
+
+// cachePath returns the cache file of a module's import graph.
+func (s *ImpactSelector) cachePath(moduleRoot string) string {
+	sum := sha256.Sum256([]byte(moduleRoot))
+	return filepath.Join(s.cfg.GetDir(), hex.EncodeToString(sum[:8])+".json")
+}
+
+// save writes the import graph to the cache.
+func (s *ImpactSelector) save(moduleRoot string, graph *importGraph) error {
+	data, err := json.Marshal(graph)
+	if err != nil {
+		return fmt.Errorf("marshal import graph: %w", err)
+	}
+	if mkdirErr := s.deps.FS.MkdirAll(s.cfg.GetDir(), cacheDirMode); mkdirErr != nil {
+		return fmt.Errorf("create impact cache: %w", mkdirErr)
+	}
+	if writeErr := s.deps.FS.WriteFile(s.cachePath(moduleRoot), data, lockFileMode); writeErr != nil {
+		return fmt.Errorf("write import graph: %w", writeErr)
+	}
+	return nil
+}
+
+// parseGoList builds the import graph of the main module from `go list -deps -test -json`.
+// Test variants such as "p [p.test]" and the packages recompiled for them all
+// belong to the test binary of p, so their imports are counted as p's.
+func parseGoList(output string) (*importGraph, error) {
+	graph := &importGraph{Packages: make(map[string]*graphPackage)}
+	imports := make(map[string]map[string]bool)
+
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for {
+		var pkg goListPackage
+		if err := decoder.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("parse go list output: %w", err)
+		}
+		if pkg.Standard || pkg.Module == nil || !pkg.Module.Main {
+			continue
+		}
+		// The generated main package of a test binary is not a package of the module
+		if pkg.ForTest == "" && strings.HasSuffix(pkg.ImportPath, ".test") {
+			continue
+		}
+
+		name := pkg.ForTest
+		if name == "" {
+			name = pkg.ImportPath
+			graph.Packages[name] = &graphPackage{
+				Dir:      pkg.Dir,
+				HasTests: len(pkg.TestGoFiles)+len(pkg.XTestGoFiles) > 0,
+			}
+		}
+		if imports[name] == nil {
+			imports[name] = make(map[string]bool)
+		}
+		for _, imported := range pkg.Imports {
+			imported, _, _ = strings.Cut(imported, " [")
+			imports[name][imported] = true
+		}
+	}
+
+	for name, pkg := range graph.Packages {
+		pkg.Imports = graph.moduleImports(name, imports[name])
+	}
+	return graph, nil
+}
+
+// moduleImports returns the sorted imports of a package that are other packages of the module.
+func (g *importGraph) moduleImports(importPath string, imports map[string]bool) []string {
+	var kept []string
+	for imported := range imports {
+		if _, ok := g.Packages[imported]; ok && imported != importPath {
+			kept = append(kept, imported)
+		}
+	}
+	slices.Sort(kept)
+	return kept
+}
+
+// packageInDir returns the package whose directory is dir.
+func (g *importGraph) packageInDir(dir string) string {
+	for name, pkg := range g.Packages {
+		if pkg.Dir == dir {
+			return name
+		}
+	}
+	return ""
+}
+
+// impacted returns the edited packages followed by the packages with tests
+// that transitively import one of them, nearest first and sorted within a
+// distance, capped at limit packages.
+func (g *importGraph) impacted(edited []string, limit int) []string {
+	importers := make(map[string][]string)
+	for name, pkg := range g.Packages {
+		for _, imported := range pkg.Imports {
+			importers[imported] = append(importers[imported], name)
+		}
+	}
+
+	var selected []string
+	seen := make(map[string]bool)
+	for _, name := range edited {
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, name)
+		}
+	}
+	level := slices.Clone(selected)
+	for len(level) > 0 && len(selected) < limit {
+		var next []string
+		for _, name := range level {
+			for _, importer := range importers[name] {
+				if !seen[importer] {
+					seen[importer] = true
+					next = append(next, importer)
+				}
+			}
+		}
+		slices.Sort(next)
+		for _, name := range next {
+			if g.Packages[name].HasTests && len(selected) < limit {
+				selected = append(selected, name)
+			}
+		}
+		level = next
+	}
+	return selected
+}
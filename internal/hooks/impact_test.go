@@ -0,0 +1,205 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// goListOutput is `go list -deps -test -json ./...` output for a module where
+// api imports util, web and cmd import api, and only the tests of other import util.
+const goListOutput = `
+{"ImportPath":"fmt","Standard":true}
+{"ImportPath":"example.com/m/util","Dir":"/project/util","Module":{"Main":true},
+ "Imports":["fmt"],"TestGoFiles":["util_test.go"]}
+{"ImportPath":"example.com/m/api","Dir":"/project/api","Module":{"Main":true},
+ "Imports":["example.com/m/util"],"TestGoFiles":["api_test.go"]}
+{"ImportPath":"example.com/m/web","Dir":"/project/web","Module":{"Main":true},
+ "Imports":["example.com/m/api"],"XTestGoFiles":["web_test.go"]}
+{"ImportPath":"example.com/m/cmd","Dir":"/project/cmd","Module":{"Main":true},
+ "Imports":["example.com/m/api"]}
+{"ImportPath":"example.com/m/other","Dir":"/project/other","Module":{"Main":true},
+ "TestGoFiles":["other_test.go"]}
+{"ImportPath":"example.com/m/other [example.com/m/other.test]","ForTest":"example.com/m/other",
+ "Dir":"/project/other","Module":{"Main":true},"Imports":["example.com/m/util"]}
+{"ImportPath":"example.com/m/other.test","Dir":"/project/other","Module":{"Main":true},
+ "Imports":["example.com/m/other [example.com/m/other.test]"]}
+{"ImportPath":"github.com/dep/lib","Dir":"/mod/lib","Module":{"Main":false},"Imports":["example.com/m/util"]}
+`
+
+func TestParseGoList_Impacted(t *testing.T) {
+	graph, err := parseGoList(goListOutput)
+	if err != nil {
+		t.Fatalf("parseGoList() error = %v", err)
+	}
+	if len(graph.Packages) != 5 {
+		t.Fatalf("packages = %v, want the 5 packages of the module", graph.Packages)
+	}
+
+	tests := []struct {
+		name   string
+		edited string
+		limit  int
+		want   string
+	}{
+		{
+			name:   "shared util",
+			edited: "example.com/m/util",
+			limit:  10,
+			want:   "example.com/m/util example.com/m/api example.com/m/other example.com/m/web",
+		},
+		{
+			name:   "nearest importers first",
+			edited: "example.com/m/util",
+			limit:  2,
+			want:   "example.com/m/util example.com/m/api",
+		},
+		{
+			name:   "leaf package",
+			edited: "example.com/m/web",
+			limit:  10,
+			want:   "example.com/m/web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(graph.impacted([]string{tt.edited}, tt.limit), " "); got != tt.want {
+				t.Errorf("impacted() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// impactProject is a Go module on a fake filesystem whose go list output is goListOutput.
+type impactProject struct {
+	mu      sync.Mutex
+	files   fstest.MapFS
+	goLists int
+}
+
+func setupImpactProject(deps *TestDependencies) *impactProject {
+	p := &impactProject{files: fstest.MapFS{
+		"project/go.mod":                 {Data: []byte("module example.com/m\n")},
+		"project/util/util.go":           {Data: []byte("package util\n")},
+		"project/util/util_test.go":      {Data: []byte("package util\n")},
+		"project/api/api.go":             {Data: []byte("package api\n\nimport \"example.com/m/util\"\n")},
+		"project/web/web.go":             {Data: []byte("package web\n\nimport \"example.com/m/api\"\n")},
+		"project/cmd/main.go":            {Data: []byte("package main\n\nimport \"example.com/m/api\"\n")},
+		"project/other/other.go":         {Data: []byte("package other\n")},
+		"project/other/other_test.go":    {Data: []byte("package other\n\nimport \"example.com/m/util\"\n")},
+		"project/.git/HEAD":              {Data: []byte("ref: refs/heads/main\n")},
+		"project/util/testdata/input.go": {Data: []byte("not go\n")},
+	}}
+
+	path := func(name string) string { return strings.TrimPrefix(name, "/") }
+	deps.MockFS.statFunc = func(name string) (os.FileInfo, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return fs.Stat(p.files, path(name))
+	}
+	deps.MockFS.readDirFunc = func(name string) ([]os.DirEntry, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return fs.ReadDir(p.files, path(name))
+	}
+	deps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return fs.ReadFile(p.files, path(name))
+	}
+	deps.MockFS.writeFileFunc = func(name string, data []byte, _ os.FileMode) error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.files[path(name)] = &fstest.MapFile{Data: data}
+		return nil
+	}
+	deps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		if name == "go" && args[0] == "list" {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.goLists++
+			return &CommandOutput{Stdout: []byte(goListOutput)}, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+	return p
+}
+
+func (p *impactProject) write(name, content string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[name] = &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestImpactSelector_TestCommand(t *testing.T) {
+	testDeps := createTestDependencies()
+	project := setupImpactProject(testDeps)
+	cfg := config.ImpactConfig{Enabled: true, Dir: "/cache/impact"}
+	selector := NewImpactSelector("/project", cfg, 10, testDeps.Dependencies)
+	ctx := context.Background()
+
+	cmd := selector.TestCommand(ctx, "/project/util/util.go")
+	want := "go test example.com/m/util example.com/m/api example.com/m/other example.com/m/web"
+	if cmd == nil || cmd.String() != want || cmd.WorkingDir != "/project" {
+		t.Fatalf("TestCommand() = %+v, want %q in /project", cmd, want)
+	}
+
+	// Editing a file does not rebuild the graph
+	project.write("project/api/api.go", "package api\n\nimport \"example.com/m/util\"\n\nfunc API() {}\n")
+	if cmd := selector.TestCommand(ctx, "/project/api/api.go"); cmd == nil ||
+		cmd.String() != "go test example.com/m/api example.com/m/web" {
+		t.Errorf("TestCommand() = %v", cmd)
+	}
+	if project.goLists != 1 {
+		t.Errorf("go list ran %d times, want the cached graph to be reused", project.goLists)
+	}
+
+	// A new import is picked up from the edited package's files
+	project.write("project/web/web.go", "package web\n\nimport (\n\t\"example.com/m/api\"\n\t\"example.com/m/other\"\n)\n")
+	selector.TestCommand(ctx, "/project/web/web.go")
+	if cmd := selector.TestCommand(ctx, "/project/other/other.go"); cmd == nil ||
+		cmd.String() != "go test example.com/m/other example.com/m/web" {
+		t.Errorf("TestCommand() after new import = %v", cmd)
+	}
+
+	// Adding a file rebuilds the graph
+	project.write("project/util/more.go", "package util\n")
+	selector.TestCommand(ctx, "/project/util/util.go")
+	if project.goLists != 2 {
+		t.Errorf("go list ran %d times, want a rebuild after adding a file", project.goLists)
+	}
+
+	for _, path := range []string{"/project/README.md", "/elsewhere/main.go"} {
+		if cmd := selector.TestCommand(ctx, path); cmd != nil {
+			t.Errorf("TestCommand(%s) = %v, want none", path, cmd)
+		}
+	}
+}
+
+func TestImpactSelector_TestCommandCoversEveryEdit(t *testing.T) {
+	testDeps := createTestDependencies()
+	setupImpactProject(testDeps)
+	cfg := config.ImpactConfig{Enabled: true, Dir: "/cache/impact"}
+	selector := NewImpactSelector("/project", cfg, 10, testDeps.Dependencies)
+	ctx := context.Background()
+
+	cmd := selector.TestCommand(ctx, "/project/web/web.go", "/project/other/other.go", "/project/web/more.go")
+	if want := "go test example.com/m/web example.com/m/other"; cmd == nil || cmd.String() != want {
+		t.Errorf("TestCommand() = %v, want %q", cmd, want)
+	}
+
+	// An edit that maps to no package, or an unknown one, tests everything
+	for _, other := range []string{"/project/README.md", ""} {
+		if cmd := selector.TestCommand(ctx, "/project/web/web.go", other); cmd != nil {
+			t.Errorf("TestCommand(web.go, %q) = %v, want none", other, cmd)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // ErrNoInput is returned when no input is available on stdin.
@@ -141,6 +142,92 @@ func (h *HookInput) GetFilePath() string {
 	return ""
 }
 
+// TextEdit is one replacement made by an Edit or MultiEdit call.
+type TextEdit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// TextEdits returns the replacements of an Edit or MultiEdit call in the
+// order they were applied. It reports false for other tools, whose input
+// does not say what changed.
+func (h *HookInput) TextEdits() ([]TextEdit, bool) {
+	if h.ToolName != "Edit" && h.ToolName != "MultiEdit" {
+		return nil, false
+	}
+
+	var toolInput struct {
+		TextEdit
+
+		Edits []TextEdit `json:"edits"`
+	}
+	if err := json.Unmarshal(h.ToolInput, &toolInput); err != nil {
+		return nil, false
+	}
+	if h.ToolName == "Edit" {
+		return []TextEdit{toolInput.TextEdit}, true
+	}
+	return toolInput.Edits, true
+}
+
+// WrittenContent returns the content a Write call writes. It reports false
+// for other tools.
+func (h *HookInput) WrittenContent() (string, bool) {
+	if h.ToolName != "Write" {
+		return "", false
+	}
+
+	var toolInput struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(h.ToolInput, &toolInput); err != nil {
+		return "", false
+	}
+	return toolInput.Content, true
+}
+
+// WriteCreated reports whether a Write call created its file rather than
+// replacing one. The second result is false for other tools and when the
+// tool response does not say.
+func (h *HookInput) WriteCreated() (bool, bool) {
+	if h.ToolName != "Write" || len(h.ToolResponse) == 0 {
+		return false, false
+	}
+
+	var response struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(h.ToolResponse, &response); err != nil {
+		return false, false
+	}
+	switch response.Type {
+	case "create":
+		return true, true
+	case "update":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// EditedText returns the text an Edit or MultiEdit call replaced and the text
+// it inserted, joined across edits. It reports false for other tools, whose
+// input does not say what changed.
+func (h *HookInput) EditedText() (string, string, bool) {
+	edits, ok := h.TextEdits()
+	if !ok {
+		return "", "", false
+	}
+	oldParts := make([]string, 0, len(edits))
+	newParts := make([]string, 0, len(edits))
+	for _, e := range edits {
+		oldParts = append(oldParts, e.OldString)
+		newParts = append(newParts, e.NewString)
+	}
+	return strings.Join(oldParts, "\n"), strings.Join(newParts, "\n"), true
+}
+
 // IsEditTool returns true if this is an edit-related tool.
 func (h *HookInput) IsEditTool() bool {
 	switch h.ToolName {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"reflect"
 	"testing"
 )
 
@@ -482,6 +483,111 @@ func TestIsEditTool(t *testing.T) {
 	}
 }
 
+func TestEditedText(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   *HookInput
+		wantOld string
+		wantNew string
+		wantOK  bool
+	}{
+		{
+			name: "Edit",
+			input: &HookInput{
+				ToolName:  "Edit",
+				ToolInput: json.RawMessage(`{"file_path":"/a.go","old_string":"foo","new_string":"bar"}`),
+			},
+			wantOld: "foo",
+			wantNew: "bar",
+			wantOK:  true,
+		},
+		{
+			name: "MultiEdit joins edits",
+			input: &HookInput{
+				ToolName: "MultiEdit",
+				ToolInput: json.RawMessage(`{"file_path":"/a.go","edits":[` +
+					`{"old_string":"a","new_string":"b"},{"old_string":"c","new_string":"d"}]}`),
+			},
+			wantOld: "a\nc",
+			wantNew: "b\nd",
+			wantOK:  true,
+		},
+		{
+			name:  "Write has no previous text",
+			input: &HookInput{ToolName: "Write", ToolInput: json.RawMessage(`{"file_path":"/a.go","content":"x"}`)},
+		},
+		{
+			name:  "invalid tool input",
+			input: &HookInput{ToolName: "Edit", ToolInput: json.RawMessage(`[]`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOld, gotNew, ok := tt.input.EditedText()
+			if gotOld != tt.wantOld || gotNew != tt.wantNew || ok != tt.wantOK {
+				t.Errorf("EditedText() = %q, %q, %v, want %q, %q, %v",
+					gotOld, gotNew, ok, tt.wantOld, tt.wantNew, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTextEdits(t *testing.T) {
+	input := &HookInput{
+		ToolName: "MultiEdit",
+		ToolInput: json.RawMessage(`{"file_path":"/a.go","edits":[` +
+			`{"old_string":"a","new_string":"b","replace_all":true},{"old_string":"c","new_string":"d"}]}`),
+	}
+	edits, ok := input.TextEdits()
+	want := []TextEdit{{OldString: "a", NewString: "b", ReplaceAll: true}, {OldString: "c", NewString: "d"}}
+	if !ok || !reflect.DeepEqual(edits, want) {
+		t.Errorf("TextEdits() = %+v, %v, want %+v, true", edits, ok, want)
+	}
+
+	if _, ok := (&HookInput{ToolName: "Write"}).TextEdits(); ok {
+		t.Error("TextEdits() reported edits for Write")
+	}
+}
+
+func TestWrittenContent(t *testing.T) {
+	write := &HookInput{ToolName: "Write", ToolInput: json.RawMessage(`{"file_path":"/a.go","content":"package a\n"}`)}
+	if content, ok := write.WrittenContent(); !ok || content != "package a\n" {
+		t.Errorf("WrittenContent() = %q, %v, want %q, true", content, ok, "package a\n")
+	}
+
+	edit := &HookInput{ToolName: "Edit", ToolInput: json.RawMessage(`{"file_path":"/a.go","new_string":"x"}`)}
+	if _, ok := edit.WrittenContent(); ok {
+		t.Error("WrittenContent() reported content for Edit")
+	}
+}
+
+func TestWriteCreated(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		response    string
+		wantCreated bool
+		wantKnown   bool
+	}{
+		{name: "created", tool: "Write", response: `{"type":"create","filePath":"/a.go"}`, wantCreated: true,
+			wantKnown: true},
+		{name: "updated", tool: "Write", response: `{"type":"update","filePath":"/a.go"}`, wantKnown: true},
+		{name: "no response", tool: "Write"},
+		{name: "other tool", tool: "Edit", response: `{"type":"create"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &HookInput{ToolName: tt.tool, ToolResponse: json.RawMessage(tt.response)}
+			created, known := input.WriteCreated()
+			if created != tt.wantCreated || known != tt.wantKnown {
+				t.Errorf("WriteCreated() = %v, %v, want %v, %v", created, known, tt.wantCreated, tt.wantKnown)
+			}
+		})
+	}
+}
+
 func TestJSONMarshaling(t *testing.T) {
 	t.Run("HookInput marshals correctly", func(t *testing.T) {
 		input := &HookInput{
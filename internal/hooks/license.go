@@ -0,0 +1,204 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+var (
+	// pythonEncodingPattern matches the encoding declaration Python reads
+	// from the first two lines of a file.
+	pythonEncodingPattern = regexp.MustCompile(`^[ \t\f]*#.*?coding[:=][ \t]*[-\w.]+`)
+	// buildConstraintPattern matches a Go build constraint line.
+	buildConstraintPattern = regexp.MustCompile(`^//(?:go:build\s|\s*\+build\s)`)
+)
+
+const (
+	// licenseYear stands for the year in a header template.
+	licenseYear = "{year}"
+	// licenseYearPattern matches a year or a range of years in a header.
+	licenseYearPattern = `\d{4}(?:\s*-\s*\d{4})?`
+	// encodingLines is how many leading lines may hold an encoding declaration.
+	encodingLines = 2
+	// licenseFileMode is used when rewriting a new file. The file exists, so
+	// its mode is kept.
+	licenseFileMode = 0o644
+)
+
+// licenseTemplate returns the header template for path, looked up by file
+// extension and then by language. It returns "" when none is configured.
+func licenseTemplate(path string, templates map[string]string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if template, ok := templates[ext]; ok {
+		return template
+	}
+	if language := placeholderLanguage(ext); language != "" {
+		return templates[language]
+	}
+	return ""
+}
+
+// headerLines splits a header template into lines without surrounding
+// newlines or trailing whitespace.
+func headerLines(template string) []string {
+	lines := strings.Split(strings.Trim(template, "\r\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
+
+// renderHeader returns the header a new file written in year must start with.
+func renderHeader(template string, year int) string {
+	return strings.Join(headerLines(strings.ReplaceAll(template, licenseYear, strconv.Itoa(year))), "\n")
+}
+
+// headerPatterns compiles each line of a header template, with {year}
+// matching any year or range of years.
+func headerPatterns(template string) []*regexp.Regexp {
+	lines := headerLines(template)
+	patterns := make([]*regexp.Regexp, 0, len(lines))
+	for _, line := range lines {
+		parts := strings.Split(line, licenseYear)
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		patterns = append(patterns, regexp.MustCompile("^"+strings.Join(parts, licenseYearPattern)+"$"))
+	}
+	return patterns
+}
+
+// preambleEnd returns how many leading lines must stay above a license
+// header: a shebang and a Python encoding declaration.
+func preambleEnd(lines []string) int {
+	n := 0
+	if n < len(lines) && strings.HasPrefix(lines[n], "#!") {
+		n++
+	}
+	if n < len(lines) && n < encodingLines && pythonEncodingPattern.MatchString(lines[n]) {
+		n++
+	}
+	return n
+}
+
+// hasLicenseHeader reports whether content starts with the header after its
+// preamble. Go build constraints and blank lines may come before the header.
+func hasLicenseHeader(content string, patterns []*regexp.Regexp) bool {
+	lines := strings.Split(content, "\n")
+	start := preambleEnd(lines)
+	for start < len(lines) {
+		line := strings.TrimSpace(lines[start])
+		if line != "" && !buildConstraintPattern.MatchString(line) {
+			break
+		}
+		start++
+	}
+	if len(lines)-start < len(patterns) {
+		return false
+	}
+	for i, pattern := range patterns {
+		if !pattern.MatchString(strings.TrimRight(lines[start+i], " \t\r")) {
+			return false
+		}
+	}
+	return true
+}
+
+// insertLicenseHeader returns content with header placed after its preamble
+// and separated from the rest by a blank line. Go build constraints stay
+// valid below the header, as they only need to precede the package clause.
+func insertLicenseHeader(content, header string) string {
+	lines := strings.SplitAfter(content, "\n")
+	n := preambleEnd(lines)
+
+	var b strings.Builder
+	for _, line := range lines[:n] {
+		b.WriteString(line)
+	}
+	if n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(header + "\n")
+	rest := strings.Join(lines[n:], "")
+	if rest != "" && strings.TrimSpace(strings.SplitN(rest, "\n", 2)[0]) != "" {
+		b.WriteString("\n")
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// isNewFile reports whether the hook input is a Write that created filePath.
+func isNewFile(ctx context.Context, filePath string, input *HookInput, deps *Dependencies) bool {
+	if input.ToolName != "Write" {
+		return false
+	}
+	if created, ok := input.WriteCreated(); ok {
+		return created
+	}
+	// Without a tool response, a file missing from HEAD counts as new
+	_, err := deps.Runner.RunContext(ctx, filepath.Dir(filePath), "git", "cat-file", "-e",
+		"HEAD:./"+filepath.Base(filePath))
+	return err != nil
+}
+
+// checkLicense requires a file created by Write to start with the license
+// header configured for its extension. With insertion enabled a missing
+// header is added to the file instead of being reported.
+func checkLicense(
+	ctx context.Context,
+	filePath string,
+	input *HookInput,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckLicense)
+	if !cfg.License.Enabled {
+		return finding{severity: severity}
+	}
+	template := licenseTemplate(filePath, cfg.License.Templates)
+	if strings.TrimSpace(template) == "" || !isNewFile(ctx, filePath, input, deps) {
+		return finding{severity: severity}
+	}
+
+	content, err := deps.FS.ReadFile(filePath)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "reading new file for the license check")
+		}
+		return finding{severity: severity}
+	}
+	if generatedPattern.Match(content[:min(len(content), generatedHeaderBytes)]) ||
+		hasLicenseHeader(string(content), headerPatterns(template)) {
+		return finding{severity: severity}
+	}
+
+	header := renderHeader(template, deps.Clock.Now().Year())
+	name := filepath.Base(filePath)
+	if cfg.License.Insert {
+		err = deps.FS.WriteFile(filePath, []byte(insertLicenseHeader(string(content), header)), licenseFileMode)
+		if err == nil {
+			return finding{severity: severity, info: fmt.Sprintf("📄 Added the license header to %s", name)}
+		}
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "inserting license header")
+		}
+	}
+
+	where := "at the top of the file"
+	if n := preambleEnd(strings.Split(string(content), "\n")); n > 0 {
+		where = fmt.Sprintf("after line %d", n)
+	}
+	return finding{
+		message: formatFailure(severity, "New file %s is missing its license header. Add these lines %s, "+
+			"followed by a blank line:\n\n%s\n", name, where, header),
+		severity: severity,
+	}
+}
@@ -0,0 +1,156 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestHasLicenseHeader(t *testing.T) {
+	const template = "// SPDX-License-Identifier: MIT\n// Copyright {year} Acme\n"
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "header at the top", content: "// SPDX-License-Identifier: MIT\n// Copyright 2021 Acme\n\npackage a\n",
+			want: true},
+		{name: "range of years", content: "// SPDX-License-Identifier: MIT\n// Copyright 2019-2024 Acme\n", want: true},
+		{name: "after build constraints",
+			content: "//go:build linux\n// +build linux\n\n// SPDX-License-Identifier: MIT\n// Copyright 2024 Acme\n",
+			want:    true},
+		{name: "after shebang", content: "#!/bin/sh\n// SPDX-License-Identifier: MIT\n// Copyright 2024 Acme\n",
+			want: true},
+		{name: "missing", content: "package a\n"},
+		{name: "other license", content: "// SPDX-License-Identifier: Apache-2.0\n// Copyright 2024 Acme\n"},
+		{name: "not a year", content: "// SPDX-License-Identifier: MIT\n// Copyright now Acme\n"},
+		{name: "header too late", content: "package a\n\n// SPDX-License-Identifier: MIT\n// Copyright 2024 Acme\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasLicenseHeader(tt.content, headerPatterns(template)); got != tt.want {
+				t.Errorf("hasLicenseHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertLicenseHeader(t *testing.T) {
+	const header = "# SPDX-License-Identifier: MIT"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "empty file", content: "", want: header + "\n"},
+		{name: "plain file", content: "import os\n", want: header + "\n\nimport os\n"},
+		{name: "leading blank line kept", content: "\nimport os\n", want: header + "\n\nimport os\n"},
+		{name: "shebang and encoding", content: "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\nimport os\n",
+			want: "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\n" + header + "\n\nimport os\n"},
+		{name: "shebang only", content: "#!/bin/sh", want: "#!/bin/sh\n" + header + "\n"},
+		{name: "go build constraint", content: "//go:build linux\n\npackage a\n",
+			want: header + "\n\n//go:build linux\n\npackage a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := insertLicenseHeader(tt.content, header); got != tt.want {
+				t.Errorf("insertLicenseHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_License(t *testing.T) {
+	const content = "//go:build linux\n\npackage main\n"
+	write := func(responseType string) string {
+		toolInput, _ := json.Marshal(map[string]string{"file_path": "/project/tool.go", "content": content})
+		return `{"hook_event_name":"PostToolUse","tool_name":"Write","tool_input":` + string(toolInput) +
+			`,"tool_response":{"type":"` + responseType + `","filePath":"/project/tool.go"}}`
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		insert     bool
+		templates  map[string]string
+		wantExit   int
+		wantStderr string
+		wantFile   string
+	}{
+		{
+			name:     "missing header blocks with the exact header",
+			input:    write("create"),
+			wantExit: ExitCodeShowMessage,
+			wantStderr: "tool.go is missing its license header. Add these lines at the top of the file, " +
+				"followed by a blank line:\n\n// SPDX-License-Identifier: MIT\n// Copyright 2023 Acme\n",
+			wantFile: content,
+		},
+		{
+			name:       "header inserted",
+			input:      write("create"),
+			insert:     true,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "Added the license header to tool.go",
+			wantFile:   "// SPDX-License-Identifier: MIT\n// Copyright 2023 Acme\n\n" + content,
+		},
+		{
+			name:       "existing file is not checked",
+			input:      write("update"),
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "Validations pass",
+			wantFile:   content,
+		},
+		{
+			name:       "template keyed by language",
+			input:      write("create"),
+			templates:  map[string]string{"go": "// Licensed under MIT"},
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "\n// Licensed under MIT\n",
+			wantFile:   content,
+		},
+		{
+			name:       "no template for the extension",
+			input:      write("create"),
+			templates:  map[string]string{".py": "# SPDX-License-Identifier: MIT"},
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "Validations pass",
+			wantFile:   content,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			files.files["/project/tool.go"] = []byte(content)
+			setupQueueProject(testDeps, func() bool { return false })
+			testDeps.MockInput.readAllFunc = func() ([]byte, error) { return []byte(tt.input), nil }
+
+			templates := tt.templates
+			if templates == nil {
+				templates = map[string]string{".go": "// SPDX-License-Identifier: MIT\n// Copyright {year} Acme"}
+			}
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{
+					License: config.LicenseConfig{Enabled: true, Templates: templates, Insert: tt.insert},
+				},
+				TimeoutSeconds: 10,
+			}
+			exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+			if exitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExit)
+			}
+			if stderr := testDeps.MockStderr.String(); !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr missing %q:\n%s", tt.wantStderr, stderr)
+			}
+			if got := string(files.files["/project/tool.go"]); got != tt.wantFile {
+				t.Errorf("file = %q, want %q", got, tt.wantFile)
+			}
+		})
+	}
+}
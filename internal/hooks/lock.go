@@ -2,36 +2,113 @@ package hooks
 
 import (
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"time"
 )
 
 const lockFileMode = 0600 // Read/write for owner only
 
+// lockFileSuffix is the extension of lock files inside the lock directory.
+const lockFileSuffix = ".lock"
+
+// ErrLockHeld is returned by FileLocker.TryLock when another process holds the lock.
+var ErrLockHeld = errors.New("lock held by another process")
+
+// DeferredResult is the outcome of a queued validation run, kept until the
+// next hook invocation can report it.
+type DeferredResult struct {
+	ExitCode    int    `json:"exit_code"`
+	Message     string `json:"message"`
+	CompletedAt int64  `json:"completed_at"`
+}
+
+// PendingRun is an edit turned away while the lock was held, kept until the
+// lock holder validates it.
+type PendingRun struct {
+	PID      int    `json:"pid"`
+	MarkedAt int64  `json:"marked_at"`
+	File     string `json:"file"`
+	// Files are all edits turned away since the marker was taken, File last.
+	Files    []string `json:"files,omitempty"`
+	SkipLint bool     `json:"skip_lint,omitempty"`
+	SkipTest bool     `json:"skip_test,omitempty"`
+}
+
+// EditedFiles returns the files of every turned-away edit. A marker that
+// does not record them yields an empty path, which no package contains.
+func (p *PendingRun) EditedFiles() []string {
+	if len(p.Files) == 0 {
+		return []string{p.File}
+	}
+	return p.Files
+}
+
+// lockState is the content of a lock file. While a run is in progress it
+// describes the holder; after release it records when the run completed.
+// Whether the lock is actually held is decided by the kernel lock, never by
+// this content, so a crashed holder cannot leave a stale lock behind.
+type lockState struct {
+	PID          int    `json:"pid,omitempty"`
+	Hook         string `json:"hook"`
+	Project      string `json:"project"`
+	StartedAt    int64  `json:"started_at,omitempty"`
+	CompletedAt  int64  `json:"completed_at,omitempty"`
+	CooldownSecs int    `json:"cooldown_secs,omitempty"`
+}
+
 // LockManager handles process locking to prevent concurrent hook execution.
+// Locks are kernel advisory locks (flock) on a per-project file, so they are
+// released automatically when the holding process exits.
 type LockManager struct {
 	lockFile      string
+	workspaceDir  string
+	hookName      string
 	pid           int
 	cooldownSecs  int
 	cleanupOnExit bool
+	held          LockedFile
 	deps          *Dependencies
 }
 
-// NewLockManager creates a new lock manager for the given workspace.
+// DefaultLockDir returns the directory for lock files.
+// It prefers $XDG_RUNTIME_DIR, which is private to the user, and falls back to
+// a per-user directory under the system temp dir.
+func DefaultLockDir() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "cc-tools", "locks")
+	}
+	return filepath.Join(os.TempDir(), "cc-tools-"+strconv.Itoa(os.Getuid()), "locks")
+}
+
+// NewLockManager creates a new lock manager for the given workspace in the default lock directory.
 func NewLockManager(workspaceDir, hookName string, cooldownSecs int, deps *Dependencies) *LockManager {
+	return NewLockManagerInDir("", workspaceDir, hookName, cooldownSecs, deps)
+}
+
+// NewLockManagerInDir creates a new lock manager that keeps its lock file in lockDir.
+// An empty lockDir selects DefaultLockDir.
+func NewLockManagerInDir(
+	lockDir, workspaceDir, hookName string,
+	cooldownSecs int,
+	deps *Dependencies,
+) *LockManager {
 	if deps == nil {
 		deps = NewDefaultDependencies()
 	}
-
-	// Generate a unique lock file name based on workspace and hook
-	hash := sha256.Sum256([]byte(workspaceDir))
-	lockFileName := fmt.Sprintf("claude-hook-%s-%x.lock", hookName, hash[:8])
-	// Use /tmp directly for lock files as they are temporary runtime state
-	lockFile := filepath.Join("/tmp", lockFileName)
+	if lockDir == "" {
+		lockDir = DefaultLockDir()
+	}
 
 	return &LockManager{
-		lockFile:      lockFile,
+		lockFile:      filepath.Join(lockDir, lockFileName(workspaceDir, hookName)),
+		workspaceDir:  workspaceDir,
+		hookName:      hookName,
 		pid:           deps.Process.GetPID(),
 		cooldownSecs:  cooldownSecs,
 		cleanupOnExit: true,
@@ -39,115 +116,267 @@ func NewLockManager(workspaceDir, hookName string, cooldownSecs int, deps *Depen
 	}
 }
 
-// isAnotherProcessRunning checks if another process holds the lock.
-func (l *LockManager) isAnotherProcessRunning(lines []string) bool {
-	if len(lines) < 1 || lines[0] == "" {
-		return false
-	}
+// lockFileName generates a unique lock file name based on workspace and hook.
+func lockFileName(workspaceDir, hookName string) string {
+	hash := sha256.Sum256([]byte(workspaceDir))
+	return fmt.Sprintf("%s-%x%s", hookName, hash[:8], lockFileSuffix)
+}
+
+// TryAcquire attempts to acquire the lock without blocking.
+// Returns true if lock acquired, false if another process has it or cooldown active.
+func (l *LockManager) TryAcquire() (bool, error) {
+	return l.tryAcquire(true)
+}
+
+// TryAcquireQueued attempts to acquire the lock for a queued edit without
+// blocking. Queued edits already waited for the cooldown of the run they were
+// queued behind, so a cooldown is not applied again.
+func (l *LockManager) TryAcquireQueued() (bool, error) {
+	return l.tryAcquire(false)
+}
 
-	pid, err := strconv.Atoi(lines[0])
+// tryAcquire attempts to acquire the lock, turning it down during cooldown if asked to.
+func (l *LockManager) tryAcquire(cooldown bool) (bool, error) {
+	file, err := l.deps.Locker.TryLock(l.lockFile)
 	if err != nil {
-		return false
+		if errors.Is(err, ErrLockHeld) {
+			return false, nil
+		}
+		return false, fmt.Errorf("locking %s: %w", l.lockFile, err)
+	}
+
+	data, readErr := file.ReadAll()
+	if readErr != nil {
+		_ = file.Unlock()
+		return false, fmt.Errorf("reading lock file: %w", readErr)
+	}
+
+	// The kernel lock is ours; only a recent completion can still turn us away
+	if state, ok := parseLockState(data); ok && cooldown && l.inCooldown(state) {
+		_ = file.Unlock()
+		return false, nil
+	}
+
+	state := lockState{
+		PID:       l.pid,
+		Hook:      l.hookName,
+		Project:   l.workspaceDir,
+		StartedAt: l.deps.Clock.Now().Unix(),
+	}
+	if writeErr := writeLockState(file, state); writeErr != nil {
+		_ = file.Unlock()
+		return false, writeErr
 	}
 
-	return l.deps.Process.ProcessExists(pid)
+	l.held = file
+	return true, nil
 }
 
-// isInCooldownPeriod checks if the lock is in cooldown period.
-func (l *LockManager) isInCooldownPeriod(lines []string) bool {
-	if len(lines) < 2 || lines[1] == "" {
-		return false
+// Release releases the lock and starts the cooldown period.
+func (l *LockManager) Release() error {
+	if l.held == nil {
+		return nil
+	}
+	file := l.held
+	l.held = nil
+
+	var writeErr error
+	if l.cleanupOnExit {
+		state := lockState{
+			Hook:         l.hookName,
+			Project:      l.workspaceDir,
+			CompletedAt:  l.deps.Clock.Now().Unix(),
+			CooldownSecs: l.cooldownSecs,
+		}
+		writeErr = writeLockState(file, state)
+	}
+
+	if err := file.Unlock(); err != nil {
+		return fmt.Errorf("unlocking %s: %w", l.lockFile, err)
 	}
+	return writeErr
+}
 
-	completionTime, err := strconv.ParseInt(lines[1], 10, 64)
+// inCooldown checks if a completed run is still within the cooldown period.
+func (l *LockManager) inCooldown(state lockState) bool {
+	return cooldownLeft(state, l.cooldownSecs, l.deps.Clock.Now()) > 0
+}
+
+// cooldownLeft returns how much of the cooldown is left after a completed run.
+func cooldownLeft(state lockState, cooldownSecs int, now time.Time) time.Duration {
+	if state.CompletedAt == 0 {
+		return 0
+	}
+	end := time.Unix(state.CompletedAt, 0).Add(time.Duration(cooldownSecs) * time.Second)
+	return max(end.Sub(now), 0)
+}
+
+// CooldownRemaining returns how much of the cooldown period is left when the
+// lock is idle. It returns zero while another process holds the lock.
+func (l *LockManager) CooldownRemaining() time.Duration {
+	data, err := l.deps.FS.ReadFile(l.lockFile)
 	if err != nil {
-		return false
+		return 0
 	}
 
-	timeSinceCompletion := l.deps.Clock.Now().Unix() - completionTime
-	return timeSinceCompletion < int64(l.cooldownSecs)
+	state, ok := parseLockState(data)
+	if !ok || state.PID != 0 {
+		return 0
+	}
+	return cooldownLeft(state, l.cooldownSecs, l.deps.Clock.Now())
 }
 
-// TryAcquire attempts to acquire the lock atomically.
-// Returns true if lock acquired, false if another process has it or cooldown active.
-func (l *LockManager) TryAcquire() (bool, error) {
-	// First, try to atomically create the lock file
-	// CreateExclusive uses O_EXCL to ensure this fails if the file already exists
-	content := fmt.Sprintf("%d\n", l.pid)
-	err := l.deps.FS.CreateExclusive(l.lockFile, []byte(content), lockFileMode)
+// HolderPID returns the PID recorded by the current lock holder, or zero if
+// the lock is not held. Whether it is held is probed with a non-blocking lock
+// attempt, so a PID left behind in the file, which may have been reused, is
+// never returned.
+func (l *LockManager) HolderPID() int {
+	file, err := l.deps.Locker.TryLock(l.lockFile)
 	if err == nil {
-		// We created the file atomically!
-		return true, nil
+		_ = file.Unlock()
+		return 0
+	}
+	if !errors.Is(err, ErrLockHeld) {
+		return 0
 	}
 
-	// File exists - check if it's a stale lock or in cooldown
-	data, readErr := l.deps.FS.ReadFile(l.lockFile)
-	if readErr != nil {
-		// Couldn't read the existing file - perhaps it was just deleted
-		// Not an error - just couldn't acquire lock
-		return false, nil //nolint:nilerr // Intentionally returning nil - not an error condition
+	data, err := l.deps.FS.ReadFile(l.lockFile)
+	if err != nil {
+		return 0
 	}
+	state, _ := parseLockState(data)
+	return state.PID
+}
 
-	lines := splitLines(string(data))
+// SkipCooldown makes the next Release leave no completion time behind, so
+// the next invocation can start immediately.
+func (l *LockManager) SkipCooldown() {
+	l.cleanupOnExit = false
+}
 
-	// Check if another process is running
-	if l.isAnotherProcessRunning(lines) {
-		return false, nil
+// MarkPending records that an invocation for filePath was turned away while
+// the lock was held. A run already pending keeps every command and every file
+// either edit needs. The marker is replaced by a rename, so it is never read half written.
+func (l *LockManager) MarkPending(filePath string, skipConfig *SkipConfig) error {
+	pending := PendingRun{PID: l.pid, MarkedAt: l.deps.Clock.Now().Unix(), File: filePath}
+	if skipConfig != nil {
+		pending.SkipLint, pending.SkipTest = skipConfig.SkipLint, skipConfig.SkipTest
 	}
-
-	// Check if in cooldown period
-	if l.isInCooldownPeriod(lines) {
-		return false, nil
+	if earlier := l.readPending(l.pendingFile()); earlier != nil {
+		pending.SkipLint = pending.SkipLint && earlier.SkipLint
+		pending.SkipTest = pending.SkipTest && earlier.SkipTest
+		for _, file := range earlier.EditedFiles() {
+			if file != filePath && !slices.Contains(pending.Files, file) {
+				pending.Files = append(pending.Files, file)
+			}
+		}
 	}
+	pending.Files = append(pending.Files, filePath)
 
-	// Lock is stale - try to remove it and acquire atomically
-	if removeErr := l.deps.FS.Remove(l.lockFile); removeErr != nil {
-		// Another process might have just acquired it
-		// Not an error - just couldn't acquire lock
-		return false, nil //nolint:nilerr // Intentionally returning nil - not an error condition
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("marshal pending marker: %w", err)
 	}
+	tmp := l.pendingFile() + "." + strconv.Itoa(l.pid)
+	if writeErr := l.deps.FS.WriteFile(tmp, data, lockFileMode); writeErr != nil {
+		return fmt.Errorf("writing pending marker: %w", writeErr)
+	}
+	if renameErr := l.deps.FS.Rename(tmp, l.pendingFile()); renameErr != nil {
+		_ = l.deps.FS.Remove(tmp)
+		return fmt.Errorf("writing pending marker: %w", renameErr)
+	}
+	return nil
+}
 
-	// Try to create again atomically
-	if createErr := l.deps.FS.CreateExclusive(l.lockFile, []byte(content), lockFileMode); createErr != nil {
-		// Another process beat us to it
-		// Not an error - just couldn't acquire lock
-		return false, nil //nolint:nilerr // Intentionally returning nil - not an error condition
+// TakePending returns and removes the pending marker, if any. The marker is
+// renamed away before it is read, so a marker written meanwhile is kept for
+// the next call instead of being removed unread.
+func (l *LockManager) TakePending() *PendingRun {
+	taken := l.pendingFile() + ".taken." + strconv.Itoa(l.pid)
+	if err := l.deps.FS.Rename(l.pendingFile(), taken); err != nil {
+		return nil
 	}
+	defer func() {
+		_ = l.deps.FS.Remove(taken)
+	}()
+	return l.readPending(taken)
+}
 
-	return true, nil
+// HasPending reports whether an edit is waiting in the pending marker.
+func (l *LockManager) HasPending() bool {
+	return l.readPending(l.pendingFile()) != nil
 }
 
-// Release releases the lock and starts the cooldown period.
-func (l *LockManager) Release() error {
-	if !l.cleanupOnExit {
+// readPending returns the pending marker in the named file without removing it.
+func (l *LockManager) readPending(name string) *PendingRun {
+	data, err := l.deps.FS.ReadFile(name)
+	if err != nil {
 		return nil
 	}
+	var pending PendingRun
+	// A marker that cannot be parsed still asks for a run
+	_ = json.Unmarshal(data, &pending)
+	return &pending
+}
 
-	// Write empty PID and completion timestamp
-	content := fmt.Sprintf("\n%d\n", l.deps.Clock.Now().Unix())
-	if err := l.deps.FS.WriteFile(l.lockFile, []byte(content), lockFileMode); err != nil {
-		return fmt.Errorf("writing lock file: %w", err)
+// StoreResult saves the result of a queued run for the next hook invocation.
+func (l *LockManager) StoreResult(result *DeferredResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal deferred result: %w", err)
+	}
+	if writeErr := l.deps.FS.WriteFile(l.resultFile(), data, lockFileMode); writeErr != nil {
+		return fmt.Errorf("writing deferred result: %w", writeErr)
 	}
 	return nil
 }
 
-// splitLines splits a string into lines, handling both \n and \r\n.
-func splitLines(s string) []string {
-	var lines []string
-	var current []byte
+// TakeResult returns and removes a stored deferred result, if any.
+func (l *LockManager) TakeResult() *DeferredResult {
+	data, err := l.deps.FS.ReadFile(l.resultFile())
+	if err != nil {
+		return nil
+	}
+	_ = l.deps.FS.Remove(l.resultFile())
 
-	for i := range len(s) {
-		if s[i] == '\n' {
-			lines = append(lines, string(current))
-			current = nil
-		} else if s[i] != '\r' {
-			current = append(current, s[i])
-		}
+	var result DeferredResult
+	if unmarshalErr := json.Unmarshal(data, &result); unmarshalErr != nil {
+		return nil
 	}
+	return &result
+}
+
+// pendingFile returns the path of the pending-run marker.
+func (l *LockManager) pendingFile() string {
+	return l.lockFile + ".pending"
+}
 
-	if len(current) > 0 {
-		lines = append(lines, string(current))
+// resultFile returns the path of the deferred result file.
+func (l *LockManager) resultFile() string {
+	return l.lockFile + ".result"
+}
+
+// parseLockState decodes lock file content. Empty or malformed content is
+// treated as no state at all.
+func parseLockState(data []byte) (lockState, bool) {
+	var state lockState
+	if len(data) == 0 {
+		return state, false
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return lockState{}, false
 	}
+	return state, true
+}
 
-	return lines
+// writeLockState replaces the content of a held lock file.
+func writeLockState(file LockedFile, state lockState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal lock state: %w", err)
+	}
+	if writeErr := file.Replace(append(data, '\n')); writeErr != nil {
+		return fmt.Errorf("writing lock file: %w", writeErr)
+	}
+	return nil
 }
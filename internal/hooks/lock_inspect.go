@@ -0,0 +1,124 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LockInfo describes a lock file found in the lock directory.
+type LockInfo struct {
+	Path              string
+	Hook              string
+	Project           string
+	Held              bool
+	PID               int
+	StartedAt         time.Time
+	CooldownRemaining time.Duration
+}
+
+// ListLocks inspects every lock file in lockDir. An empty lockDir selects DefaultLockDir.
+// Whether a lock is held is probed with a non-blocking lock attempt, so the
+// result never depends on PIDs that may have been reused.
+func ListLocks(lockDir string, deps *Dependencies) ([]LockInfo, error) {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	if lockDir == "" {
+		lockDir = DefaultLockDir()
+	}
+
+	entries, err := deps.FS.ReadDir(lockDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing locks: %w", err)
+	}
+
+	var locks []LockInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), lockFileSuffix) {
+			continue
+		}
+		info, inspectErr := inspectLock(filepath.Join(lockDir, entry.Name()), deps)
+		if inspectErr != nil {
+			return nil, inspectErr
+		}
+		locks = append(locks, info)
+	}
+
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Path < locks[j].Path })
+	return locks, nil
+}
+
+// inspectLock reads the state of a single lock file.
+func inspectLock(path string, deps *Dependencies) (LockInfo, error) {
+	info := LockInfo{Path: path}
+
+	file, err := deps.Locker.TryLock(path)
+	var data []byte
+	switch {
+	case errors.Is(err, ErrLockHeld):
+		info.Held = true
+		data, _ = deps.FS.ReadFile(path)
+	case err != nil:
+		return info, fmt.Errorf("inspecting %s: %w", path, err)
+	default:
+		data, err = file.ReadAll()
+		_ = file.Unlock()
+		if err != nil {
+			return info, fmt.Errorf("inspecting %s: %w", path, err)
+		}
+	}
+
+	state, ok := parseLockState(data)
+	if !ok {
+		return info, nil
+	}
+	info.Hook = state.Hook
+	info.Project = state.Project
+
+	if info.Held {
+		info.PID = state.PID
+		if state.StartedAt != 0 {
+			info.StartedAt = time.Unix(state.StartedAt, 0)
+		}
+		return info, nil
+	}
+
+	info.CooldownRemaining = cooldownLeft(state, state.CooldownSecs, deps.Clock.Now())
+	return info, nil
+}
+
+// ClearLock force-removes a lock file together with its queue state.
+// A process still holding the lock keeps running, but the next hook invocation
+// creates a fresh lock file and no longer waits for it.
+func ClearLock(path string, deps *Dependencies) error {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+
+	for _, name := range []string{path, path + ".pending", path + ".result"} {
+		if err := deps.FS.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("clearing lock: %w", err)
+		}
+	}
+	return nil
+}
+
+// LockPathsForProject returns the lock files of every hook that belong to projectDir.
+func LockPathsForProject(locks []LockInfo, projectDir string) []string {
+	suffix := lockFileName(projectDir, "")
+	var paths []string
+	for _, lock := range locks {
+		if lock.Project == projectDir || strings.HasSuffix(filepath.Base(lock.Path), suffix) {
+			paths = append(paths, lock.Path)
+		}
+	}
+	return paths
+}
@@ -1,8 +1,11 @@
 package hooks
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -10,16 +13,12 @@ import (
 func TestLockManagerWithMocks(t *testing.T) {
 	t.Run("successful lock acquisition", func(t *testing.T) {
 		testDeps := createTestDependencies()
-
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			return nil // Successfully created exclusive file
-		}
+		held := &mockLockedFile{}
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) { return held, nil }
 		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
 		testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000000, 0) }
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
+		lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
 
 		acquired, err := lm.TryAcquire()
 		if err != nil {
@@ -28,320 +27,257 @@ func TestLockManagerWithMocks(t *testing.T) {
 		if !acquired {
 			t.Fatal("Expected to acquire lock")
 		}
-	})
-
-	t.Run("lock held by running process", func(t *testing.T) {
-		testDeps := createTestDependencies()
 
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			return fmt.Errorf("file exists") // Can't create exclusive - file exists
+		var state lockState
+		if unmarshalErr := json.Unmarshal(held.content, &state); unmarshalErr != nil {
+			t.Fatalf("lock content is not JSON: %v", unmarshalErr)
 		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			return []byte("12345\n"), nil // Lock file with PID
+		if state.PID != 99999 || state.Project != "/project" || state.StartedAt != 1700000000 {
+			t.Errorf("unexpected lock state: %+v", state)
 		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-		testDeps.MockProcess.processExistsFunc = func(pid int) bool {
-			return pid == 12345 // Process 12345 is running
-		}
-
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
-
-		acquired, err := lm.TryAcquire()
-		if err != nil {
-			t.Fatalf("Unexpected error: %v", err)
-		}
-		if acquired {
-			t.Fatal("Should not acquire lock when another process holds it")
+		if held.unlockCount != 0 {
+			t.Error("lock should stay held after acquisition")
 		}
 	})
 
-	t.Run("lock held by dead process", func(t *testing.T) {
+	t.Run("lock held by another process", func(t *testing.T) {
 		testDeps := createTestDependencies()
-
-		var createExclusiveCallCount int
-
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			createExclusiveCallCount++
-			if createExclusiveCallCount == 1 {
-				return fmt.Errorf("file exists") // First attempt fails
-			}
-			return nil // Second attempt succeeds after removing stale lock
-		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			return []byte("12345\n"), nil // Lock file with PID
-		}
-		testDeps.MockFS.removeFunc = func(_ string) error {
-			return nil // Successfully remove stale lock
-		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-		testDeps.MockProcess.processExistsFunc = func(_ int) bool {
-			return false // Process 12345 is not running
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) {
+			return nil, ErrLockHeld
 		}
-		testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000000, 0) }
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
+		lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
 
 		acquired, err := lm.TryAcquire()
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
-		if !acquired {
-			t.Fatal("Should acquire lock when holding process is dead")
-		}
-		if createExclusiveCallCount != 2 {
-			t.Errorf("Expected 2 createExclusive calls, got %d", createExclusiveCallCount)
+		if acquired {
+			t.Fatal("Should not acquire lock when another process holds it")
 		}
 	})
 
-	t.Run("respects cooldown period", func(t *testing.T) {
+	t.Run("reports locker errors", func(t *testing.T) {
 		testDeps := createTestDependencies()
-
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			return fmt.Errorf("file exists") // Can't create exclusive
-		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			// Lock file with empty PID and recent timestamp
-			return []byte("\n1700000099\n"), nil
-		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-		testDeps.MockClock.nowFunc = func() time.Time {
-			return time.Unix(1700000100, 0) // 1 second after completion
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) {
+			return nil, fmt.Errorf("permission denied")
 		}
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies) // 5 second cooldown
+		lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
 
 		acquired, err := lm.TryAcquire()
-		if err != nil {
-			t.Fatalf("Unexpected error: %v", err)
+		if err == nil || !strings.Contains(err.Error(), "permission denied") {
+			t.Fatalf("Expected permission error, got %v", err)
 		}
 		if acquired {
-			t.Fatal("Should not acquire lock during cooldown period")
+			t.Fatal("Should not acquire lock on locker failure")
 		}
 	})
 
-	t.Run("acquires after cooldown expires", func(t *testing.T) {
-		testDeps := createTestDependencies()
-
-		var createExclusiveCallCount int
-
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			createExclusiveCallCount++
-			if createExclusiveCallCount == 1 {
-				return fmt.Errorf("file exists") // First attempt fails
-			}
-			return nil // Second attempt succeeds after removing expired lock
-		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			// Lock file with empty PID and old timestamp
-			return []byte("\n1700000094\n"), nil
-		}
-		testDeps.MockFS.removeFunc = func(_ string) error {
-			return nil // Successfully remove expired lock
-		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-		testDeps.MockClock.nowFunc = func() time.Time {
-			return time.Unix(1700000100, 0) // 6 seconds after completion
+	t.Run("respects cooldown from completed run", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			completedAt int64
+			want        bool
+		}{
+			{name: "within cooldown", completedAt: 1700000000 - 2, want: false},
+			{name: "cooldown expired", completedAt: 1700000000 - 10, want: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				testDeps := createTestDependencies()
+				content := fmt.Sprintf(`{"hook":"test","project":"/project","completed_at":%d}`, tt.completedAt)
+				held := &mockLockedFile{content: []byte(content)}
+				testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) { return held, nil }
+				testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000000, 0) }
+
+				lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
+
+				acquired, err := lm.TryAcquire()
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if acquired != tt.want {
+					t.Errorf("TryAcquire() = %v, want %v", acquired, tt.want)
+				}
+				if !tt.want && held.unlockCount != 1 {
+					t.Error("lock should be released when turned away by cooldown")
+				}
+			})
 		}
+	})
+
+	t.Run("ignores malformed lock content", func(t *testing.T) {
+		testDeps := createTestDependencies()
+		held := &mockLockedFile{content: []byte("12345\n1700000000\n")}
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) { return held, nil }
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies) // 5 second cooldown
+		lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
 
 		acquired, err := lm.TryAcquire()
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 		if !acquired {
-			t.Fatal("Should acquire lock after cooldown expires")
-		}
-		if createExclusiveCallCount != 2 {
-			t.Errorf("Expected 2 createExclusive calls, got %d", createExclusiveCallCount)
+			t.Fatal("Should acquire lock with malformed content")
 		}
 	})
 
-	t.Run("release writes timestamp", func(t *testing.T) {
+	t.Run("release records completion", func(t *testing.T) {
 		testDeps := createTestDependencies()
+		held := &mockLockedFile{}
+		testDeps.MockLocker.tryLockFunc = func(_ string) (LockedFile, error) { return held, nil }
+		testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000200, 0) }
 
-		var writtenData []byte
-
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.writeFileFunc = func(_ string, data []byte, _ os.FileMode) error {
-			writtenData = data
-			return nil
-		}
-		testDeps.MockClock.nowFunc = func() time.Time {
-			return time.Unix(1700000200, 0)
+		lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
+		if acquired, _ := lm.TryAcquire(); !acquired {
+			t.Fatal("Expected to acquire lock")
 		}
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
-
-		err := lm.Release()
-		if err != nil {
+		if err := lm.Release(); err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 
-		expected := "\n1700000200\n"
-		if string(writtenData) != expected {
-			t.Errorf("Expected written data %q, got %q", expected, string(writtenData))
+		state, ok := parseLockState(held.content)
+		if !ok || state.PID != 0 || state.CompletedAt != 1700000200 || state.CooldownSecs != 5 {
+			t.Errorf("unexpected state after release: %s", held.content)
 		}
-	})
-
-	t.Run("handles write error on acquire", func(t *testing.T) {
-		testDeps := createTestDependencies()
-
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			return fmt.Errorf("permission denied")
-		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			return nil, fmt.Errorf("file not found")
+		if held.unlockCount != 1 {
+			t.Errorf("Expected one unlock, got %d", held.unlockCount)
 		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
 
-		acquired, err := lm.TryAcquire()
-		if err != nil {
-			t.Fatal("Should not return error, just fail to acquire")
-		}
-		if acquired {
-			t.Fatal("Should not acquire lock on write failure")
+		// Releasing twice is a no-op
+		if err := lm.Release(); err != nil || held.unlockCount != 1 {
+			t.Errorf("second Release() = %v, unlocks = %d", err, held.unlockCount)
 		}
 	})
+}
 
-	t.Run("handles malformed lock file", func(t *testing.T) {
-		testDeps := createTestDependencies()
+func TestLockManagerCooldownRemaining(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    time.Duration
+	}{
+		{name: "no lock file", content: "", want: 0},
+		{name: "idle within cooldown", content: `{"completed_at":1699999998}`, want: 3 * time.Second},
+		{name: "idle after cooldown", content: `{"completed_at":1699999000}`, want: 0},
+		{name: "held by a run", content: `{"pid":42,"started_at":1699999998}`, want: 0},
+	}
 
-		var createExclusiveCallCount int
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
+				if tt.content == "" {
+					return nil, errors.New("not found")
+				}
+				return []byte(tt.content), nil
+			}
 
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			createExclusiveCallCount++
-			if createExclusiveCallCount == 1 {
-				return fmt.Errorf("file exists") // First attempt fails
+			lm := NewLockManagerInDir("/locks", "/project", "test", 5, testDeps.Dependencies)
+			if got := lm.CooldownRemaining(); got != tt.want {
+				t.Errorf("CooldownRemaining() = %v, want %v", got, tt.want)
 			}
-			return nil // Second attempt succeeds after removing malformed lock
-		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			return []byte("not-a-number\n"), nil // Malformed PID
-		}
-		testDeps.MockFS.removeFunc = func(_ string) error {
-			return nil // Successfully remove malformed lock
-		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-		testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000000, 0) }
+		})
+	}
+}
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
+func TestDefaultLockDir(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := DefaultLockDir(); got != "/run/user/1000/cc-tools/locks" {
+		t.Errorf("DefaultLockDir() = %q", got)
+	}
 
-		acquired, err := lm.TryAcquire()
-		if err != nil {
-			t.Fatalf("Unexpected error: %v", err)
-		}
-		if !acquired {
-			t.Fatal("Should acquire lock with malformed PID")
-		}
-		if createExclusiveCallCount != 2 {
-			t.Errorf("Expected 2 createExclusive calls, got %d", createExclusiveCallCount)
-		}
-	})
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("TMPDIR", "/scratch")
+	if got := DefaultLockDir(); !strings.HasPrefix(got, "/scratch/cc-tools-") {
+		t.Errorf("DefaultLockDir() = %q, want per-user dir under TMPDIR", got)
+	}
+}
 
-	t.Run("handles malformed timestamp", func(t *testing.T) {
-		testDeps := createTestDependencies()
+func TestFlockLocker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "test.lock")
+	locker := &flockLocker{}
 
-		var createExclusiveCallCount int
+	first, err := locker.TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock() error = %v", err)
+	}
+	if replaceErr := first.Replace([]byte("held")); replaceErr != nil {
+		t.Fatalf("Replace() error = %v", replaceErr)
+	}
 
-		// Setup mocks
-		testDeps.MockFS.tempDirFunc = func() string { return "/tmp" }
-		testDeps.MockFS.createExclusiveFunc = func(_ string, _ []byte, _ os.FileMode) error {
-			createExclusiveCallCount++
-			if createExclusiveCallCount == 1 {
-				return fmt.Errorf("file exists") // First attempt fails
-			}
-			return nil // Second attempt succeeds after removing malformed lock
-		}
-		testDeps.MockFS.readFileFunc = func(_ string) ([]byte, error) {
-			return []byte("\nnot-a-timestamp\n"), nil // Malformed timestamp
-		}
-		testDeps.MockFS.removeFunc = func(_ string) error {
-			return nil // Successfully remove malformed lock
-		}
-		testDeps.MockProcess.getPIDFunc = func() int { return 99999 }
-		testDeps.MockClock.nowFunc = func() time.Time { return time.Unix(1700000000, 0) }
+	if _, secondErr := locker.TryLock(path); !errors.Is(secondErr, ErrLockHeld) {
+		t.Fatalf("second TryLock() error = %v, want ErrLockHeld", secondErr)
+	}
 
-		lm := NewLockManager("/project", "test", 5, testDeps.Dependencies)
+	if unlockErr := first.Unlock(); unlockErr != nil {
+		t.Fatalf("Unlock() error = %v", unlockErr)
+	}
 
-		acquired, err := lm.TryAcquire()
-		if err != nil {
-			t.Fatalf("Unexpected error: %v", err)
-		}
-		if !acquired {
-			t.Fatal("Should acquire lock with malformed timestamp")
-		}
-		if createExclusiveCallCount != 2 {
-			t.Errorf("Expected 2 createExclusive calls, got %d", createExclusiveCallCount)
-		}
-	})
+	again, err := locker.TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock() after unlock error = %v", err)
+	}
+	defer func() { _ = again.Unlock() }()
+
+	data, err := again.ReadAll()
+	if err != nil || string(data) != "held" {
+		t.Errorf("ReadAll() = %q, %v; want content to survive unlock", data, err)
+	}
 }
 
-func TestSplitLines(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected []string
-	}{
-		{
-			name:     "unix line endings",
-			input:    "line1\nline2\nline3",
-			expected: []string{"line1", "line2", "line3"},
-		},
-		{
-			name:     "windows line endings",
-			input:    "line1\r\nline2\r\nline3",
-			expected: []string{"line1", "line2", "line3"},
-		},
-		{
-			name:     "mixed line endings",
-			input:    "line1\nline2\r\nline3",
-			expected: []string{"line1", "line2", "line3"},
-		},
-		{
-			name:     "empty lines",
-			input:    "\n\n",
-			expected: []string{"", ""},
-		},
-		{
-			name:     "no newline at end",
-			input:    "line1\nline2",
-			expected: []string{"line1", "line2"},
-		},
-		{
-			name:     "empty string",
-			input:    "",
-			expected: []string{},
-		},
+func TestListAndClearLocks(t *testing.T) {
+	lockDir := filepath.Join(t.TempDir(), "locks")
+	deps := NewDefaultDependencies()
+
+	idle := NewLockManagerInDir(lockDir, "/project/idle", "validate", 60, deps)
+	if acquired, err := idle.TryAcquire(); !acquired || err != nil {
+		t.Fatalf("TryAcquire() = %v, %v", acquired, err)
+	}
+	if err := idle.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := splitLines(tt.input)
-			if len(result) != len(tt.expected) {
-				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(result))
-				return
-			}
-			for i, line := range result {
-				if line != tt.expected[i] {
-					t.Errorf("Line %d: expected %q, got %q", i, tt.expected[i], line)
-				}
-			}
-		})
+	busy := NewLockManagerInDir(lockDir, "/project/busy", "validate", 0, deps)
+	if acquired, err := busy.TryAcquire(); !acquired || err != nil {
+		t.Fatalf("TryAcquire() = %v, %v", acquired, err)
+	}
+	defer func() { _ = busy.Release() }()
+
+	locks, err := ListLocks(lockDir, deps)
+	if err != nil {
+		t.Fatalf("ListLocks() error = %v", err)
+	}
+	if len(locks) != 2 {
+		t.Fatalf("ListLocks() returned %d locks, want 2", len(locks))
+	}
+
+	byProject := make(map[string]LockInfo)
+	for _, lock := range locks {
+		byProject[lock.Project] = lock
+	}
+	if lock := byProject["/project/busy"]; !lock.Held || lock.PID != deps.Process.GetPID() {
+		t.Errorf("busy lock = %+v, want held by this process", lock)
+	}
+	if lock := byProject["/project/idle"]; lock.Held || lock.CooldownRemaining <= 0 {
+		t.Errorf("idle lock = %+v, want idle with cooldown", lock)
+	}
+
+	paths := LockPathsForProject(locks, "/project/busy")
+	if len(paths) != 1 {
+		t.Fatalf("LockPathsForProject() = %v, want one path", paths)
+	}
+	if clearErr := ClearLock(paths[0], deps); clearErr != nil {
+		t.Fatalf("ClearLock() error = %v", clearErr)
+	}
+
+	// A cleared lock no longer blocks new runs, even while its old holder lives
+	next := NewLockManagerInDir(lockDir, "/project/busy", "validate", 0, deps)
+	if acquired, acquireErr := next.TryAcquire(); !acquired || acquireErr != nil {
+		t.Errorf("TryAcquire() after clear = %v, %v", acquired, acquireErr)
 	}
+	_ = next.Release()
 }
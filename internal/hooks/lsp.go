@@ -0,0 +1,201 @@
+package hooks
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+	"github.com/Veraticus/cc-tools/internal/lsp"
+)
+
+// defaultLanguageServers returns the built-in language server command of each language.
+func defaultLanguageServers() map[string][]string {
+	return map[string][]string{
+		"go":         {"gopls"},
+		"python":     {"pyright-langserver", "--stdio"},
+		"javascript": {"typescript-language-server", "--stdio"},
+		"typescript": {"typescript-language-server", "--stdio"},
+		"rust":       {"rust-analyzer"},
+	}
+}
+
+// languageServer returns the command of the language server for path and
+// the language identifier of the document. The command is nil when no
+// server handles the file.
+func languageServer(path string, custom map[string][]string) ([]string, string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	language := placeholderLanguage(ext)
+	if command, ok := custom[ext]; ok {
+		return command, languageID(ext, language)
+	}
+	if language == "" {
+		return nil, ""
+	}
+	if command, ok := custom[language]; ok {
+		return command, languageID(ext, language)
+	}
+	return defaultLanguageServers()[language], languageID(ext, language)
+}
+
+// languageID returns the LSP language identifier of a file extension.
+func languageID(ext, language string) string {
+	switch {
+	case ext == ".tsx":
+		return "typescriptreact"
+	case ext == ".jsx":
+		return "javascriptreact"
+	case language != "":
+		return language
+	default:
+		return strings.TrimPrefix(ext, ".")
+	}
+}
+
+// lspSocket returns the socket of the daemon running command for
+// projectRoot. Languages sharing a server share its daemon. A sandboxed
+// daemon gets a directory of its own, since it may write to it.
+func lspSocket(lockDir, projectRoot string, command []string, sandboxed bool) string {
+	if lockDir == "" {
+		lockDir = DefaultLockDir()
+	}
+	hash := sha256.Sum256([]byte(projectRoot + "\x00" + strings.Join(command, "\x00")))
+	name := fmt.Sprintf("lsp-%x", hash[:8])
+	if sandboxed {
+		return filepath.Join(lockDir, name, "lsp.sock")
+	}
+	return filepath.Join(lockDir, name+".sock")
+}
+
+// checkLSP reports the diagnostics the project's language server publishes
+// for the edited file. Errors are findings; warnings are listed alongside.
+// Files are skipped when the server is not installed or does not answer
+// within the configured timeout, which the first edit of a session usually
+// hits while the server starts. The server runs project code, so it gets the
+// environment and sandbox of validation commands.
+func checkLSP(
+	ctx context.Context,
+	projectRoot, filePath string,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckLSP)
+	if !cfg.LSP.Enabled || deps.LSP == nil {
+		return finding{severity: severity}
+	}
+	command, id := languageServer(filePath, cfg.LSP.Servers)
+	if len(command) == 0 {
+		return finding{severity: severity}
+	}
+
+	loadDeps := deps
+	if cfg.Sandbox.Enabled {
+		// Loaders such as direnv evaluate project code
+		sandboxed, sandboxErr := withSandbox(deps, projectRoot, cfg.Sandbox, nil)
+		if sandboxErr != nil {
+			if logger != nil && logger.IsEnabled() {
+				logger.LogError(sandboxErr, "applying sandbox for language server")
+			}
+			return finding{severity: severity}
+		}
+		loadDeps = sandboxed
+	}
+	loader := NewEnvLoader(projectRoot, cfg.Env, loadDeps)
+	diff, envErr := loader.Load(ctx)
+	if envErr != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(envErr, "loading environment for language server")
+	}
+	argv := append(loader.wrapper(), command...)
+	if _, err := loader.Runner(deps.Runner, diff).LookPath(argv[0]); err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.Log("Language server %s not installed; skipping diagnostics", argv[0])
+		}
+		return finding{severity: severity}
+	}
+
+	content, err := deps.FS.ReadFile(filePath)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "reading edited file for language server diagnostics")
+		}
+		return finding{severity: severity}
+	}
+	server := lsp.Server{
+		Root:        projectRoot,
+		Command:     argv,
+		Socket:      lspSocket(cfg.LockDir, projectRoot, argv, cfg.Sandbox.Enabled),
+		IdleSeconds: cfg.LSP.GetIdleSeconds(),
+	}
+	if !diff.IsEmpty() {
+		server.Env = diff.Environ(os.Environ())
+	}
+	if cfg.Sandbox.Enabled {
+		server.Writable = sandboxPolicy(projectRoot, cfg.Sandbox).Writable
+	}
+	lspCtx, cancel := context.WithTimeout(ctx, cfg.LSP.GetTimeout())
+	defer cancel()
+	diagnostics, err := deps.LSP.Diagnose(lspCtx, server, lsp.Request{Path: filePath, LanguageID: id,
+		Text: string(content)})
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "collecting language server diagnostics")
+		}
+		return finding{severity: severity}
+	}
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("Language server %s published %d diagnostics", command[0], len(diagnostics))
+	}
+
+	name := filepath.Base(filePath)
+	serverName := filepath.Base(command[0])
+	result := finding{severity: severity}
+	if errs := formatDiagnostics(name, diagnostics, isLSPError); errs != "" {
+		result.message = formatFailure(severity, "%s reports errors in %s:\n%s\nFix them before continuing",
+			serverName, name, errs)
+	}
+	if warnings := formatDiagnostics(name, diagnostics, func(d lsp.Diagnostic) bool {
+		return d.Severity == lsp.SeverityWarning
+	}); warnings != "" {
+		result.info = fmt.Sprintf("💡 %s reports warnings in %s:\n%s", serverName, name, warnings)
+	}
+	return result
+}
+
+// isLSPError reports whether a diagnostic is an error. Diagnostics without a
+// severity count as errors.
+func isLSPError(d lsp.Diagnostic) bool {
+	return d.Severity == lsp.SeverityError || d.Severity == 0
+}
+
+// formatDiagnostics lists the diagnostics that keep selects in position
+// order, one "file:line:column: message [source code]" line each.
+func formatDiagnostics(name string, diagnostics []lsp.Diagnostic, keep func(lsp.Diagnostic) bool) string {
+	selected := slices.DeleteFunc(slices.Clone(diagnostics), func(d lsp.Diagnostic) bool { return !keep(d) })
+	slices.SortStableFunc(selected, func(a, b lsp.Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Range.Start.Line, b.Range.Start.Line),
+			cmp.Compare(a.Range.Start.Character, b.Range.Start.Character))
+	})
+
+	lines := make([]string, 0, min(len(selected), maxListedDiagnostics)+1)
+	for i, d := range selected {
+		if i == maxListedDiagnostics {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(selected)-i))
+			break
+		}
+		message, _, _ := strings.Cut(d.Message, "\n")
+		origin := strings.TrimSpace(d.Source + " " + d.CodeString())
+		if origin != "" {
+			origin = " [" + origin + "]"
+		}
+		lines = append(lines, fmt.Sprintf("  %s:%d:%d: %s%s", name, d.Range.Start.Line+1,
+			d.Range.Start.Character+1, message, origin))
+	}
+	return strings.Join(lines, "\n")
+}
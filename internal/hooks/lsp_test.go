@@ -0,0 +1,199 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/lsp"
+)
+
+func TestLanguageServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		custom  map[string][]string
+		want    []string
+		wantID  string
+		wantNil bool
+	}{
+		{name: "go", path: "/p/main.go", want: []string{"gopls"}, wantID: "go"},
+		{name: "tsx", path: "/p/App.tsx", want: []string{"typescript-language-server", "--stdio"},
+			wantID: "typescriptreact"},
+		{name: "python replaced", path: "/p/app.py", custom: map[string][]string{"python": {"pylsp"}},
+			want: []string{"pylsp"}, wantID: "python"},
+		{name: "other extension", path: "/p/app.rb", custom: map[string][]string{".rb": {"solargraph", "stdio"}},
+			want: []string{"solargraph", "stdio"}, wantID: "rb"},
+		{name: "language turned off", path: "/p/lib.rs", custom: map[string][]string{"rust": {}}, wantNil: true},
+		{name: "unknown extension", path: "/p/notes.txt", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, id := languageServer(tt.path, tt.custom)
+			if tt.wantNil {
+				if len(command) != 0 {
+					t.Errorf("languageServer() = %q, want none", command)
+				}
+				return
+			}
+			if !slices.Equal(command, tt.want) || id != tt.wantID {
+				t.Errorf("languageServer() = %q, %q, want %q, %q", command, id, tt.want, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_LSP(t *testing.T) {
+	diagnostic := func(line, col, severity int, message, code string) lsp.Diagnostic {
+		return lsp.Diagnostic{
+			Range:    lsp.Range{Start: lsp.Position{Line: line, Character: col}},
+			Severity: severity,
+			Code:     json.RawMessage(code),
+			Source:   "compiler",
+			Message:  message,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		diagnostics []lsp.Diagnostic
+		err         error
+		noServer    bool
+		severity    string
+		wantExit    int
+		wantStderr  []string
+		wantStdout  string
+	}{
+		{
+			name: "errors block in position order",
+			diagnostics: []lsp.Diagnostic{
+				diagnostic(9, 1, lsp.SeverityError, "undefined: y", `"UndeclaredName"`),
+				diagnostic(3, 4, lsp.SeverityError, "undefined: x\nmore detail", `"UndeclaredName"`),
+				diagnostic(5, 0, lsp.SeverityWarning, "x declared and not used", ""),
+				diagnostic(6, 0, lsp.SeverityHint, "could be simplified", ""),
+			},
+			wantExit: ExitCodeShowMessage,
+			wantStderr: []string{
+				"BLOCKING: gopls reports errors in main.go:\n" +
+					"  main.go:4:5: undefined: x [compiler UndeclaredName]\n" +
+					"  main.go:10:2: undefined: y [compiler UndeclaredName]\n",
+				"gopls reports warnings in main.go:\n  main.go:6:1: x declared and not used [compiler]",
+			},
+		},
+		{
+			name:        "warn policy does not block",
+			diagnostics: []lsp.Diagnostic{diagnostic(0, 0, lsp.SeverityError, "expected package", "")},
+			severity:    config.SeverityWarn,
+			wantStdout:  "main.go:1:1: expected package",
+		},
+		{
+			name:       "server not ready",
+			err:        lsp.ErrNoDiagnostics,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: []string{"Validations pass"},
+		},
+		{
+			name:       "server not installed",
+			err:        errors.New("daemon must not be asked"),
+			noServer:   true,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: []string{"Validations pass"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			files.files["/project/main.go"] = []byte("package main\n")
+			setupQueueProject(testDeps, func() bool { return false })
+			testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+				if tt.noServer {
+					return "", errors.New("not found")
+				}
+				return "/usr/bin/" + file, nil
+			}
+			testDeps.MockLSP.diagnoseFunc = func(ctx context.Context, server lsp.Server, req lsp.Request) (
+				[]lsp.Diagnostic, error) {
+				if tt.noServer {
+					t.Error("daemon asked for a server that is not installed")
+				}
+				if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 500*time.Millisecond {
+					t.Errorf("request deadline = %v, want the configured timeout", deadline)
+				}
+				if server.Root != "/project" || !slices.Equal(server.Command, []string{"gopls"}) ||
+					!strings.HasPrefix(server.Socket, "/locks/lsp-") || server.IdleSeconds != 600 {
+					t.Errorf("server = %+v", server)
+				}
+				if req.Path != "/project/main.go" || req.LanguageID != "go" || req.Text != "package main\n" {
+					t.Errorf("request = %+v", req)
+				}
+				return tt.diagnostics, tt.err
+			}
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{
+					LockDir:  "/locks",
+					LSP:      config.LSPConfig{Enabled: true, TimeoutMS: 500},
+					Severity: config.SeverityConfig{LSP: tt.severity},
+				},
+				TimeoutSeconds: 10,
+			}
+			exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+			if exitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExit)
+			}
+			stderr := testDeps.MockStderr.String()
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("stderr missing %q:\n%s", want, stderr)
+				}
+			}
+			if tt.wantStdout != "" && !strings.Contains(testDeps.MockStdout.String(), tt.wantStdout) {
+				t.Errorf("stdout missing %q:\n%s", tt.wantStdout, testDeps.MockStdout.String())
+			}
+		})
+	}
+}
+
+func TestCheckLSP_SandboxAndEnvironment(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	files.files["/project/main.go"] = []byte("package main\n")
+	files.files["/project/.env"] = []byte("GOFLAGS=-tags=integration\n")
+	testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+	var got lsp.Server
+	testDeps.MockLSP.diagnoseFunc = func(_ context.Context, server lsp.Server, _ lsp.Request) (
+		[]lsp.Diagnostic, error) {
+		got = server
+		return nil, nil
+	}
+
+	cfg := &config.ValidateConfig{
+		ValidateOptions: config.ValidateOptions{
+			LockDir: "/locks",
+			Env:     config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv}},
+			Sandbox: config.SandboxConfig{Enabled: true},
+			LSP:     config.LSPConfig{Enabled: true},
+		},
+	}
+	checkLSP(context.Background(), "/project", "/project/main.go", cfg, testDeps.Dependencies, nil)
+
+	if !slices.Contains(got.Env, "GOFLAGS=-tags=integration") {
+		t.Errorf("server environment is missing the loaded variables: %q", got.Env)
+	}
+	if !slices.Contains(got.Writable, "/project") || slices.Contains(got.Writable, "/locks") {
+		t.Errorf("server writable paths = %q, want the validation sandbox", got.Writable)
+	}
+	if !strings.HasPrefix(got.Socket, "/locks/lsp-") || !strings.HasSuffix(got.Socket, "/lsp.sock") {
+		t.Errorf("socket = %q, want a directory of its own", got.Socket)
+	}
+}
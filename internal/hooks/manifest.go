@@ -0,0 +1,614 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+var (
+	// ErrNotManifest is returned for files that are not dependency manifests.
+	ErrNotManifest = errors.New("not a dependency manifest")
+	// ErrLockCheckFailed is returned when a lockfile check failed without
+	// showing that the manifest and its lockfile disagree.
+	ErrLockCheckFailed = errors.New("lockfile check could not run")
+)
+
+var (
+	// tomlVersionPattern extracts the version of an inline dependency table.
+	tomlVersionPattern = regexp.MustCompile(`\bversion\s*=\s*["']([^"']*)["']`)
+	// pythonRequirementPattern splits a PEP 508 requirement into its name and specifier.
+	pythonRequirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(.*)$`)
+	// pythonNameSeparators matches the runs of separators PEP 503 normalizes to "-".
+	pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+	// goModDiffPattern matches the diff `go mod tidy -diff` prints for an untidy module.
+	goModDiffPattern = regexp.MustCompile(`(?m)^(diff |--- |\+\+\+ |@@ )`)
+	// goModVerifyPattern matches the failures of `go mod verify` that mean go.sum
+	// and the module cache disagree.
+	goModVerifyPattern = regexp.MustCompile(`has been modified|checksum mismatch`)
+)
+
+// maxManifestOutputLines caps the command output quoted for a failed lockfile check.
+const maxManifestOutputLines = 20
+
+// minNpmLockfileVersion is the first package-lock.json format that records
+// the dependencies of the root package.
+const minNpmLockfileVersion = 2
+
+// cargoDependencyTables are the Cargo.toml tables that declare dependencies.
+func cargoDependencyTables() []string {
+	return []string{"dependencies", "dev-dependencies", "build-dependencies"}
+}
+
+// isManifest reports whether a file name is a dependency manifest.
+func isManifest(name string) bool {
+	switch name {
+	case "go.mod", "package.json", "Cargo.toml", "pyproject.toml":
+		return true
+	default:
+		return strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt")
+	}
+}
+
+// DependencyChange is a dependency a manifest edit added or whose version it changed.
+type DependencyChange struct {
+	Name string
+	// Old is the previous version, empty for added dependencies.
+	Old string
+	New string
+}
+
+// String formats the change as "+ name version" or "~ name old → new".
+func (c DependencyChange) String() string {
+	if c.Old == "" {
+		return strings.TrimSpace("+ " + c.Name + " " + c.New)
+	}
+	return fmt.Sprintf("~ %s %s → %s", c.Name, c.Old, c.New)
+}
+
+// ParseDependencies returns the direct dependencies a manifest declares,
+// mapped to their version or version requirement. Indirect go.mod
+// requirements are left out.
+func ParseDependencies(name string, content []byte) (map[string]string, error) {
+	switch {
+	case name == "go.mod":
+		return parseGoModRequires(string(content)), nil
+	case name == "package.json":
+		return parsePackageJSON(content)
+	case name == "Cargo.toml":
+		return parseCargoDependencies(string(content)), nil
+	case name == "pyproject.toml":
+		return parsePyprojectDependencies(string(content)), nil
+	case isManifest(name):
+		deps := make(map[string]string)
+		for line := range strings.Lines(string(content)) {
+			line, _, _ = strings.Cut(line, " #")
+			line = strings.TrimSpace(line)
+			// Options, includes, editable installs and bare URLs name no package
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") ||
+				(strings.Contains(line, "://") && !strings.Contains(line, " @ ")) {
+				continue
+			}
+			addPythonRequirement(deps, line)
+		}
+		return deps, nil
+	default:
+		return nil, fmt.Errorf("%s: %w", name, ErrNotManifest)
+	}
+}
+
+// parseGoModRequires returns the direct requirements of a go.mod file.
+func parseGoModRequires(content string) map[string]string {
+	// A requirement is a module path and version, as is a "require (" line
+	const requireFields = 2
+	deps := make(map[string]string)
+	inBlock := false
+	for line := range strings.Lines(content) {
+		line, comment, _ := strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inBlock && fields[0] == ")":
+			inBlock = false
+			continue
+		case !inBlock && fields[0] == "require" && len(fields) == requireFields && fields[1] == "(":
+			inBlock = true
+			continue
+		case !inBlock && fields[0] == "require":
+			fields = fields[1:]
+		case !inBlock:
+			continue
+		}
+		if len(fields) != requireFields || strings.Contains(comment, "indirect") {
+			continue
+		}
+		deps[fields[0]] = fields[1]
+	}
+	return deps
+}
+
+// packageManifest holds the dependency groups of a package.json file, which
+// package-lock.json repeats for its root package.
+type packageManifest struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// groups returns the dependency groups keyed by their package.json field.
+func (p packageManifest) groups() map[string]map[string]string {
+	return map[string]map[string]string{
+		"dependencies":         p.Dependencies,
+		"devDependencies":      p.DevDependencies,
+		"optionalDependencies": p.OptionalDependencies,
+		"peerDependencies":     p.PeerDependencies,
+	}
+}
+
+// parsePackageJSON returns the dependencies of all groups of a package.json file.
+func parsePackageJSON(content []byte) (map[string]string, error) {
+	var manifest packageManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parse package.json: %w", err)
+	}
+	deps := make(map[string]string)
+	for _, field := range sortedKeys(manifest.groups()) {
+		for name, version := range manifest.groups()[field] {
+			deps[name] = version
+		}
+	}
+	return deps, nil
+}
+
+// parseCargoDependencies returns the dependencies of a Cargo.toml file,
+// including target-specific and workspace dependency tables.
+func parseCargoDependencies(content string) map[string]string {
+	deps := make(map[string]string)
+	isDependencyTable, tableDependency := false, ""
+	for line := range strings.Lines(content) {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if table, ok := tomlTable(line); ok {
+			isDependencyTable, tableDependency = false, ""
+			for _, kind := range cargoDependencyTables() {
+				if table == kind || strings.HasSuffix(table, "."+kind) {
+					isDependencyTable = true
+				}
+				// [dependencies.serde] declares one dependency as a table
+				if i := strings.LastIndex(table, kind+"."); i >= 0 && (i == 0 || table[i-1] == '.') {
+					tableDependency = strings.Trim(table[i+len(kind)+1:], `"'`)
+					deps[tableDependency] = ""
+				}
+			}
+			continue
+		}
+		key, value, ok := tomlKeyValue(line)
+		switch {
+		case !ok:
+		case tableDependency != "":
+			if key == "version" {
+				deps[tableDependency] = strings.Trim(value, `"'`)
+			}
+		case isDependencyTable:
+			deps[key] = tomlDependencyVersion(value)
+		}
+	}
+	return deps
+}
+
+// parsePyprojectDependencies returns the dependencies of a pyproject.toml
+// file: PEP 621 project dependencies and optional dependencies, PEP 735
+// dependency groups, and Poetry dependency tables.
+func parsePyprojectDependencies(content string) map[string]string {
+	deps := make(map[string]string)
+	table := ""
+	var array strings.Builder
+	inArray := false
+	for line := range strings.Lines(content) {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if inArray {
+			array.WriteString(line)
+			if requirements, closed := tomlStringArray(array.String()); closed {
+				inArray = false
+				for _, requirement := range requirements {
+					addPythonRequirement(deps, requirement)
+				}
+			}
+			continue
+		}
+		if name, ok := tomlTable(line); ok {
+			table = name
+			continue
+		}
+		key, value, ok := tomlKeyValue(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case (table == "project" && key == "dependencies") || table == "project.optional-dependencies" ||
+			table == "dependency-groups":
+			if !strings.HasPrefix(value, "[") {
+				continue
+			}
+			array.Reset()
+			array.WriteString(value)
+			requirements, closed := tomlStringArray(value)
+			if !closed {
+				inArray = true
+				continue
+			}
+			for _, requirement := range requirements {
+				addPythonRequirement(deps, requirement)
+			}
+		case strings.HasPrefix(table, "tool.poetry.") && strings.HasSuffix(table, "dependencies") && key != "python":
+			deps[normalizePythonName(key)] = tomlDependencyVersion(value)
+		}
+	}
+	return deps
+}
+
+// addPythonRequirement adds a PEP 508 requirement such as
+// "requests[socks]>=2.31; python_version>'3.8'" to deps.
+func addPythonRequirement(deps map[string]string, requirement string) {
+	requirement, _, _ = strings.Cut(requirement, ";")
+	match := pythonRequirementPattern.FindStringSubmatch(strings.TrimSpace(requirement))
+	if match == nil {
+		return
+	}
+	deps[normalizePythonName(match[1])] = strings.TrimSpace(match[2])
+}
+
+// normalizePythonName normalizes a Python package name as PEP 503 does.
+func normalizePythonName(name string) string {
+	return pythonNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// stripTOMLComment removes a comment from a TOML line, leaving "#" inside strings alone.
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlTable returns the name of the table a header line such as
+// "[dependencies]" opens. Array-of-tables headers name no table.
+func tomlTable(line string) (string, bool) {
+	if strings.HasPrefix(line, "[[") {
+		return "", true
+	}
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// tomlKeyValue splits a "key = value" line, unquoting the key.
+func tomlKeyValue(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.Trim(strings.TrimSpace(key), `"'`), strings.TrimSpace(value), true
+}
+
+// tomlDependencyVersion returns the version of a dependency declared as a
+// string or an inline table, or the source of an unversioned one.
+func tomlDependencyVersion(value string) string {
+	if !strings.HasPrefix(value, "{") {
+		return strings.Trim(value, `"'`)
+	}
+	if match := tomlVersionPattern.FindStringSubmatch(value); match != nil {
+		return match[1]
+	}
+	for _, source := range []string{"path", "git", "workspace"} {
+		if strings.Contains(value, source) {
+			return source
+		}
+	}
+	return ""
+}
+
+// tomlStringArray returns the strings of a TOML array that starts with "[",
+// skipping those in inline tables, and whether the array is closed.
+func tomlStringArray(value string) ([]string, bool) {
+	var values []string
+	var current strings.Builder
+	var quote rune
+	depth := 0
+	for _, r := range value[1:] {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+			if depth == 0 {
+				values = append(values, current.String())
+			}
+			current.Reset()
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+		case r == ']':
+			return values, true
+		}
+	}
+	return values, false
+}
+
+// diffDependencies returns the dependencies added or changed between two
+// versions of a manifest, ordered by name.
+func diffDependencies(before, after map[string]string) []DependencyChange {
+	var changes []DependencyChange
+	for _, name := range sortedKeys(after) {
+		old, existed := before[name]
+		switch {
+		case !existed:
+			changes = append(changes, DependencyChange{Name: name, New: after[name]})
+		case old != after[name]:
+			changes = append(changes, DependencyChange{Name: name, Old: old, New: after[name]})
+		}
+	}
+	return changes
+}
+
+// npmLockProblems compares the dependencies of package.json with the root
+// package recorded in package-lock.json, which must agree for `npm ci`.
+// Lockfiles too old to record the root package are not compared.
+func npmLockProblems(manifestData, lockData []byte) ([]string, error) {
+	var manifest packageManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("parse package.json: %w", err)
+	}
+	var lock struct {
+		LockfileVersion int                        `json:"lockfileVersion"`
+		Packages        map[string]packageManifest `json:"packages"`
+	}
+	if err := json.Unmarshal(lockData, &lock); err != nil {
+		return nil, fmt.Errorf("parse package-lock.json: %w", err)
+	}
+	root, ok := lock.Packages[""]
+	if lock.LockfileVersion < minNpmLockfileVersion || !ok {
+		return nil, nil
+	}
+
+	var problems []string
+	locked := root.groups()
+	for field, group := range manifest.groups() {
+		for _, name := range sortedKeys(group) {
+			lockedVersion, found := locked[field][name]
+			switch {
+			case !found:
+				problems = append(problems, fmt.Sprintf("%s %s is missing from package-lock.json", field, name))
+			case lockedVersion != group[name]:
+				problems = append(problems, fmt.Sprintf("%s %s is %s in package.json but %s in package-lock.json",
+					field, name, group[name], lockedVersion))
+			}
+		}
+		for _, name := range sortedKeys(locked[field]) {
+			if _, found := group[name]; !found {
+				problems = append(problems, fmt.Sprintf("%s %s is in package-lock.json but not package.json", field, name))
+			}
+		}
+	}
+	slices.Sort(problems)
+	return problems, nil
+}
+
+// ManifestVerifier checks an edited dependency manifest against its
+// lockfile and git HEAD. All checks work offline.
+type ManifestVerifier struct {
+	projectRoot string
+	executor    *CommandExecutor
+	deps        *Dependencies
+}
+
+// NewManifestVerifier creates a manifest verifier for the project.
+func NewManifestVerifier(projectRoot string, timeoutSecs int, deps *Dependencies) *ManifestVerifier {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &ManifestVerifier{
+		projectRoot: projectRoot,
+		executor:    NewCommandExecutor(timeoutSecs, false, deps),
+		deps:        deps,
+	}
+}
+
+// Changes returns the dependencies added or changed since HEAD. A manifest
+// that is new since HEAD adds all of its dependencies.
+func (v *ManifestVerifier) Changes(ctx context.Context, filePath string) ([]DependencyChange, error) {
+	name := filepath.Base(filePath)
+	current, err := v.deps.FS.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filePath, err)
+	}
+	after, err := ParseDependencies(name, current)
+	if err != nil {
+		return nil, err
+	}
+
+	before := map[string]string{}
+	if out, showErr := v.deps.Runner.RunContext(ctx, filepath.Dir(filePath), "git", "show", "HEAD:./"+name); showErr == nil {
+		if parsed, parseErr := ParseDependencies(name, out.Stdout); parseErr == nil {
+			before = parsed
+		}
+	}
+	return diffDependencies(before, after), nil
+}
+
+// Verify returns the ways the manifest disagrees with its lockfile. Checks
+// whose tool or lockfile is missing are skipped. Checks that fail without
+// showing a disagreement, such as a module lookup the offline go command may
+// not make, are reported through an error wrapping ErrLockCheckFailed.
+func (v *ManifestVerifier) Verify(ctx context.Context, filePath string) ([]string, error) {
+	dir := filepath.Dir(filePath)
+	if filepath.Base(filePath) == "package.json" {
+		lock, err := v.deps.FS.ReadFile(filepath.Join(dir, "package-lock.json"))
+		if err != nil {
+			return nil, nil
+		}
+		manifest, err := v.deps.FS.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", filePath, err)
+		}
+		return npmLockProblems(manifest, lock)
+	}
+
+	var problems, failures []string
+	for _, check := range v.lockChecks(filePath) {
+		if _, err := v.deps.Runner.LookPath(check.tool); err != nil {
+			continue
+		}
+		result := v.executor.Execute(ctx, check.command(dir))
+		if result.Success {
+			continue
+		}
+		output := strings.TrimSpace(result.Stdout + "\n" + result.Stderr)
+		if result.TimedOut || (check.drift != nil && !check.drift.MatchString(output)) {
+			reason := result.Error.Error()
+			if first, _, _ := strings.Cut(output, "\n"); first != "" && !result.TimedOut {
+				reason = first
+			}
+			failures = append(failures, fmt.Sprintf("`%s %s`: %s", check.tool, strings.Join(check.args, " "), reason))
+			continue
+		}
+		lines := strings.Split(output, "\n")
+		if len(lines) > maxManifestOutputLines {
+			lines = append(lines[:maxManifestOutputLines], "...")
+		}
+		problems = append(problems, fmt.Sprintf("`%s %s` failed:\n    %s",
+			check.tool, strings.Join(check.args, " "), strings.Join(lines, "\n    ")))
+	}
+	if len(failures) > 0 {
+		return problems, fmt.Errorf("%w: %s", ErrLockCheckFailed, strings.Join(failures, "; "))
+	}
+	return problems, nil
+}
+
+// lockCheck is an offline command that checks a manifest against its lockfile.
+type lockCheck struct {
+	tool string
+	args []string
+	// env holds NAME=value settings the command runs with.
+	env []string
+	// drift matches the output of a failure that shows a disagreement. Other
+	// failures are errors of the tool. Nil treats every failure as a disagreement.
+	drift *regexp.Regexp
+}
+
+// command returns the command running the check in dir.
+func (c lockCheck) command(dir string) *DiscoveredCommand {
+	if len(c.env) == 0 {
+		return &DiscoveredCommand{Command: c.tool, Args: c.args, WorkingDir: dir}
+	}
+	args := append(append(slices.Clone(c.env), c.tool), c.args...)
+	return &DiscoveredCommand{Command: "env", Args: args, WorkingDir: dir}
+}
+
+// lockChecks returns the checks of a manifest against its lockfile.
+func (v *ManifestVerifier) lockChecks(filePath string) []lockCheck {
+	dir := filepath.Dir(filePath)
+	switch filepath.Base(filePath) {
+	case "go.mod":
+		// GOPROXY=off keeps the go command on the local module cache
+		offline := []string{"GOPROXY=off", "GOSUMDB=off"}
+		return []lockCheck{
+			{tool: "go", args: []string{"mod", "tidy", "-diff"}, env: offline, drift: goModDiffPattern},
+			{tool: "go", args: []string{"mod", "verify"}, env: offline, drift: goModVerifyPattern},
+		}
+	case "Cargo.toml":
+		if !v.findUp(dir, "Cargo.lock") {
+			return nil
+		}
+		return []lockCheck{{tool: "cargo", args: []string{"metadata", "--locked", "--offline", "--format-version", "1"}}}
+	case "pyproject.toml":
+		if _, err := v.deps.FS.Stat(filepath.Join(dir, "uv.lock")); err == nil {
+			return []lockCheck{{tool: "uv", args: []string{"lock", "--check", "--offline"}}}
+		}
+		if _, err := v.deps.FS.Stat(filepath.Join(dir, "poetry.lock")); err == nil {
+			return []lockCheck{{tool: "poetry", args: []string{"check", "--lock"}}}
+		}
+	}
+	return nil
+}
+
+// findUp reports whether a file exists in dir or a parent of it within the project.
+func (v *ManifestVerifier) findUp(dir, name string) bool {
+	for {
+		if _, err := v.deps.FS.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if dir == v.projectRoot || parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// checkManifest verifies an edited dependency manifest against its lockfile
+// and lists the dependencies the edit added or changed.
+func checkManifest(
+	ctx context.Context,
+	projectRoot, filePath string,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckManifests)
+	name := filepath.Base(filePath)
+	if !cfg.Manifests.Enabled || !isManifest(name) {
+		return finding{severity: severity}
+	}
+
+	verifier := NewManifestVerifier(projectRoot, cfg.TimeoutSeconds, deps)
+	result := finding{severity: severity}
+	changes, err := verifier.Changes(ctx, filePath)
+	if err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "listing dependency changes")
+	}
+	if len(changes) > 0 {
+		lines := make([]string, 0, len(changes))
+		for _, change := range changes {
+			lines = append(lines, "  "+change.String())
+		}
+		result.info = fmt.Sprintf("📦 Dependency changes in %s since HEAD:\n%s", name, strings.Join(lines, "\n"))
+	}
+
+	problems, err := verifier.Verify(ctx, filePath)
+	if err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "verifying lockfile")
+	}
+	if errors.Is(err, ErrLockCheckFailed) {
+		note := fmt.Sprintf("💡 Could not check %s against its lockfile: %s", name,
+			strings.TrimPrefix(err.Error(), ErrLockCheckFailed.Error()+": "))
+		result.info = strings.TrimPrefix(result.info+"\n"+note, "\n")
+	}
+	if len(problems) > 0 {
+		result.message = formatFailure(severity, "%s and its lockfile disagree:\n  %s\n"+
+			"Update the lockfile with the package manager rather than editing the manifest alone",
+			name, strings.Join(problems, "\n  "))
+	}
+	return result
+}
@@ -0,0 +1,292 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestParseDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "go.mod",
+			file: "go.mod",
+			content: "module example.com/a\n\ngo 1.24\n\nrequire golang.org/x/mod v0.20.0\n\n" +
+				"require (\n\tgithub.com/a/b v1.2.3 // pinned\n\tgithub.com/c/d v0.1.0 // indirect\n)\n\n" +
+				"replace github.com/a/b => ../b\n",
+			want: map[string]string{"golang.org/x/mod": "v0.20.0", "github.com/a/b": "v1.2.3"},
+		},
+		{
+			name:    "package.json",
+			file:    "package.json",
+			content: `{"name":"app","dependencies":{"react":"^18.2.0"},"devDependencies":{"vitest":"~1.0.0"}}`,
+			want:    map[string]string{"react": "^18.2.0", "vitest": "~1.0.0"},
+		},
+		{
+			name:    "invalid package.json",
+			file:    "package.json",
+			content: "{",
+			wantErr: true,
+		},
+		{
+			name: "Cargo.toml",
+			file: "Cargo.toml",
+			content: "[package]\nname = \"app\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = \"1.0\" # serialization\n" +
+				"tokio = { version = \"1.38\", features = [\"full\"] }\nlocal = { path = \"../local\" }\n\n" +
+				"[dependencies.regex]\nversion = \"1.10\"\ndefault-features = false\n\n" +
+				"[target.'cfg(unix)'.dev-dependencies]\nnix = \"0.29\"\n\n[[bin]]\nname = \"app\"\n",
+			want: map[string]string{
+				"serde": "1.0", "tokio": "1.38", "local": "path", "regex": "1.10", "nix": "0.29",
+			},
+		},
+		{
+			name: "pyproject.toml",
+			file: "pyproject.toml",
+			content: "[project]\nname = \"app\"\nversion = \"1.0\"\ndependencies = [\n" +
+				"  \"requests[socks]>=2.31 ; python_version > '3.8'\",\n  \"Django_Rest.Framework\",  # api\n]\n\n" +
+				"[project.optional-dependencies]\ntest = [\"pytest>=8\"]\n\n" +
+				"[dependency-groups]\ndev = [\"ruff==0.5.0\", {include-group = \"test\"}]\n\n" +
+				"[tool.poetry.group.docs.dependencies]\npython = \"^3.10\"\nmkdocs = { version = \"^1.6\" }\n",
+			want: map[string]string{
+				"requests": ">=2.31", "django-rest-framework": "", "pytest": ">=8", "ruff": "==0.5.0", "mkdocs": "^1.6",
+			},
+		},
+		{
+			name: "requirements.txt",
+			file: "requirements-dev.txt",
+			content: "# Pinned\n-r requirements.txt\n-e .\nflask==3.0.3  # web\nnumpy\n" +
+				"git+https://github.com/a/b.git\npkg @ https://example.com/pkg.whl\n",
+			want: map[string]string{"flask": "==3.0.3", "numpy": "", "pkg": "@ https://example.com/pkg.whl"},
+		},
+		{
+			name:    "not a manifest",
+			file:    "main.go",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDependencies(tt.file, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDependencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNpmLockProblems(t *testing.T) {
+	const manifest = `{"dependencies":{"react":"^18.2.0","lodash":"^4.17.21"},"devDependencies":{"vitest":"^1.0.0"}}`
+	tests := []struct {
+		name string
+		lock string
+		want []string
+	}{
+		{
+			name: "in sync",
+			lock: `{"lockfileVersion":3,"packages":{"":{"dependencies":{"react":"^18.2.0","lodash":"^4.17.21"},` +
+				`"devDependencies":{"vitest":"^1.0.0"}}}}`,
+		},
+		{
+			name: "out of sync",
+			lock: `{"lockfileVersion":3,"packages":{"":{"dependencies":{"react":"^18.0.0","left-pad":"^1.3.0"},` +
+				`"devDependencies":{"vitest":"^1.0.0"}}}}`,
+			want: []string{
+				"dependencies left-pad is in package-lock.json but not package.json",
+				"dependencies lodash is missing from package-lock.json",
+				"dependencies react is ^18.2.0 in package.json but ^18.0.0 in package-lock.json",
+			},
+		},
+		{
+			name: "lockfile v1 is not compared",
+			lock: `{"lockfileVersion":1,"dependencies":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := npmLockProblems([]byte(manifest), []byte(tt.lock))
+			if err != nil {
+				t.Fatalf("npmLockProblems() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("npmLockProblems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckManifest(t *testing.T) {
+	const head = "module example.com/a\n\ngo 1.24\n\nrequire github.com/a/b v1.0.0\n"
+	const current = "module example.com/a\n\ngo 1.24\n\nrequire (\n\tgithub.com/a/b v1.1.0\n" +
+		"\tgolang.org/x/mod v0.20.0\n)\n"
+
+	tests := []struct {
+		name        string
+		file        string
+		tidyFails   bool
+		tidyError   string
+		noGo        bool
+		disabled    bool
+		wantMessage []string
+		wantInfo    []string
+	}{
+		{
+			name:     "added and upgraded dependencies",
+			file:     "/project/go.mod",
+			wantInfo: []string{"go.mod since HEAD", "~ github.com/a/b v1.0.0 → v1.1.0", "+ golang.org/x/mod v0.20.0"},
+		},
+		{
+			name:        "untidy module",
+			file:        "/project/go.mod",
+			tidyFails:   true,
+			wantMessage: []string{"BLOCKING: go.mod and its lockfile disagree", "`go mod tidy -diff` failed", "+golang.org/x/mod"},
+			wantInfo:    []string{"+ golang.org/x/mod v0.20.0"},
+		},
+		{
+			name: "offline module lookup fails",
+			file: "/project/go.mod",
+			tidyError: "go: finding module for package github.com/c/d\n" +
+				"go: github.com/c/d@v1.2.0: module lookup disabled by GOPROXY=off\n",
+			wantInfo: []string{"+ golang.org/x/mod v0.20.0",
+				"Could not check go.mod against its lockfile: `go mod tidy -diff`: go: finding module"},
+		},
+		{
+			name:      "toolchain without tidy -diff",
+			file:      "/project/go.mod",
+			tidyError: "flag provided but not defined: -diff\nusage: go mod tidy [-e] [-v] [-x]\n",
+			wantInfo:  []string{"`go mod tidy -diff`: flag provided but not defined: -diff"},
+		},
+		{
+			name:      "go missing",
+			file:      "/project/go.mod",
+			tidyFails: true,
+			noGo:      true,
+			wantInfo:  []string{"+ golang.org/x/mod v0.20.0"},
+		},
+		{
+			name: "not a manifest",
+			file: "/project/main.go",
+		},
+		{
+			name:     "disabled",
+			file:     "/project/go.mod",
+			disabled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+				if name == "/project/go.mod" {
+					return []byte(current), nil
+				}
+				return nil, os.ErrNotExist
+			}
+			testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+				if tt.noGo {
+					return "", errors.New("not found")
+				}
+				return "/usr/bin/" + file, nil
+			}
+			testDeps.MockRunner.runContextFunc = func(_ context.Context, dir, name string, args ...string) (
+				*CommandOutput, error) {
+				command := name + " " + strings.Join(args, " ")
+				switch {
+				case dir != "/project":
+					return nil, errors.New("wrong directory")
+				case command == "git show HEAD:./go.mod":
+					return &CommandOutput{Stdout: []byte(head)}, nil
+				case command == "env GOPROXY=off GOSUMDB=off go mod tidy -diff" && tt.tidyFails:
+					return &CommandOutput{Stdout: []byte("diff current/go.sum tidy/go.sum\n+golang.org/x/mod v0.20.0 h1:x=\n")},
+						errors.New("exit status 1")
+				case command == "env GOPROXY=off GOSUMDB=off go mod tidy -diff" && tt.tidyError != "":
+					return &CommandOutput{Stderr: []byte(tt.tidyError)}, errors.New("exit status 1")
+				case strings.HasPrefix(command, "env GOPROXY=off GOSUMDB=off go mod"):
+					return &CommandOutput{}, nil
+				default:
+					return nil, errors.New("unexpected command: " + command)
+				}
+			}
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{Manifests: config.ManifestConfig{Enabled: !tt.disabled}},
+				TimeoutSeconds:  10,
+			}
+			got := checkManifest(context.Background(), "/project", tt.file, cfg, testDeps.Dependencies, nil)
+			for _, want := range tt.wantMessage {
+				if !strings.Contains(got.message, want) {
+					t.Errorf("message missing %q:\n%s", want, got.message)
+				}
+			}
+			if len(tt.wantMessage) == 0 && got.message != "" {
+				t.Errorf("unexpected message:\n%s", got.message)
+			}
+			for _, want := range tt.wantInfo {
+				if !strings.Contains(got.info, want) {
+					t.Errorf("info missing %q:\n%s", want, got.info)
+				}
+			}
+			if len(tt.wantInfo) == 0 && got.info != "" {
+				t.Errorf("unexpected info:\n%s", got.info)
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_ManifestCheckedWhileBusy(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	files.files["/project/go.mod"] = []byte("module example.com/a\n\ngo 1.24\n\nrequire golang.org/x/mod v0.20.0\n")
+	setupQueueProject(testDeps, func() bool { return false })
+	testDeps.MockInput.readAllFunc = func() ([]byte, error) {
+		return []byte(`{"hook_event_name":"PostToolUse","tool_name":"Edit","tool_input":{"file_path":"/project/go.mod"}}`),
+			nil
+	}
+	testDeps.MockRunner.lookPathFunc = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		switch command := name + " " + strings.Join(args, " "); command {
+		case "env GOPROXY=off GOSUMDB=off go mod tidy -diff":
+			return &CommandOutput{Stdout: []byte("diff current/go.sum tidy/go.sum\n+golang.org/x/mod v0.20.0 h1:x=\n")},
+				errors.New("exit status 1")
+		default:
+			return &CommandOutput{}, errors.New("unexpected command: " + command)
+		}
+	}
+
+	// Another edit's validation holds the lock
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+
+	cfg := &config.ValidateConfig{
+		ValidateOptions: config.ValidateOptions{Manifests: config.ManifestConfig{Enabled: true}},
+		TimeoutSeconds:  10,
+	}
+	exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+	if exitCode != ExitCodeShowMessage {
+		t.Errorf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+	}
+	stderr := testDeps.MockStderr.String()
+	for _, want := range []string{"go.mod and its lockfile disagree", "+ golang.org/x/mod v0.20.0"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"time"
+
+	"github.com/Veraticus/cc-tools/internal/lsp"
 )
 
 // Helper function to create json.RawMessage from a map.
@@ -21,12 +23,15 @@ func mustMarshalJSON(v any) json.RawMessage {
 // Mock implementations for testing
 
 type mockFileSystem struct {
-	statFunc            func(string) (os.FileInfo, error)
-	readFileFunc        func(string) ([]byte, error)
-	writeFileFunc       func(string, []byte, os.FileMode) error
-	tempDirFunc         func() string
-	createExclusiveFunc func(string, []byte, os.FileMode) error
-	removeFunc          func(string) error
+	statFunc      func(string) (os.FileInfo, error)
+	readFileFunc  func(string) ([]byte, error)
+	writeFileFunc func(string, []byte, os.FileMode) error
+	appendFunc    func(string, []byte, os.FileMode) error
+	tempDirFunc   func() string
+	readDirFunc   func(string) ([]os.DirEntry, error)
+	mkdirAllFunc  func(string, os.FileMode) error
+	removeFunc    func(string) error
+	renameFunc    func(string, string) error
 }
 
 func (m *mockFileSystem) Stat(name string) (os.FileInfo, error) {
@@ -50,6 +55,13 @@ func (m *mockFileSystem) WriteFile(name string, data []byte, perm os.FileMode) e
 	return nil
 }
 
+func (m *mockFileSystem) AppendFile(name string, data []byte, perm os.FileMode) error {
+	if m.appendFunc != nil {
+		return m.appendFunc(name, data, perm)
+	}
+	return nil
+}
+
 func (m *mockFileSystem) TempDir() string {
 	if m.tempDirFunc != nil {
 		return m.tempDirFunc()
@@ -57,9 +69,16 @@ func (m *mockFileSystem) TempDir() string {
 	return "/tmp"
 }
 
-func (m *mockFileSystem) CreateExclusive(name string, data []byte, perm os.FileMode) error {
-	if m.createExclusiveFunc != nil {
-		return m.createExclusiveFunc(name, data, perm)
+func (m *mockFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	if m.readDirFunc != nil {
+		return m.readDirFunc(name)
+	}
+	return nil, os.ErrNotExist
+}
+
+func (m *mockFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	if m.mkdirAllFunc != nil {
+		return m.mkdirAllFunc(path, perm)
 	}
 	return nil
 }
@@ -71,9 +90,18 @@ func (m *mockFileSystem) Remove(name string) error {
 	return nil
 }
 
+func (m *mockFileSystem) Rename(oldpath, newpath string) error {
+	if m.renameFunc != nil {
+		return m.renameFunc(oldpath, newpath)
+	}
+	return os.ErrNotExist
+}
+
 type mockCommandRunner struct {
 	runContextFunc func(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error)
 	lookPathFunc   func(file string) (string, error)
+	// env is the environment set by WithEnv
+	env []string
 }
 
 func (m *mockCommandRunner) RunContext(
@@ -94,10 +122,18 @@ func (m *mockCommandRunner) LookPath(file string) (string, error) {
 	return "", errors.New("command not found")
 }
 
+func (m *mockCommandRunner) WithEnv(env []string) CommandRunner {
+	withEnv := *m
+	withEnv.env = env
+	return &withEnv
+}
+
 type mockProcessManager struct {
 	getPIDFunc        func() int
 	findProcessFunc   func(pid int) (*os.Process, error)
 	processExistsFunc func(pid int) bool
+	signalFunc        func(pid int, sig os.Signal) error
+	notifyContextFunc func(ctx context.Context, sig os.Signal) (context.Context, context.CancelFunc)
 }
 
 func (m *mockProcessManager) GetPID() int {
@@ -121,6 +157,58 @@ func (m *mockProcessManager) ProcessExists(pid int) bool {
 	return false
 }
 
+func (m *mockProcessManager) Signal(pid int, sig os.Signal) error {
+	if m.signalFunc != nil {
+		return m.signalFunc(pid, sig)
+	}
+	return errors.New("process not found")
+}
+
+func (m *mockProcessManager) NotifyContext(
+	ctx context.Context,
+	sig os.Signal,
+) (context.Context, context.CancelFunc) {
+	if m.notifyContextFunc != nil {
+		return m.notifyContextFunc(ctx, sig)
+	}
+	return context.WithCancel(ctx)
+}
+
+type mockFileLocker struct {
+	tryLockFunc func(name string) (LockedFile, error)
+}
+
+func (m *mockFileLocker) TryLock(name string) (LockedFile, error) {
+	if m.tryLockFunc != nil {
+		return m.tryLockFunc(name)
+	}
+	return &mockLockedFile{}, nil
+}
+
+type mockLockedFile struct {
+	content     []byte
+	readErr     error
+	replaceErr  error
+	unlockCount int
+}
+
+func (m *mockLockedFile) ReadAll() ([]byte, error) {
+	return m.content, m.readErr
+}
+
+func (m *mockLockedFile) Replace(data []byte) error {
+	if m.replaceErr != nil {
+		return m.replaceErr
+	}
+	m.content = data
+	return nil
+}
+
+func (m *mockLockedFile) Unlock() error {
+	m.unlockCount++
+	return nil
+}
+
 type mockClock struct {
 	nowFunc func() time.Time
 }
@@ -164,6 +252,21 @@ func (m *mockOutputWriter) String() string {
 	return string(m.writtenData)
 }
 
+type mockLanguageServers struct {
+	diagnoseFunc func(ctx context.Context, server lsp.Server, req lsp.Request) ([]lsp.Diagnostic, error)
+}
+
+func (m *mockLanguageServers) Diagnose(
+	ctx context.Context,
+	server lsp.Server,
+	req lsp.Request,
+) ([]lsp.Diagnostic, error) {
+	if m.diagnoseFunc != nil {
+		return m.diagnoseFunc(ctx, server, req)
+	}
+	return nil, lsp.ErrNoDiagnostics
+}
+
 // Helper to create test dependencies with mocks
 // TestDependencies wraps Dependencies with direct access to mock implementations.
 type TestDependencies struct {
@@ -172,38 +275,46 @@ type TestDependencies struct {
 	MockFS      *mockFileSystem
 	MockRunner  *mockCommandRunner
 	MockProcess *mockProcessManager
+	MockLocker  *mockFileLocker
 	MockClock   *mockClock
 	MockInput   *mockInputReader
 	MockStdout  *mockOutputWriter
 	MockStderr  *mockOutputWriter
+	MockLSP     *mockLanguageServers
 }
 
 func createTestDependencies() *TestDependencies {
 	fs := &mockFileSystem{}
 	runner := &mockCommandRunner{}
 	process := &mockProcessManager{}
+	locker := &mockFileLocker{}
 	clock := &mockClock{}
 	input := &mockInputReader{}
 	stdout := &mockOutputWriter{}
 	stderr := &mockOutputWriter{}
+	languageServers := &mockLanguageServers{}
 
 	return &TestDependencies{
 		Dependencies: &Dependencies{
 			FS:      fs,
 			Runner:  runner,
 			Process: process,
+			Locker:  locker,
 			Clock:   clock,
 			Input:   input,
 			Stdout:  stdout,
 			Stderr:  stderr,
+			LSP:     languageServers,
 		},
 		MockFS:      fs,
 		MockRunner:  runner,
 		MockProcess: process,
+		MockLocker:  locker,
 		MockClock:   clock,
 		MockInput:   input,
 		MockStdout:  stdout,
 		MockStderr:  stderr,
+		MockLSP:     languageServers,
 	}
 }
 
@@ -0,0 +1,236 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// placeholderLanguage returns the language whose placeholder patterns apply
+// to files with the given extension, or "" for other files.
+func placeholderLanguage(ext string) string {
+	switch ext {
+	case ".go":
+		return "go"
+	case ".py", ".pyi":
+		return "python"
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts", ".tsx", ".mts", ".cts":
+		return "typescript"
+	case ".rs":
+		return "rust"
+	default:
+		return ""
+	}
+}
+
+// defaultPlaceholderPatterns returns the built-in placeholder patterns of each language.
+func defaultPlaceholderPatterns() map[string][]string {
+	todo := `(?i)\bTODO\b:?\s*implement`
+	jsThrow := `\bthrow\s+new\s+Error\(\s*["'` + "`" + `](?i:not (?:yet )?implemented|unimplemented|todo)`
+	return map[string][]string{
+		"go": {todo, `\bpanic\(\s*"(?i:not (?:yet )?implemented|unimplemented|todo)`},
+		"python": {
+			todo,
+			`^(?:pass|\.\.\.)\s*#\s*(?i:placeholder|stub|todo)`,
+		},
+		"javascript": {
+			todo, jsThrow,
+			`^export\s+(?:default\s+)?(?:async\s+)?function\b[^{]*\{\s*\}\s*;?$`,
+		},
+		"typescript": {
+			todo, jsThrow,
+			`^export\s+(?:default\s+)?(?:async\s+)?function\b[^{]*\{\s*\}\s*;?$`,
+		},
+		"rust": {
+			todo,
+			`\b(?:unimplemented|todo)!\s*\(`,
+			`^pub(?:\([^)]*\))?\s+(?:async\s+)?fn\b[^{]*\{\s*\}$`,
+		},
+	}
+}
+
+// placeholderPatterns compiles the placeholder patterns for a file. Patterns
+// configured for its extension or language replace the built-in ones;
+// invalid patterns are logged and skipped.
+func placeholderPatterns(path string, custom map[string][]string, logger *debuglog.Logger) []*regexp.Regexp {
+	ext := strings.ToLower(filepath.Ext(path))
+	lang := placeholderLanguage(ext)
+	sources, ok := custom[ext]
+	if !ok && lang != "" {
+		sources, ok = custom[lang]
+	}
+	if !ok {
+		sources = defaultPlaceholderPatterns()[lang]
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(sources))
+	for _, source := range sources {
+		pattern, err := regexp.Compile(source)
+		if err != nil {
+			if logger != nil && logger.IsEnabled() {
+				logger.LogError(err, "compiling placeholder pattern")
+			}
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// placeholder is placeholder code found on a line of an edited file.
+type placeholder struct {
+	line int
+	text string
+}
+
+// findPlaceholders returns the added lines of a file that match a
+// placeholder pattern and, for Go, the exported functions with an empty body
+// that contain an added line, ordered by line.
+func findPlaceholders(path, content string, added []addedLine, patterns []*regexp.Regexp) []placeholder {
+	var found []placeholder
+	for _, line := range added {
+		for _, pattern := range patterns {
+			if pattern.MatchString(line.text) {
+				found = append(found, placeholder{line: line.number, text: line.text})
+				break
+			}
+		}
+	}
+	if strings.EqualFold(filepath.Ext(path), ".go") {
+		found = append(found, emptyGoFuncs(path, content, added)...)
+	}
+	slices.SortStableFunc(found, func(a, b placeholder) int { return a.line - b.line })
+	return found
+}
+
+// emptyGoFuncs returns the exported functions of a Go file whose body is
+// empty and which contain an added line. Methods are left alone, since empty
+// methods commonly satisfy an interface, and so are bodies holding a comment.
+func emptyGoFuncs(path, content string, added []addedLine) []placeholder {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var found []placeholder
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !fn.Name.IsExported() || fn.Body == nil || len(fn.Body.List) > 0 {
+			continue
+		}
+		if slices.ContainsFunc(file.Comments, func(c *ast.CommentGroup) bool {
+			return c.Pos() > fn.Body.Lbrace && c.End() < fn.Body.Rbrace
+		}) {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()).Line, fset.Position(fn.End()).Line
+		if slices.ContainsFunc(added, func(line addedLine) bool { return line.number >= start && line.number <= end }) {
+			found = append(found, placeholder{line: start, text: "func " + fn.Name.Name + " has an empty body"})
+		}
+	}
+	return found
+}
+
+// editAddedLines returns the lines of content an edit added. For Edit and
+// MultiEdit they are located from the tool input; other edits, and edits
+// whose inserted text is no longer in the file, are compared with git HEAD,
+// so a file that is new since HEAD counts as entirely added.
+func editAddedLines(
+	ctx context.Context,
+	filePath, content string,
+	input *HookInput,
+	deps *Dependencies,
+) []addedLine {
+	if edits, ok := input.TextEdits(); ok {
+		var added []addedLine
+		seen := make(map[int]bool)
+		located := true
+		for _, edit := range edits {
+			if edit.NewString == "" {
+				// Deletions add nothing
+				continue
+			}
+			i := strings.Index(content, edit.NewString)
+			if i < 0 {
+				located = false
+				break
+			}
+			for _, line := range addedLines(edit.OldString, edit.NewString, strings.Count(content[:i], "\n")+1) {
+				if !seen[line.number] {
+					seen[line.number] = true
+					added = append(added, line)
+				}
+			}
+		}
+		if located {
+			return added
+		}
+	}
+
+	before := ""
+	if out, err := deps.Runner.RunContext(ctx, filepath.Dir(filePath), "git", "show",
+		"HEAD:./"+filepath.Base(filePath)); err == nil {
+		before = string(out.Stdout)
+	}
+	return addedLines(before, content, 1)
+}
+
+// checkPlaceholders flags placeholder code added by the edit, such as
+// panic("not implemented") or an empty exported function.
+func checkPlaceholders(
+	ctx context.Context,
+	filePath string,
+	input *HookInput,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckPlaceholders)
+	if !cfg.Placeholders.Enabled {
+		return finding{severity: severity}
+	}
+	patterns := placeholderPatterns(filePath, cfg.Placeholders.Patterns, logger)
+	if len(patterns) == 0 && !strings.EqualFold(filepath.Ext(filePath), ".go") {
+		return finding{severity: severity}
+	}
+
+	content, err := deps.FS.ReadFile(filePath)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "reading edited file for the placeholder check")
+		}
+		return finding{severity: severity}
+	}
+	added := editAddedLines(ctx, filePath, string(content), input, deps)
+	placeholders := findPlaceholders(filePath, string(content), added, patterns)
+	if len(placeholders) == 0 {
+		return finding{severity: severity}
+	}
+
+	lines := make([]string, 0, min(len(placeholders), maxListedLines)+1)
+	for i, p := range placeholders {
+		if i == maxListedLines {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(placeholders)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %d: %s", p.line, p.text))
+	}
+	return finding{
+		message: formatFailure(severity, "The edit to %s leaves placeholder code:\n%s\n"+
+			"Implement it, or ask the user whether the stub is intended",
+			filepath.Base(filePath), strings.Join(lines, "\n")),
+		severity: severity,
+	}
+}
@@ -0,0 +1,229 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestFindPlaceholders(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		before  string
+		after   string
+		custom  map[string][]string
+		want    []string
+		wantNot bool
+	}{
+		{
+			name:   "go panic and todo",
+			path:   "pkg/a.go",
+			before: "package a\n",
+			after: "package a\n\nfunc parse() error {\n\t// TODO: implement\n" +
+				"\tpanic(\"not implemented\")\n}\n",
+			want: []string{"4: // TODO: implement", `5: panic("not implemented")`},
+		},
+		{
+			name:   "empty exported go function",
+			path:   "a.go",
+			before: "package a\n",
+			after: "package a\n\n// Parse parses.\nfunc Parse(s string) error {\n}\n\n" +
+				"func helper() {}\n\ntype T struct{}\n\nfunc (T) Close() {}\n",
+			want: []string{"4: func Parse has an empty body"},
+		},
+		{
+			name:    "existing empty function and commented body",
+			path:    "a.go",
+			before:  "package a\n\nfunc Old() {}\n",
+			after:   "package a\n\nfunc Old() {}\n\nfunc Noop() {\n\t// Nothing to release\n}\n",
+			wantNot: true,
+		},
+		{
+			name:  "python placeholder pass",
+			path:  "app/api.py",
+			after: "def handler(request):\n    pass  # placeholder\n",
+			want:  []string{"2: pass  # placeholder"},
+		},
+		{
+			name:  "typescript throw and empty export",
+			path:  "src/api.ts",
+			after: "export function load(): void {}\nfunction save() {\n  throw new Error(\"unimplemented\");\n}\n",
+			want:  []string{"1: export function load(): void {}", `3: throw new Error("unimplemented");`},
+		},
+		{
+			name:  "rust macros",
+			path:  "src/lib.rs",
+			after: "pub fn parse() -> u8 {\n    unimplemented!()\n}\nfn later() { todo!() }\n",
+			want:  []string{"2: unimplemented!()", "4: fn later() { todo!() }"},
+		},
+		{
+			name:    "custom patterns replace the defaults",
+			path:    "src/lib.rs",
+			after:   "fn later() { todo!() }\n",
+			custom:  map[string][]string{"rust": {`\bFIXME\b`}},
+			wantNot: true,
+		},
+		{
+			name:   "patterns for another extension",
+			path:   "lib/app.rb",
+			after:  "def run\n  raise NotImplementedError\nend\n",
+			custom: map[string][]string{".rb": {`raise NotImplementedError`}},
+			want:   []string{"2: raise NotImplementedError"},
+		},
+		{
+			name:    "not implemented error value is fine",
+			path:    "a.go",
+			after:   "package a\n\nvar ErrNotImplemented = errors.New(\"not implemented\")\n",
+			wantNot: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns := placeholderPatterns(tt.path, tt.custom, nil)
+			found := findPlaceholders(tt.path, tt.after, addedLines(tt.before, tt.after, 1), patterns)
+			got := make([]string, 0, len(found))
+			for _, p := range found {
+				got = append(got, fmt.Sprintf("%d: %s", p.line, p.text))
+			}
+			if tt.wantNot {
+				if len(got) > 0 {
+					t.Errorf("findPlaceholders() = %q, want none", got)
+				}
+				return
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("findPlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditAddedLines(t *testing.T) {
+	const content = "package a\n\nfunc A() {\n\tpanic(\"todo\")\n}\n\nfunc B() {}\n"
+	tests := []struct {
+		name      string
+		input     *HookInput
+		head      string
+		want      []int
+		wantNoGit bool
+	}{
+		{
+			name: "edit located in the file",
+			input: &HookInput{ToolName: "Edit", ToolInput: []byte(
+				`{"file_path":"/p/a.go","old_string":"func A() {\n}","new_string":"func A() {\n\tpanic(\"todo\")\n}"}`)},
+			want:      []int{4},
+			wantNoGit: true,
+		},
+		{
+			name: "multi edit",
+			input: &HookInput{ToolName: "MultiEdit", ToolInput: []byte(`{"file_path":"/p/a.go","edits":[` +
+				`{"old_string":"x","new_string":"\tpanic(\"todo\")"},{"old_string":"y","new_string":"func B() {}"}]}`)},
+			want:      []int{4, 7},
+			wantNoGit: true,
+		},
+		{
+			name: "stale edit falls back to git",
+			input: &HookInput{ToolName: "Edit", ToolInput: []byte(
+				`{"file_path":"/p/a.go","old_string":"a","new_string":"gone"}`)},
+			head: "package a\n\nfunc A() {\n}\n",
+			want: []int{4, 6, 7},
+		},
+		{
+			name:  "write compares with git",
+			input: &HookInput{ToolName: "Write", ToolInput: []byte(`{"file_path":"/p/a.go"}`)},
+			head:  content,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			gitCalled := false
+			testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, _ ...string) (
+				*CommandOutput, error) {
+				if name != "git" {
+					return nil, errors.New("unexpected command")
+				}
+				gitCalled = true
+				return &CommandOutput{Stdout: []byte(tt.head)}, nil
+			}
+
+			added := editAddedLines(context.Background(), "/p/a.go", content, tt.input, testDeps.Dependencies)
+			got := make([]int, 0, len(added))
+			for _, line := range added {
+				got = append(got, line.number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("added lines = %v, want %v", got, tt.want)
+			}
+			if tt.wantNoGit && gitCalled {
+				t.Error("git was consulted for a located edit")
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_Placeholders(t *testing.T) {
+	const stubEdit = `{"hook_event_name":"PostToolUse","tool_name":"Edit","tool_input":` +
+		`{"file_path":"/project/main.go","old_string":"func run() {","new_string":"func run() {\n\tpanic(\"TODO\")"}}`
+
+	tests := []struct {
+		name       string
+		severity   string
+		disabled   bool
+		wantExit   int
+		wantStderr string
+		wantStdout string
+	}{
+		{
+			name:       "placeholder blocks with line number",
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "main.go leaves placeholder code:\n  4: panic(\"TODO\")",
+		},
+		{
+			name:       "warn policy does not block",
+			severity:   config.SeverityWarn,
+			wantStdout: "ADVISORY",
+		},
+		{
+			name:       "disabled",
+			disabled:   true,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "Validations pass",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			files.files["/project/main.go"] = []byte("package main\n\nfunc run() {\n\tpanic(\"TODO\")\n}\n")
+			setupQueueProject(testDeps, func() bool { return false })
+			testDeps.MockInput.readAllFunc = func() ([]byte, error) { return []byte(stubEdit), nil }
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{
+					Placeholders: config.PlaceholderConfig{Enabled: !tt.disabled},
+					Severity:     config.SeverityConfig{Placeholders: tt.severity},
+				},
+				TimeoutSeconds: 10,
+			}
+			exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+			if exitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExit)
+			}
+			if stderr := testDeps.MockStderr.String(); !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr missing %q:\n%s", tt.wantStderr, stderr)
+			}
+			if tt.wantStdout != "" && !strings.Contains(testDeps.MockStdout.String(), tt.wantStdout) {
+				t.Errorf("stdout missing %q:\n%s", tt.wantStdout, testDeps.MockStdout.String())
+			}
+		})
+	}
+}
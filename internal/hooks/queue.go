@@ -0,0 +1,194 @@
+package hooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+	"github.com/Veraticus/cc-tools/internal/output"
+)
+
+// acquireValidateLock acquires the validate lock according to the busy policy.
+// With the queue and supersede policies an invocation that arrives during
+// cooldown waits for the cooldown to end. One that arrives during a run either
+// leaves a pending marker so the lock holder validates the tree once more, or
+// cancels the holder's run and takes over the lock. With impact selection,
+// every turned-away edit is left in the pending marker, so the next run also
+// tests the packages it affects.
+func acquireValidateLock(
+	ctx context.Context,
+	lockMgr *LockManager,
+	filePath string,
+	skipConfig *SkipConfig,
+	cfg *config.ValidateConfig,
+	debug bool,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) bool {
+	if acquireLock(lockMgr, debug, deps.Stderr, logger) {
+		return true
+	}
+	policy := cfg.GetOnBusy()
+	if policy == config.OnBusyQueue || policy == config.OnBusySupersede {
+		if remaining := lockMgr.CooldownRemaining(); remaining > 0 {
+			if logger != nil && logger.IsEnabled() {
+				logger.Log("Waiting %v for cooldown to end", remaining)
+			}
+			if sleepContext(ctx, remaining) && acquireLock(lockMgr, debug, deps.Stderr, logger) {
+				return true
+			}
+		}
+		if policy == config.OnBusySupersede && ctx.Err() == nil && supersedeHolder(ctx, lockMgr, deps, logger) {
+			return true
+		}
+	}
+
+	if policy != config.OnBusyQueue && !cfg.Impact.Enabled {
+		return false
+	}
+	if err := lockMgr.MarkPending(filePath, skipConfig); err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "marking pending run")
+		}
+		return false
+	}
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("Left the edit in the pending marker for the lock holder")
+	}
+	if policy == config.OnBusyQueue {
+		// The holder may have checked for markers for the last time and
+		// released the lock meanwhile, leaving the edit for this invocation
+		if acquired, err := lockMgr.TryAcquireQueued(); err == nil && acquired {
+			if logger != nil && logger.IsEnabled() {
+				logger.Log("Lock released while queuing; validating here")
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// deliverDeferredResult reports the result of a queued run to an invocation
+// that could not acquire the lock.
+func deliverDeferredResult(
+	lockMgr *LockManager,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) int {
+	if cfg.GetOnBusy() != config.OnBusyQueue {
+		return 0
+	}
+
+	result := lockMgr.TakeResult()
+	if result == nil || result.Message == "" {
+		return 0
+	}
+
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("Delivering deferred result from %s", time.Unix(result.CompletedAt, 0).Format(time.RFC3339))
+	}
+	reportMessage(deps, result.ExitCode, result.Message)
+	return result.ExitCode
+}
+
+// clearQueueState drops queue markers left over from earlier runs and returns
+// the files of the edits turned away since the last run. The run about to
+// start covers the latest tree, but impact selection still needs those files.
+func clearQueueState(lockMgr *LockManager, cfg *config.ValidateConfig) []string {
+	if cfg.GetOnBusy() != config.OnBusyQueue && !cfg.Impact.Enabled {
+		return nil
+	}
+	pending := lockMgr.TakePending()
+	if cfg.GetOnBusy() == config.OnBusyQueue {
+		lockMgr.TakeResult()
+	}
+	if pending == nil {
+		return nil
+	}
+	return pending.EditedFiles()
+}
+
+// maxQueuedRuns bounds how many queued runs one lock holder performs, so a
+// steady stream of edits cannot keep it validating forever.
+const maxQueuedRuns = 5
+
+// rerunPending validates again for as long as other invocations were turned
+// away, up to maxQueuedRuns times, each time for the latest turned-away edit.
+// When no edit is left it releases the lock and checks once more, so an edit
+// marked in between is not stranded. The last result is stored for the next
+// hook invocation.
+func rerunPending(
+	ctx context.Context,
+	lockMgr *LockManager,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+	run func(pending *PendingRun) (int, string),
+) {
+	if cfg.GetOnBusy() != config.OnBusyQueue {
+		return
+	}
+
+	for range maxQueuedRuns {
+		pending := lockMgr.TakePending()
+		if pending == nil {
+			pending = takeLatePending(lockMgr, logger)
+		}
+		if pending == nil || ctx.Err() != nil {
+			return
+		}
+		if logger != nil && logger.IsEnabled() {
+			logger.LogSection("Running queued validation")
+			logger.Log("Queued edit: %s", pending.File)
+		}
+
+		exitCode, message := run(pending)
+		if message == "" {
+			// A stored result of an earlier queued run is now stale
+			lockMgr.TakeResult()
+			continue
+		}
+
+		formatter := output.NewHookFormatter()
+		deferred := &DeferredResult{
+			ExitCode:    exitCode,
+			Message:     formatter.FormatWarning("Result of validation queued after an earlier edit:") + "\n" + message,
+			CompletedAt: deps.Clock.Now().Unix(),
+		}
+		if err := lockMgr.StoreResult(deferred); err != nil && logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "storing deferred result")
+		}
+	}
+}
+
+// takeLatePending releases the lock, then takes it back for an edit marked
+// after the last check, when that edit's invocation could not acquire the lock
+// itself. Otherwise the edit is left to whichever invocation holds the lock.
+func takeLatePending(lockMgr *LockManager, logger *debuglog.Logger) *PendingRun {
+	if err := lockMgr.Release(); err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "releasing lock")
+	}
+	if !lockMgr.HasPending() {
+		return nil
+	}
+	if acquired, err := lockMgr.TryAcquireQueued(); err != nil || !acquired {
+		return nil
+	}
+	return lockMgr.TakePending()
+}
+
+// sleepContext waits for the duration or until the context is done.
+// It returns false if the context ended first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
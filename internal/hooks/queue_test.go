@@ -0,0 +1,415 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// memFiles backs the mock filesystem and locker with in-memory maps.
+type memFiles struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	held  map[string]bool
+}
+
+func newMemFiles(deps *TestDependencies) *memFiles {
+	m := &memFiles{files: make(map[string][]byte), held: make(map[string]bool)}
+	deps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		data, ok := m.files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return data, nil
+	}
+	deps.MockFS.writeFileFunc = func(name string, data []byte, _ os.FileMode) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.files[name] = data
+		return nil
+	}
+	deps.MockLocker.tryLockFunc = func(name string) (LockedFile, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.held[name] {
+			return nil, ErrLockHeld
+		}
+		m.held[name] = true
+		return &memLockedFile{files: m, name: name}, nil
+	}
+	deps.MockFS.removeFunc = func(name string) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.files[name]; !ok {
+			return os.ErrNotExist
+		}
+		delete(m.files, name)
+		return nil
+	}
+	deps.MockFS.renameFunc = func(oldpath, newpath string) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		data, ok := m.files[oldpath]
+		if !ok {
+			return os.ErrNotExist
+		}
+		delete(m.files, oldpath)
+		m.files[newpath] = data
+		return nil
+	}
+	return m
+}
+
+// hold simulates another process holding the named lock.
+func (m *memFiles) hold(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.held[name] = true
+	m.files[name] = []byte(`{"pid":4242,"hook":"validate","project":"/project","started_at":1700000000}`)
+}
+
+func (m *memFiles) withSuffix(suffix string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for name := range m.files {
+		if strings.HasSuffix(name, suffix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// memLockedFile is a lock held in memFiles.
+type memLockedFile struct {
+	files *memFiles
+	name  string
+}
+
+func (f *memLockedFile) ReadAll() ([]byte, error) {
+	f.files.mu.Lock()
+	defer f.files.mu.Unlock()
+	return f.files.files[f.name], nil
+}
+
+func (f *memLockedFile) Replace(data []byte) error {
+	f.files.mu.Lock()
+	defer f.files.mu.Unlock()
+	f.files.files[f.name] = data
+	return nil
+}
+
+func (f *memLockedFile) Unlock() error {
+	f.files.mu.Lock()
+	defer f.files.mu.Unlock()
+	delete(f.files.held, f.name)
+	return nil
+}
+
+func setupQueueProject(deps *TestDependencies, lintFails func() bool) {
+	deps.MockFS.statFunc = func(path string) (os.FileInfo, error) {
+		if strings.HasSuffix(path, "Makefile") {
+			return mockFileInfo{name: filepath.Base(path)}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	deps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		if name == "make" && len(args) >= 3 && args[len(args)-2] == "-n" {
+			return &CommandOutput{}, nil
+		}
+		if name == "make" && len(args) == 1 {
+			if args[0] == "lint" && lintFails() {
+				return &CommandOutput{}, errors.New("exit status 1")
+			}
+			return &CommandOutput{}, nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+	deps.MockInput.readAllFunc = func() ([]byte, error) {
+		return []byte(`{"hook_event_name":"PostToolUse","tool_name":"Edit","tool_input":{"file_path":"/project/main.go"}}`), nil
+	}
+}
+
+func queueConfig() *config.ValidateConfig {
+	return &config.ValidateConfig{
+		ValidateOptions: config.ValidateOptions{OnBusy: config.OnBusyQueue},
+		TimeoutSeconds:  10,
+		CooldownSeconds: 0,
+	}
+}
+
+func TestQueuedValidation_RejectedRunMarksPending(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	// Another process holds the lock
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+
+	exitCode := RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+
+	if exitCode != 0 {
+		t.Errorf("exit code = %d, want 0", exitCode)
+	}
+	if len(files.withSuffix(".pending")) != 1 {
+		t.Error("expected a pending marker for the lock holder")
+	}
+	if testDeps.MockStderr.String() != "" {
+		t.Errorf("expected no output, got %q", testDeps.MockStderr.String())
+	}
+}
+
+func TestQueuedValidation_DropPolicyLeavesNoMarker(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+
+	cfg := queueConfig()
+	cfg.OnBusy = config.OnBusyDrop
+	_ = RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+
+	if len(files.withSuffix(".pending")) != 0 {
+		t.Error("drop policy should not record pending runs")
+	}
+}
+
+func TestQueuedValidation_ImpactKeepsTurnedAwayEdits(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+	_ = lockMgr.MarkPending("/project/pkg/a.go", nil)
+
+	cfg := queueConfig()
+	cfg.OnBusy = config.OnBusyDrop
+	cfg.Impact.Enabled = true
+	_ = RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+
+	// The next run to take the lock also covers both turned-away edits
+	got := clearQueueState(lockMgr, cfg)
+	if want := []string{"/project/pkg/a.go", "/project/main.go"}; !slices.Equal(got, want) {
+		t.Errorf("edited files = %q, want %q", got, want)
+	}
+}
+
+func TestLockManager_TakePendingKeepsLaterMarker(t *testing.T) {
+	testDeps := createTestDependencies()
+	newMemFiles(testDeps)
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	_ = lockMgr.MarkPending("/project/a.go", nil)
+
+	// Another invocation marks its edit while the taken marker is being read
+	baseRead := testDeps.MockFS.readFileFunc
+	testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+		if strings.Contains(name, ".taken.") {
+			_ = lockMgr.MarkPending("/project/b.go", nil)
+		}
+		return baseRead(name)
+	}
+
+	if pending := lockMgr.TakePending(); pending == nil || pending.File != "/project/a.go" {
+		t.Fatalf("TakePending() = %+v, want the marker for a.go", pending)
+	}
+	if pending := lockMgr.TakePending(); pending == nil || pending.File != "/project/b.go" {
+		t.Errorf("TakePending() = %+v, want the later marker for b.go", pending)
+	}
+}
+
+func TestQueuedValidation_WaiterRunsWhenHolderLeavesWhileQueuing(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+	// The holder finishes right after this invocation failed to acquire the lock
+	baseRename := testDeps.MockFS.renameFunc
+	testDeps.MockFS.renameFunc = func(oldpath, newpath string) error {
+		err := baseRename(oldpath, newpath)
+		if strings.HasSuffix(newpath, ".pending") {
+			files.mu.Lock()
+			delete(files.held, lockMgr.lockFile)
+			files.mu.Unlock()
+		}
+		return err
+	}
+
+	exitCode := RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+	if exitCode != ExitCodeShowMessage || !strings.Contains(testDeps.MockStderr.String(), "Validations pass") {
+		t.Errorf("exit code = %d, output %q; want the edit validated here", exitCode, testDeps.MockStderr.String())
+	}
+	if len(files.withSuffix(".pending")) != 0 {
+		t.Error("expected the marker to be consumed")
+	}
+}
+
+func TestQueuedValidation_HolderRunsEditMarkedAfterLastCheck(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	runs := 0
+	var mu sync.Mutex
+	setupQueueProject(testDeps, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return false
+	})
+
+	// An edit is marked just after the holder found no marker, while it still held the lock
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	marked := false
+	baseRename := testDeps.MockFS.renameFunc
+	testDeps.MockFS.renameFunc = func(oldpath, newpath string) error {
+		err := baseRename(oldpath, newpath)
+		mu.Lock()
+		late := err != nil && strings.HasSuffix(oldpath, ".pending") && runs == 1 && !marked
+		marked = marked || late
+		mu.Unlock()
+		if late {
+			_ = lockMgr.MarkPending("/project/late.go", nil)
+		}
+		return err
+	}
+
+	_ = RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+	if runs != 2 {
+		t.Errorf("expected the holder to run lint twice, ran %d times", runs)
+	}
+	if len(files.withSuffix(".pending")) != 0 {
+		t.Error("expected the late marker to be consumed")
+	}
+}
+
+func TestQueuedValidation_HolderRerunsAndNextInvocationGetsResult(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+
+	runs := 0
+	var mu sync.Mutex
+	setupQueueProject(testDeps, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		// The first run passes; the queued run sees a broken tree
+		return runs > 1
+	})
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	// Simulate an invocation turned away while the first run is in progress
+	baseRunner := testDeps.MockRunner.runContextFunc
+	testDeps.MockRunner.runContextFunc = func(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error) {
+		if name == "make" && len(args) == 1 && args[0] == "lint" {
+			mu.Lock()
+			first := runs == 0
+			mu.Unlock()
+			if first {
+				_ = lockMgr.MarkPending("/project/main.go", nil)
+			}
+		}
+		return baseRunner(ctx, dir, name, args...)
+	}
+
+	exitCode := RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+	if exitCode != ExitCodeShowMessage {
+		t.Fatalf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+	}
+	if !strings.Contains(testDeps.MockStderr.String(), "Validations pass") {
+		t.Errorf("holder should report its own run, got %q", testDeps.MockStderr.String())
+	}
+	if runs != 2 {
+		t.Fatalf("expected the holder to run lint twice, ran %d times", runs)
+	}
+	if len(files.withSuffix(".result")) != 1 {
+		t.Fatal("expected the queued run's result to be stored")
+	}
+
+	// The next invocation is turned away and receives the stored result
+	files.hold(lockMgr.lockFile)
+	testDeps.MockStderr.writtenData = nil
+
+	exitCode = RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+	if exitCode != ExitCodeShowMessage {
+		t.Errorf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+	}
+	stderr := testDeps.MockStderr.String()
+	if !strings.Contains(stderr, "queued") || !strings.Contains(stderr, "lint failures") {
+		t.Errorf("expected deferred lint failure, got %q", stderr)
+	}
+	if len(files.withSuffix(".result")) != 0 {
+		t.Error("deferred result should be consumed")
+	}
+}
+
+func TestQueuedValidation_EditDuringRerunIsValidated(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+
+	runs := 0
+	var mu sync.Mutex
+	setupQueueProject(testDeps, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		// Only the last edit of the burst breaks the tree
+		return runs > 2
+	})
+	baseStat := testDeps.MockFS.statFunc
+	var searched []string
+	testDeps.MockFS.statFunc = func(path string) (os.FileInfo, error) {
+		mu.Lock()
+		searched = append(searched, path)
+		mu.Unlock()
+		return baseStat(path)
+	}
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	// One edit arrives during the first run and another during the queued run
+	queued := []string{"/project/pkg/a.go", "/project/cmd/b.go"}
+	baseRunner := testDeps.MockRunner.runContextFunc
+	testDeps.MockRunner.runContextFunc = func(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error) {
+		if name == "make" && len(args) == 1 && args[0] == "lint" {
+			mu.Lock()
+			if runs < len(queued) {
+				_ = lockMgr.MarkPending(queued[runs], nil)
+			}
+			mu.Unlock()
+		}
+		return baseRunner(ctx, dir, name, args...)
+	}
+
+	exitCode := RunValidateHookWithConfig(context.Background(), false, queueConfig(), nil, testDeps.Dependencies)
+	if exitCode != ExitCodeShowMessage {
+		t.Fatalf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+	}
+	if runs != 3 {
+		t.Fatalf("expected the holder to run lint three times, ran %d times", runs)
+	}
+	if len(files.withSuffix(".pending")) != 0 {
+		t.Error("expected every pending marker to be consumed")
+	}
+	for _, dir := range []string{"/project/pkg", "/project/cmd"} {
+		if !slices.Contains(searched, dir+"/Makefile") {
+			t.Errorf("expected a queued run for the edit in %s, searched %q", dir, searched)
+		}
+	}
+
+	result := lockMgr.TakeResult()
+	if result == nil || !strings.Contains(result.Message, "lint failures") {
+		t.Errorf("expected the last queued run's lint failure to be stored, got %+v", result)
+	}
+}
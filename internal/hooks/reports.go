@@ -0,0 +1,251 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+// Report file names within the report directory.
+const (
+	JUnitReportFile = "junit.xml"
+	SARIFReportFile = "lint.sarif"
+)
+
+// Report files are read by editors and CI dashboards, so they are not private.
+const (
+	reportDirMode  = 0755
+	reportFileMode = 0644
+)
+
+// maxReportOutput caps the command output embedded in a report when no
+// individual failures could be parsed.
+const maxReportOutput = 64 << 10
+
+// reportTimePrecision is the number of decimals of durations in JUnit reports.
+const reportTimePrecision = 3
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifSourceRoot is the URI base of result locations, resolved to the project root.
+const sarifSourceRoot = "%SRCROOT%"
+
+// sarifLog is a SARIF 2.1.0 log.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is a single analysis run in a SARIF log.
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	Invocations        []sarifInvocation                `json:"invocations"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+// sarifTool names the tool that produced a run.
+type sarifTool struct {
+	Driver struct {
+		Name string `json:"name"`
+	} `json:"driver"`
+}
+
+// sarifInvocation describes how the tool was run.
+type sarifInvocation struct {
+	CommandLine         string                `json:"commandLine"`
+	WorkingDirectory    sarifArtifactLocation `json:"workingDirectory"`
+	ExecutionSuccessful bool                  `json:"executionSuccessful"`
+	ExitCode            int                   `json:"exitCode"`
+}
+
+// sarifResult is a single finding.
+type sarifResult struct {
+	Level         string          `json:"level"`
+	Message       sarifMessage    `json:"message"`
+	Locations     []sarifLocation `json:"locations"`
+	BaselineState string          `json:"baselineState,omitempty"`
+}
+
+// sarifMessage is the text of a finding.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation is where a finding is.
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+// sarifArtifactLocation is a file or directory, optionally relative to a URI base.
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// sarifRegion is a position within a file.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeReports writes the JUnit and SARIF reports of a validation run to the
+// configured directory. Reports of checks that did not run are left alone.
+func writeReports(
+	ctx context.Context,
+	projectRoot string,
+	cfg config.ReportsConfig,
+	result *ValidateResult,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) {
+	// A cancelled run was superseded and says nothing about the current tree
+	if !cfg.Enabled || ctx.Err() != nil {
+		return
+	}
+
+	dir := cfg.GetDir(projectRoot)
+	reports := make(map[string][]byte)
+	if result.TestResult != nil {
+		data, err := xml.MarshalIndent(junitReport(result.TestResult, deps.Clock.Now()), "", "  ")
+		if err == nil {
+			reports[JUnitReportFile] = append([]byte(xml.Header), append(data, '\n')...)
+		}
+	}
+	if result.LintResult != nil {
+		level := sarifLevel(result.Severity.Get(config.CheckLint))
+		data, err := json.MarshalIndent(sarifReport(result.LintResult, projectRoot, level), "", "  ")
+		if err == nil {
+			reports[SARIFReportFile] = append(data, '\n')
+		}
+	}
+	if len(reports) == 0 {
+		return
+	}
+
+	err := deps.FS.MkdirAll(dir, reportDirMode)
+	for name, data := range reports {
+		if err != nil {
+			break
+		}
+		if writeErr := deps.FS.WriteFile(filepath.Join(dir, name), data, reportFileMode); writeErr != nil {
+			err = fmt.Errorf("write %s: %w", name, writeErr)
+		}
+	}
+	if err != nil && logger != nil && logger.IsEnabled() {
+		logger.LogError(err, "writing validation reports")
+	}
+}
+
+// junitReport builds a JUnit report of a test run. Parsed failures become
+// test cases of their own; otherwise the run is a single test case.
+func junitReport(result *ValidationResult, now time.Time) *junitTestSuites {
+	suite := junitTestSuite{
+		Name:      result.Command.String(),
+		Time:      strconv.FormatFloat(result.Duration.Seconds(), 'f', reportTimePrecision, 64),
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+
+	switch {
+	case result.Success:
+		suite.Cases = []junitTestCase{{Name: result.Command.String()}}
+	case len(result.TestFailures) > 0:
+		for _, failure := range result.TestFailures {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:    failure.Name,
+				File:    failure.File,
+				Line:    failure.Line,
+				Failure: &junitFailure{Message: failure.Message},
+			})
+		}
+	default:
+		output := result.Output
+		if len(output) > maxReportOutput {
+			output = output[len(output)-maxReportOutput:]
+		}
+		suite.Cases = []junitTestCase{{
+			Name:    result.Command.String(),
+			Failure: &junitFailure{Message: fmt.Sprintf("exit code %d", result.ExitCode), Text: output},
+		}}
+	}
+
+	for _, tc := range suite.Cases {
+		suite.Tests++
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+	return &junitTestSuites{Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+}
+
+// sarifReport builds a SARIF log of the findings of a lint run. With a
+// baseline, findings in it are marked unchanged and the others new. The
+// execution counts as successful when the linter ran to completion, whether
+// or not it reported findings.
+func sarifReport(result *ValidationResult, projectRoot, level string) *sarifLog {
+	run := sarifRun{
+		Invocations: []sarifInvocation{{
+			CommandLine:         result.Command.String(),
+			WorkingDirectory:    sarifArtifactLocation{URI: fileURI(result.Command.WorkingDir)},
+			ExecutionSuccessful: result.ExitCode >= 0 && result.FailureReason == "",
+			ExitCode:            result.ExitCode,
+		}},
+		OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+			sarifSourceRoot: {URI: fileURI(projectRoot) + "/"},
+		},
+		Results: []sarifResult{},
+	}
+	run.Tool.Driver.Name = result.Command.String()
+
+	fresh := make(map[Diagnostic]bool, len(result.NewDiagnostics))
+	for _, d := range result.NewDiagnostics {
+		fresh[d] = true
+	}
+	for _, d := range ParseDiagnostics(lintOutput(result.Output, result.Command), result.Command.WorkingDir, projectRoot) {
+		finding := sarifResult{Level: level, Message: sarifMessage{Text: d.Message}}
+		if result.Baselined > 0 {
+			finding.BaselineState = "unchanged"
+			if fresh[d] {
+				finding.BaselineState = "new"
+			}
+		}
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation = sarifArtifactLocation{URI: d.File, URIBaseID: sarifSourceRoot}
+		if d.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+		finding.Locations = []sarifLocation{location}
+		run.Results = append(run.Results, finding)
+	}
+
+	return &sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}
+}
+
+// sarifLevel maps a check severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case config.SeverityWarn:
+		return "warning"
+	case config.SeveritySilent:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// fileURI returns the file URI of an absolute path.
+func fileURI(path string) string {
+	return "file://" + filepath.ToSlash(path)
+}
@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestWriteReports(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	testDeps.MockClock.nowFunc = func() time.Time { return time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	lintFinding := Diagnostic{File: "pkg/a.go", Line: 3, Column: 7, Message: "unused variable x"}
+	result := &ValidateResult{
+		LintResult: &ValidationResult{
+			Success:  false,
+			ExitCode: 1,
+			Command:  &DiscoveredCommand{Command: "make", Args: []string{"lint"}, WorkingDir: "/project"},
+			Output: "pkg/a.go:3:7: unused variable x\n" +
+				"pkg/b.go:10: line too long\n",
+			Baselined:      1,
+			NewDiagnostics: []Diagnostic{lintFinding},
+		},
+		TestResult: &ValidationResult{
+			Success:  false,
+			ExitCode: 1,
+			Command:  &DiscoveredCommand{Command: "make", Args: []string{"test"}, WorkingDir: "/project"},
+			Duration: 1500 * time.Millisecond,
+			TestFailures: []TestFailure{
+				{Name: "TestAdd", File: "math_test.go", Line: 42, Message: "expected 2 got 3"},
+				{Name: "TestSub"},
+			},
+		},
+		Severity: config.SeverityConfig{Lint: config.SeverityWarn},
+	}
+
+	cfg := config.ReportsConfig{Enabled: true, Dir: "out/reports"}
+	writeReports(context.Background(), "/project", cfg, result, testDeps.Dependencies, nil)
+
+	var junit junitTestSuites
+	if err := xml.Unmarshal(files.files["/project/out/reports/junit.xml"], &junit); err != nil {
+		t.Fatalf("junit.xml is not valid XML: %v", err)
+	}
+	if junit.Tests != 2 || junit.Failures != 2 || len(junit.Suites) != 1 {
+		t.Fatalf("junit = %+v, want one suite with two failures", junit)
+	}
+	suite := junit.Suites[0]
+	if suite.Name != "make test" || suite.Time != "1.500" || suite.Timestamp != "2025-03-01T12:00:00Z" {
+		t.Errorf("suite = %+v", suite)
+	}
+	if tc := suite.Cases[0]; tc.Name != "TestAdd" || tc.File != "math_test.go" || tc.Line != 42 ||
+		tc.Failure == nil || tc.Failure.Message != "expected 2 got 3" {
+		t.Errorf("first test case = %+v", tc)
+	}
+
+	var sarif sarifLog
+	if err := json.Unmarshal(files.files["/project/out/reports/lint.sarif"], &sarif); err != nil {
+		t.Fatalf("lint.sarif is not valid JSON: %v", err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 2 {
+		t.Fatalf("sarif = %+v, want one run with two results", sarif)
+	}
+	run := sarif.Runs[0]
+	if run.Tool.Driver.Name != "make lint" || !run.Invocations[0].ExecutionSuccessful {
+		t.Errorf("run = %+v", run)
+	}
+	first := run.Results[0]
+	location := first.Locations[0].PhysicalLocation
+	if first.Level != "warning" || first.BaselineState != "new" || location.ArtifactLocation.URI != "pkg/a.go" ||
+		location.Region == nil || location.Region.StartLine != 3 || location.Region.StartColumn != 7 {
+		t.Errorf("first result = %+v", first)
+	}
+	if run.Results[1].BaselineState != "unchanged" {
+		t.Errorf("baselined finding state = %q, want unchanged", run.Results[1].BaselineState)
+	}
+}
+
+func TestWriteReports_PassingRun(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+
+	result := &ValidateResult{
+		BothPassed: true,
+		LintResult: &ValidationResult{Success: true, Command: &DiscoveredCommand{Command: "make", Args: []string{"lint"}}},
+	}
+	writeReports(context.Background(), "/project", config.ReportsConfig{Enabled: true}, result,
+		testDeps.Dependencies, nil)
+
+	sarif := string(files.files["/project/.cc-tools/reports/lint.sarif"])
+	if !strings.Contains(sarif, `"results": []`) {
+		t.Errorf("passing lint should have an empty result list:\n%s", sarif)
+	}
+	if _, ok := files.files["/project/.cc-tools/reports/junit.xml"]; ok {
+		t.Error("no JUnit report should be written without a test run")
+	}
+}
+
+func TestRunValidateHook_WritesReports(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "enabled", enabled: true},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			setupQueueProject(testDeps, func() bool { return true })
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{Reports: config.ReportsConfig{Enabled: tt.enabled}},
+				TimeoutSeconds:  10,
+			}
+			RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+
+			_, wrote := files.files["/project/.cc-tools/reports/junit.xml"]
+			if wrote != tt.enabled {
+				t.Errorf("junit.xml written = %v, want %v", wrote, tt.enabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package hooks
+
+import (
+	"os"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/shared"
+)
+
+// ProjectRootOptions builds project root detection options from the configuration.
+// The project directory comes from CLAUDE_PROJECT_DIR, falling back to the
+// cwd reported in the hook input.
+func ProjectRootOptions(cfg config.RootConfig, cwd string) shared.RootOptions {
+	opts := shared.RootOptions{Strategies: cfg.Strategies, Markers: cfg.Markers}
+	if dir := os.Getenv("CLAUDE_PROJECT_DIR"); dir != "" {
+		opts.ProjectDir, opts.ProjectDirSource = dir, "CLAUDE_PROJECT_DIR"
+	} else if cwd != "" {
+		opts.ProjectDir, opts.ProjectDirSource = cwd, "hook cwd"
+	}
+	return opts
+}
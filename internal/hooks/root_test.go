@@ -0,0 +1,26 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestProjectRootOptions(t *testing.T) {
+	cfg := config.RootConfig{Strategies: []string{"project_dir"}, Markers: []string{"WORKSPACE"}}
+
+	t.Setenv("CLAUDE_PROJECT_DIR", "")
+	opts := ProjectRootOptions(cfg, "/from/hook")
+	if opts.ProjectDir != "/from/hook" || opts.ProjectDirSource != "hook cwd" {
+		t.Errorf("expected hook cwd, got %q from %q", opts.ProjectDir, opts.ProjectDirSource)
+	}
+	if len(opts.Strategies) != 1 || len(opts.Markers) != 1 {
+		t.Errorf("expected configured strategies and markers, got %+v", opts)
+	}
+
+	t.Setenv("CLAUDE_PROJECT_DIR", "/from/env")
+	opts = ProjectRootOptions(cfg, "/from/hook")
+	if opts.ProjectDir != "/from/env" || opts.ProjectDirSource != "CLAUDE_PROJECT_DIR" {
+		t.Errorf("expected CLAUDE_PROJECT_DIR to win, got %q from %q", opts.ProjectDir, opts.ProjectDirSource)
+	}
+}
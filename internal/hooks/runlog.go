@@ -0,0 +1,134 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+// runLogTimeFormat names run logs so they sort chronologically.
+const runLogTimeFormat = "20060102-150405.000"
+
+// RunLog writes the full output of each validation command to a file, so a
+// failure can be inspected without running the command again.
+type RunLog struct {
+	dir     string
+	maxRuns int
+	maxAge  time.Duration
+	env     []string
+	deps    *Dependencies
+}
+
+// NewRunLog creates a run log for the project. env describes the environment
+// the commands run in and is written to every log.
+func NewRunLog(projectRoot string, cfg config.RunLogConfig, env []string, deps *Dependencies) *RunLog {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	return &RunLog{
+		dir:     filepath.Join(cfg.GetDir(), runLogProjectDir(projectRoot)),
+		maxRuns: cfg.GetMaxRuns(),
+		maxAge:  cfg.GetMaxAge(),
+		env:     env,
+		deps:    deps,
+	}
+}
+
+// runLogProjectDir names a project's log directory after the project, with a
+// short hash of its path to keep projects with the same name apart.
+func runLogProjectDir(projectRoot string) string {
+	hash := sha256.Sum256([]byte(projectRoot))
+	return fmt.Sprintf("%s-%x", filepath.Base(projectRoot), hash[:4])
+}
+
+// runLogEntry is what a run log records about a command.
+type runLogEntry struct {
+	cmd      *DiscoveredCommand
+	cmdType  CommandType
+	started  time.Time
+	duration time.Duration
+	result   *ValidationResult
+	stdout   string
+	stderr   string
+}
+
+// Write records a command run, removes logs beyond the retention limits and
+// returns the path of the new log.
+func (l *RunLog) Write(entry runLogEntry) (string, error) {
+	if err := l.deps.FS.MkdirAll(l.dir, cacheDirMode); err != nil {
+		return "", fmt.Errorf("create run log dir: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.log", entry.started.Format(runLogTimeFormat), entry.cmdType)
+	path := filepath.Join(l.dir, name)
+	if err := l.deps.FS.WriteFile(path, []byte(l.format(entry)), lockFileMode); err != nil {
+		return "", fmt.Errorf("write run log: %w", err)
+	}
+
+	if err := pruneDir(l.deps, l.dir, ".log", l.maxAge, l.maxRuns); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// format renders the log contents.
+func (l *RunLog) format(entry runLogEntry) string {
+	var b strings.Builder
+	result := entry.result
+
+	fmt.Fprintf(&b, "Command:   %s\n", entry.cmd.String())
+	fmt.Fprintf(&b, "Directory: %s\n", entry.cmd.WorkingDir)
+	fmt.Fprintf(&b, "Type:      %s\n", entry.cmdType)
+	fmt.Fprintf(&b, "Started:   %s\n", entry.started.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "Duration:  %s\n", entry.duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "Exit code: %d\n", result.ExitCode)
+	fmt.Fprintf(&b, "Result:    %s\n", runLogOutcome(result))
+	if result.Error != nil && !result.Success {
+		fmt.Fprintf(&b, "Error:     %v\n", result.Error)
+	}
+
+	b.WriteString("\nEnvironment:\n")
+	if len(l.env) == 0 {
+		b.WriteString("  (inherited from the hook, no loaders)\n")
+	}
+	for _, line := range l.env {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+
+	if result.Cached {
+		// Cached entries keep stdout and stderr combined
+		fmt.Fprintf(&b, "\n=== output (cached) ===\n%s", result.Output)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n=== stdout ===\n%s", entry.stdout)
+	fmt.Fprintf(&b, "\n=== stderr ===\n%s", entry.stderr)
+	return b.String()
+}
+
+// runLogOutcome summarizes a result for the log header.
+func runLogOutcome(result *ValidationResult) string {
+	outcome := "passed"
+	if !result.Success {
+		outcome = "failed"
+	}
+	if result.Cached {
+		outcome += " (cached result from an identical tree, not re-run)"
+	}
+	return outcome
+}
+
+// runLogEnv describes the environment loaders and the changes they made.
+func runLogEnv(cfg config.EnvConfig, diff *EnvDiff) []string {
+	if len(cfg.Loaders) == 0 {
+		return nil
+	}
+	lines := []string{"loaders: " + strings.Join(cfg.Loaders, ", ")}
+	if cfg.HasLoader(config.EnvLoaderNix) {
+		lines = append(lines, "commands run inside nix develop")
+	}
+	return append(lines, diff.Lines()...)
+}
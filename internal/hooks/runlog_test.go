@@ -0,0 +1,161 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestRunLogProjectDir(t *testing.T) {
+	first := runLogProjectDir("/home/a/project")
+	second := runLogProjectDir("/home/b/project")
+
+	if !strings.HasPrefix(first, "project-") || !strings.HasPrefix(second, "project-") {
+		t.Errorf("expected project name prefix, got %q and %q", first, second)
+	}
+	if first == second {
+		t.Error("projects with the same name in different places share a log directory")
+	}
+}
+
+func TestRunLog_Write(t *testing.T) {
+	logDir := t.TempDir()
+	deps := NewDefaultDependencies()
+	env := runLogEnv(config.EnvConfig{Loaders: []string{config.EnvLoaderDotenv}},
+		&EnvDiff{Set: map[string]string{"DATABASE_URL": "postgres://localhost"}})
+	runLog := NewRunLog("/repo", config.RunLogConfig{Dir: logDir}, env, deps)
+
+	started := time.Date(2026, 3, 4, 5, 6, 7, 890_000_000, time.UTC)
+	cmd := &DiscoveredCommand{Command: "make", Args: []string{"test"}, WorkingDir: "/repo"}
+	path, err := runLog.Write(runLogEntry{
+		cmd:      cmd,
+		cmdType:  CommandTypeTest,
+		started:  started,
+		duration: 1500 * time.Millisecond,
+		result:   &ValidationResult{Success: false, ExitCode: 2, Error: errors.New("exit status 2")},
+		stdout:   "--- FAIL: TestThing\n",
+		stderr:   "make: *** [test] Error 2\n",
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	wantPath := filepath.Join(logDir, runLogProjectDir("/repo"), "20260304-050607.890-test.log")
+	if path != wantPath {
+		t.Errorf("path = %q, want %q", path, wantPath)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Command:   make test",
+		"Directory: /repo",
+		"Duration:  1.5s",
+		"Exit code: 2",
+		"Result:    failed",
+		"loaders: dotenv",
+		"+DATABASE_URL\n",
+		"=== stdout ===\n--- FAIL: TestThing",
+		"=== stderr ===\nmake: *** [test] Error 2",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "postgres://") {
+		t.Errorf("log reveals an environment value:\n%s", data)
+	}
+}
+
+func TestRunLog_Retention(t *testing.T) {
+	logDir := t.TempDir()
+	deps := NewDefaultDependencies()
+	runLog := NewRunLog("/repo", config.RunLogConfig{Dir: logDir, MaxRuns: 2}, nil, deps)
+	cmd := &DiscoveredCommand{Command: "make", Args: []string{"lint"}, WorkingDir: "/repo"}
+
+	var paths []string
+	for i := range 4 {
+		path, err := runLog.Write(runLogEntry{
+			cmd:     cmd,
+			cmdType: CommandTypeLint,
+			started: time.Now().Add(time.Duration(i) * time.Second),
+			result:  &ValidationResult{Success: true},
+		})
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		modTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		if chtimesErr := os.Chtimes(path, modTime, modTime); chtimesErr != nil {
+			t.Fatal(chtimesErr)
+		}
+		paths = append(paths, path)
+	}
+
+	logs, _ := filepath.Glob(filepath.Join(logDir, "*", "*.log"))
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs after pruning, got %v", logs)
+	}
+	// The log just written always survives; the oldest are removed first
+	if _, err := os.Stat(paths[3]); err != nil {
+		t.Errorf("newest log was removed: %v", err)
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Error("oldest log was kept")
+	}
+}
+
+func TestParallelValidateExecutor_RunLog(t *testing.T) {
+	logDir := t.TempDir()
+	testDeps := createTestDependencies()
+	testDeps.Dependencies.FS = &realFileSystem{}
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, _ string, _ ...string) (*CommandOutput, error) {
+		return &CommandOutput{Stdout: []byte("main.go:3:1: unused variable x\n")}, errors.New("exit status 1")
+	}
+
+	executor := NewParallelValidateExecutor("/repo", 10, false, nil, testDeps.Dependencies)
+	executor.EnableRunLog(NewRunLog("/repo", config.RunLogConfig{Dir: logDir}, nil, testDeps.Dependencies))
+
+	lintCmd := &DiscoveredCommand{Type: CommandTypeLint, Command: "make", Args: []string{"lint"}, WorkingDir: "/repo"}
+	result := executor.executeParallel(context.Background(), lintCmd, nil)
+
+	logPath := result.LintResult.LogPath
+	if logPath == "" {
+		t.Fatal("expected a run log path on the result")
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "main.go:3:1: unused variable x") {
+		t.Errorf("log does not contain the command output:\n%s", data)
+	}
+
+	message := result.FormatMessage()
+	if !strings.Contains(message, "Full lint output: "+logPath) {
+		t.Errorf("blocking message does not reference the log: %q", message)
+	}
+}
+
+func TestValidateResult_FormatMessageOmitsPassingLogs(t *testing.T) {
+	cmd := &DiscoveredCommand{Command: "make", Args: []string{"test"}, WorkingDir: "/repo"}
+	result := &ValidateResult{
+		LintResult: &ValidationResult{Type: CommandTypeLint, Success: true, Command: cmd, LogPath: "/logs/lint.log"},
+		TestResult: &ValidationResult{Type: CommandTypeTest, Success: false, Command: cmd, LogPath: "/logs/test.log"},
+	}
+
+	message := result.FormatMessage()
+	if strings.Contains(message, "/logs/lint.log") {
+		t.Errorf("message references the log of a passing command: %q", message)
+	}
+	if !strings.Contains(message, "Full test output: /logs/test.log") {
+		t.Errorf("message does not reference the failing test log: %q", message)
+	}
+}
@@ -0,0 +1,151 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+	"github.com/Veraticus/cc-tools/internal/sandbox"
+)
+
+var (
+	// ErrSandboxViolation is returned when a sandboxed command failed after being denied a write.
+	ErrSandboxViolation = errors.New("write denied by sandbox")
+	// ErrSandboxUnavailable is returned when the sandbox could not be applied to a command.
+	ErrSandboxUnavailable = errors.New("sandbox unavailable")
+)
+
+// sandboxRunner runs every command through the sandbox helper.
+type sandboxRunner struct {
+	inner  CommandRunner
+	helper string
+	policy sandbox.Policy
+}
+
+func (r *sandboxRunner) RunContext(
+	ctx context.Context,
+	dir, name string,
+	args ...string,
+) (*CommandOutput, error) {
+	argv := sandbox.Command(r.helper, r.policy, name, args)
+	out, err := r.inner.RunContext(ctx, dir, argv[0], argv[1:]...)
+	if err == nil || out == nil {
+		return out, err
+	}
+	return out, classifySandboxError(out, err, r.policy)
+}
+
+func (r *sandboxRunner) LookPath(file string) (string, error) {
+	return r.inner.LookPath(file)
+}
+
+func (r *sandboxRunner) WithEnv(env []string) CommandRunner {
+	return &sandboxRunner{inner: r.inner.WithEnv(env), helper: r.helper, policy: r.policy}
+}
+
+// deniedPathPattern matches the absolute paths in an error message.
+var deniedPathPattern = regexp.MustCompile(`/[^\s'"‘’“”:,;()\[\]]+`)
+
+// classifySandboxError marks failures caused by the sandbox. The original error
+// stays wrapped so the command's exit code can still be read from it.
+func classifySandboxError(out *CommandOutput, err error, policy sandbox.Policy) error {
+	stderr := string(out.Stderr)
+	if strings.Contains(stderr, sandbox.UnavailableMarker) {
+		return fmt.Errorf("%w: %w", ErrSandboxUnavailable, err)
+	}
+
+	// Landlock denials surface as EACCES or EPERM in the command's own output.
+	// Only a denial naming a path the policy does not allow can be the sandbox's.
+	for line := range strings.SplitSeq(string(out.Stdout)+"\n"+stderr, "\n") {
+		lower := strings.ToLower(line)
+		if !strings.Contains(lower, "permission denied") && !strings.Contains(lower, "operation not permitted") {
+			continue
+		}
+		for _, path := range deniedPathPattern.FindAllString(line, -1) {
+			if !writable(policy, path) {
+				return fmt.Errorf("%w: %w", ErrSandboxViolation, err)
+			}
+		}
+	}
+	return err
+}
+
+// writable reports whether the policy allows writes to path.
+func writable(policy sandbox.Policy, path string) bool {
+	path = filepath.Clean(path)
+	for _, root := range policy.Writable {
+		rel, err := filepath.Rel(filepath.Clean(root), path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return true
+		}
+	}
+	return false
+}
+
+// withSandbox returns deps whose runner confines writes to the project, the
+// toolchain caches and the configured extra paths. The cc-tools binary itself
+// applies the sandbox, so it is re-executed as a helper for every command.
+func withSandbox(
+	deps *Dependencies,
+	projectRoot string,
+	cfg config.SandboxConfig,
+	logger *debuglog.Logger,
+) (*Dependencies, error) {
+	helper, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locating sandbox helper: %w", err)
+	}
+
+	policy := sandboxPolicy(projectRoot, cfg)
+	if logger != nil && logger.IsEnabled() {
+		logger.LogSection("Sandbox")
+		for _, path := range policy.Writable {
+			logger.Log("  writable: %s", path)
+		}
+	}
+
+	wrapped := *deps
+	wrapped.Runner = &sandboxRunner{inner: deps.Runner, helper: helper, policy: policy}
+	return &wrapped, nil
+}
+
+// validationDependencies returns deps whose runner runs commands in the
+// project environment and, when enabled, the sandbox. The sandbox is the
+// outermost layer, so environment loaders such as direnv and wrappers such as
+// nix develop, which evaluate project code, run confined as well.
+func validationDependencies(
+	ctx context.Context,
+	projectRoot string,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) (*Dependencies, *EnvDiff, error) {
+	if cfg.Sandbox.Enabled {
+		sandboxed, err := withSandbox(deps, projectRoot, cfg.Sandbox, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		deps = sandboxed
+	}
+	runDeps, envDiff := setupEnvironment(ctx, projectRoot, cfg.Env, deps, logger)
+	return runDeps, envDiff, nil
+}
+
+// sandboxPolicy returns the policy of commands run for projectRoot: writes to
+// the project, the toolchain caches and the configured extra paths.
+func sandboxPolicy(projectRoot string, cfg config.SandboxConfig) sandbox.Policy {
+	policy := sandbox.Policy{Writable: sandbox.DefaultWritable(projectRoot)}
+	for _, path := range cfg.AllowWrite {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectRoot, path)
+		}
+		policy.Writable = append(policy.Writable, path)
+	}
+	return policy
+}
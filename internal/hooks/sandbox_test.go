@@ -0,0 +1,172 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/sandbox"
+)
+
+func TestSandboxRunner_RunContext(t *testing.T) {
+	errExit := errors.New("exit status 1")
+	tests := []struct {
+		name       string
+		output     *CommandOutput
+		err        error
+		wantReason string
+	}{
+		{name: "success", output: &CommandOutput{}},
+		{
+			name:   "ordinary failure",
+			output: &CommandOutput{Stdout: []byte("main.go:3: unused variable")},
+			err:    errExit,
+		},
+		{
+			name:       "denied write",
+			output:     &CommandOutput{Stderr: []byte("open /home/user/.config/tool: permission denied")},
+			err:        errExit,
+			wantReason: FailureReasonSandboxViolation,
+		},
+		{
+			name:   "test failure mentioning permissions",
+			output: &CommandOutput{Stdout: []byte("--- FAIL: TestAccess\n    want error \"permission denied\"")},
+			err:    errExit,
+		},
+		{
+			name:   "denied write inside the writable paths",
+			output: &CommandOutput{Stderr: []byte("open /project/testdata/readonly.txt: permission denied")},
+			err:    errExit,
+		},
+		{
+			name:       "sandbox unavailable",
+			output:     &CommandOutput{Stderr: []byte(sandbox.UnavailableMarker + " landlock is not enabled")},
+			err:        errExit,
+			wantReason: FailureReasonSandboxUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotName string
+			var gotArgs []string
+			inner := &mockCommandRunner{
+				runContextFunc: func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+					gotName, gotArgs = name, args
+					return tt.output, tt.err
+				},
+			}
+			runner := &sandboxRunner{
+				inner:  inner,
+				helper: "/usr/bin/cc-tools",
+				policy: sandbox.Policy{Writable: []string{"/project"}},
+			}
+
+			_, err := runner.RunContext(context.Background(), "/project", "make", "lint")
+
+			wantArgv := "/usr/bin/cc-tools __sandbox --write /project -- make lint"
+			if argv := strings.Join(append([]string{gotName}, gotArgs...), " "); argv != wantArgv {
+				t.Errorf("inner argv = %q, want %q", argv, wantArgv)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error %v should wrap the command's error", err)
+			}
+			if reason := failureReason(err); reason != tt.wantReason {
+				t.Errorf("failure reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestWithSandbox_AllowWrite(t *testing.T) {
+	testDeps := createTestDependencies()
+	cfg := config.SandboxConfig{Enabled: true, AllowWrite: []string{"/opt/shared", "build/out"}}
+
+	sandboxed, err := withSandbox(testDeps.Dependencies, "/project", cfg, nil)
+	if err != nil {
+		t.Fatalf("withSandbox() error = %v", err)
+	}
+	runner, ok := sandboxed.Runner.(*sandboxRunner)
+	if !ok {
+		t.Fatalf("runner = %T, want *sandboxRunner", sandboxed.Runner)
+	}
+	writable := strings.Join(runner.policy.Writable, " ")
+	for _, want := range []string{"/project", "/opt/shared", "/project/build/out"} {
+		if !strings.Contains(writable, want) {
+			t.Errorf("writable = %q, want to contain %q", writable, want)
+		}
+	}
+}
+
+func TestExecuteValidations_SandboxViolation(t *testing.T) {
+	testDeps := createTestDependencies()
+	testDeps.MockFS.statFunc = func(path string) (os.FileInfo, error) {
+		if path == "/project/Makefile" {
+			return mockFileInfo{name: "Makefile"}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		// Unwrap the helper argv to see the sandboxed command
+		if name == "cc-tools" {
+			separator := slices.Index(args, "--")
+			name, args = args[separator+1], args[separator+2:]
+		}
+		if name == "make" && args[0] == "test" {
+			return &CommandOutput{Stderr: []byte("mkdir /var/lib/fixtures: Permission denied")},
+				errors.New("exit status 2")
+		}
+		return &CommandOutput{}, nil
+	}
+
+	deps := *testDeps.Dependencies
+	deps.Runner = &sandboxRunner{inner: testDeps.MockRunner, helper: "cc-tools"}
+	executor := NewParallelValidateExecutor("/project", 10, false, nil, &deps)
+	result, err := executor.ExecuteValidations(context.Background(), "/project", "/project")
+	if err != nil {
+		t.Fatalf("ExecuteValidations() error = %v", err)
+	}
+	if result.TestResult == nil || result.TestResult.FailureReason != FailureReasonSandboxViolation {
+		t.Fatalf("test result = %+v, want a sandbox violation", result.TestResult)
+	}
+	if message := result.FormatMessage(); !strings.Contains(message, "validate.sandbox.allow_write") {
+		t.Errorf("FormatMessage() = %q, want a sandbox hint", message)
+	}
+}
+
+func TestValidationDependencies_SandboxWrapsEnvironment(t *testing.T) {
+	testDeps := createTestDependencies()
+	var argv []string
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		argv = append([]string{name}, args...)
+		return &CommandOutput{}, nil
+	}
+	cfg := &config.ValidateConfig{
+		ValidateOptions: config.ValidateOptions{
+			Env:     config.EnvConfig{Loaders: []string{config.EnvLoaderNix}},
+			Sandbox: config.SandboxConfig{Enabled: true},
+		},
+	}
+
+	runDeps, _, err := validationDependencies(context.Background(), "/project", cfg, testDeps.Dependencies, nil)
+	if err != nil {
+		t.Fatalf("validationDependencies() error = %v", err)
+	}
+	if _, err = runDeps.Runner.RunContext(context.Background(), "/project", "make", "lint"); err != nil {
+		t.Fatalf("RunContext() error = %v", err)
+	}
+
+	// nix evaluates the project's flake, so it must start inside the helper
+	separator := slices.Index(argv, "--")
+	if len(argv) < 2 || argv[1] != sandbox.HelperCommand || separator < 0 {
+		t.Fatalf("argv = %q, want the sandbox helper outermost", argv)
+	}
+	want := []string{"nix", "develop", "/project", "-c", "make", "lint"}
+	if got := argv[separator+1:]; !slices.Equal(got, want) {
+		t.Errorf("sandboxed command = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,338 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+	"github.com/Veraticus/cc-tools/internal/shared"
+)
+
+// secretEntropy is the entropy in bits per character a value assigned to a
+// secret-looking name needs, so that "changeme" and "${TOKEN}" pass.
+const secretEntropy = 3.5
+
+// secretVisiblePrefix is how many characters of a masked secret stay visible.
+const secretVisiblePrefix = 4
+
+// maxSecretLineLength caps the masked lines quoted in a message.
+const maxSecretLineLength = 120
+
+// secretRules returns the built-in secret rules with the configured rules
+// applied on top: a rule with the ID of a built-in rule replaces it, one
+// without a pattern disables it, and others are added.
+func secretRules(custom []config.SecretRule) []config.SecretRule {
+	names := `(?i:password|passwd|pwd|secret|token|api_?key|access_?key|private_?key)`
+	rules := []config.SecretRule{
+		{ID: "aws-access-key-id", Pattern: `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`},
+		{
+			ID:      "aws-secret-access-key",
+			Pattern: `(?i)aws_?secret_?(?:access_?)?key["'` + "`" + `]?\s*(?::=|=>|=|:)\s*["'` + "`" + `]?([A-Za-z0-9/+=]{40})\b`,
+		},
+		{ID: "github-token", Pattern: `\b(?:(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`},
+		{ID: "slack-token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+		{ID: "slack-webhook", Pattern: `https://hooks\.slack\.com/services/[A-Za-z0-9/]+`},
+		{ID: "private-key", Pattern: `-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`},
+		{
+			ID:         "secret-assignment",
+			Pattern:    names + `["'` + "`" + `]?\s*(?::=|=>|=|:)\s*["'` + "`" + `]([^"'` + "`" + `\s]{8,})["'` + "`" + `]`,
+			MinEntropy: secretEntropy,
+		},
+		{
+			ID:         "dotenv-secret",
+			Pattern:    `^(?:export\s+)?[A-Za-z0-9_]*` + names + `[A-Za-z0-9_]*=["']?([^\s"'$]{8,})`,
+			MinEntropy: secretEntropy,
+		},
+	}
+	for _, rule := range custom {
+		if i := slices.IndexFunc(rules, func(r config.SecretRule) bool { return r.ID == rule.ID }); i >= 0 {
+			rules[i] = rule
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+	return slices.DeleteFunc(rules, func(r config.SecretRule) bool { return r.Pattern == "" })
+}
+
+// secretMatcher is a compiled secret rule.
+type secretMatcher struct {
+	id         string
+	pattern    *regexp.Regexp
+	minEntropy float64
+}
+
+// compileSecretRules compiles secret rules; invalid patterns are logged and skipped.
+func compileSecretRules(rules []config.SecretRule, logger *debuglog.Logger) []secretMatcher {
+	matchers := make([]secretMatcher, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			if logger != nil && logger.IsEnabled() {
+				logger.LogError(err, "compiling secret rule "+rule.ID)
+			}
+			continue
+		}
+		matchers = append(matchers, secretMatcher{id: rule.ID, pattern: pattern, minEntropy: rule.MinEntropy})
+	}
+	return matchers
+}
+
+// match returns the secret on a line: the first capture group of the
+// pattern, or its whole match when it has none.
+func (m secretMatcher) match(line string) (string, bool) {
+	for _, groups := range m.pattern.FindAllStringSubmatch(line, -1) {
+		secret := groups[0]
+		if len(groups) > 1 {
+			secret = groups[1]
+		}
+		if secret != "" && shannonEntropy(secret) >= m.minEntropy {
+			return secret, true
+		}
+	}
+	return "", false
+}
+
+// shannonEntropy returns the entropy of a string in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// secretAllowlist holds the false positives listed in a project's allowlist
+// file: "path:" lines are globs of files relative to the project root, and
+// other lines are regular expressions matching allowed secrets.
+type secretAllowlist struct {
+	paths   []string
+	secrets []*regexp.Regexp
+}
+
+// loadSecretAllowlist reads the allowlist of a project. A missing file allows nothing.
+func loadSecretAllowlist(path string, deps *Dependencies, logger *debuglog.Logger) secretAllowlist {
+	var allow secretAllowlist
+	data, err := deps.FS.ReadFile(path)
+	if err != nil {
+		return allow
+	}
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if glob, ok := strings.CutPrefix(line, "path:"); ok {
+			allow.paths = append(allow.paths, strings.TrimSpace(glob))
+			continue
+		}
+		pattern, compileErr := regexp.Compile(line)
+		if compileErr != nil {
+			if logger != nil && logger.IsEnabled() {
+				logger.LogError(compileErr, "compiling secrets allowlist entry")
+			}
+			continue
+		}
+		allow.secrets = append(allow.secrets, pattern)
+	}
+	return allow
+}
+
+// allowsPath reports whether a file, relative to the project root, is allowlisted.
+func (a secretAllowlist) allowsPath(rel string) bool {
+	return slices.ContainsFunc(a.paths, func(glob string) bool {
+		matched, _ := filepath.Match(glob, rel)
+		return matched
+	})
+}
+
+// allowsSecret reports whether a secret is allowlisted.
+func (a secretAllowlist) allowsSecret(secret string) bool {
+	return slices.ContainsFunc(a.secrets, func(pattern *regexp.Regexp) bool { return pattern.MatchString(secret) })
+}
+
+// secretLeak is a secret found on an added line, with the line masked.
+type secretLeak struct {
+	line   int
+	rule   string
+	masked string
+}
+
+// findSecrets returns the secrets on added lines that the allowlist does not
+// allow, one per line.
+func findSecrets(added []addedLine, matchers []secretMatcher, allow secretAllowlist) []secretLeak {
+	var leaks []secretLeak
+	for _, line := range added {
+		for _, matcher := range matchers {
+			secret, ok := matcher.match(line.text)
+			if !ok || allow.allowsSecret(secret) {
+				continue
+			}
+			masked := strings.ReplaceAll(line.text, secret, maskSecret(secret))
+			if len(masked) > maxSecretLineLength {
+				masked = masked[:maxSecretLineLength] + "…"
+			}
+			leaks = append(leaks, secretLeak{line: line.number, rule: matcher.id, masked: masked})
+			break
+		}
+	}
+	return leaks
+}
+
+// maskSecret hides a secret, keeping its first characters when it is long
+// enough that they do not give it away.
+func maskSecret(secret string) string {
+	if len(secret) <= 2*secretVisiblePrefix {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:secretVisiblePrefix] + strings.Repeat("*", len(secret)-secretVisiblePrefix)
+}
+
+// scanSecrets returns the secrets on the lines an edit adds to a file. Files
+// ignored by git, such as .env, never reach a commit and are not scanned.
+func scanSecrets(
+	ctx context.Context,
+	projectRoot, filePath string,
+	added []addedLine,
+	cfg config.SecretsConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) []secretLeak {
+	if len(added) == 0 {
+		return nil
+	}
+	allow := loadSecretAllowlist(filepath.Join(projectRoot, cfg.GetAllowlist()), deps, logger)
+	if rel, err := filepath.Rel(projectRoot, filePath); err == nil && allow.allowsPath(rel) {
+		return nil
+	}
+	leaks := findSecrets(added, compileSecretRules(secretRules(cfg.Rules), logger), allow)
+	if len(leaks) == 0 {
+		return nil
+	}
+	if _, err := deps.Runner.RunContext(ctx, projectRoot, "git", "check-ignore", "-q", filePath); err == nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.Log("Secrets in %s ignored: the file is ignored by git", filePath)
+		}
+		return nil
+	}
+	return leaks
+}
+
+// secretsMessage formats the secrets found in an edit of a file.
+func secretsMessage(severity, filePath, verb, allowlist string, leaks []secretLeak) string {
+	lines := make([]string, 0, min(len(leaks), maxListedLines)+1)
+	for i, leak := range leaks {
+		if i == maxListedLines {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(leaks)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %d (%s): %s", leak.line, leak.rule, leak.masked))
+	}
+	return formatFailure(severity, "The edit to %s %s secrets:\n%s\n"+
+		"Load them from the environment or a secret store instead; list false positives in %s",
+		filepath.Base(filePath), verb, strings.Join(lines, "\n"), allowlist)
+}
+
+// pendingAddedLines returns the lines a Write, Edit or MultiEdit call is
+// about to add. Lines of edits are numbered from where the replaced text
+// sits in the file, when it can be found.
+func pendingAddedLines(filePath string, input *HookInput, deps *Dependencies) []addedLine {
+	// A file that does not exist yet has no previous content
+	data, _ := deps.FS.ReadFile(filePath)
+	current := string(data)
+	if content, ok := input.WrittenContent(); ok {
+		return addedLines(current, content, 1)
+	}
+
+	edits, _ := input.TextEdits()
+	var added []addedLine
+	for _, edit := range edits {
+		first := 1
+		if i := strings.Index(current, edit.OldString); edit.OldString != "" && i >= 0 {
+			first = strings.Count(current[:i], "\n") + 1
+		}
+		added = append(added, addedLines(edit.OldString, edit.NewString, first)...)
+	}
+	return added
+}
+
+// checkSecretsBeforeEdit scans the content a PreToolUse event is about to
+// write and returns ExitCodeShowMessage to stop the edit when it holds
+// secrets. Only the block policy stops edits; advisory findings are
+// reported once the edit lands.
+func checkSecretsBeforeEdit(
+	ctx context.Context,
+	input *HookInput,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) int {
+	filePath := input.GetFilePath()
+	if filePath == "" {
+		return 0
+	}
+	root, err := shared.DetectProjectRoot(filepath.Dir(filePath), ProjectRootOptions(cfg.Root, input.CWD), nil)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "finding project root")
+		}
+		return 0
+	}
+	cfg = loadProjectConfig(cfg, root.Dir, deps, logger)
+	severity := cfg.Severity.Get(config.CheckSecrets)
+	if !cfg.Secrets.Enabled || severity != config.SeverityBlock {
+		return 0
+	}
+
+	leaks := scanSecrets(ctx, root.Dir, filePath, pendingAddedLines(filePath, input, deps), cfg.Secrets, deps, logger)
+	if len(leaks) == 0 {
+		return 0
+	}
+	reportMessage(deps, ExitCodeShowMessage,
+		secretsMessage(severity, filePath, "would add", cfg.Secrets.GetAllowlist(), leaks))
+	return ExitCodeShowMessage
+}
+
+// checkSecrets flags secrets on the lines an edit added.
+func checkSecrets(
+	ctx context.Context,
+	projectRoot, filePath string,
+	input *HookInput,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckSecrets)
+	if !cfg.Secrets.Enabled {
+		return finding{severity: severity}
+	}
+	content, err := deps.FS.ReadFile(filePath)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "reading edited file for the secret scan")
+		}
+		return finding{severity: severity}
+	}
+
+	added := editAddedLines(ctx, filePath, string(content), input, deps)
+	leaks := scanSecrets(ctx, projectRoot, filePath, added, cfg.Secrets, deps, logger)
+	if len(leaks) == 0 {
+		return finding{severity: severity}
+	}
+	return finding{
+		message:  secretsMessage(severity, filePath, "adds", cfg.Secrets.GetAllowlist(), leaks),
+		severity: severity,
+	}
+}
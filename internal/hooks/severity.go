@@ -0,0 +1,89 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	"github.com/Veraticus/cc-tools/internal/output"
+)
+
+// hookOutput is the JSON a hook prints to stdout to report without blocking.
+type hookOutput struct {
+	SystemMessage      string              `json:"systemMessage,omitempty"`
+	HookSpecificOutput *hookSpecificOutput `json:"hookSpecificOutput,omitempty"`
+}
+
+// hookSpecificOutput carries context for Claude alongside a hook result.
+type hookSpecificOutput struct {
+	HookEventName     string `json:"hookEventName"`
+	AdditionalContext string `json:"additionalContext,omitempty"`
+}
+
+// reportMessage delivers a validation message. With ExitCodeShowMessage it
+// goes to stderr, which blocks Claude until the failure is fixed. Otherwise it
+// is printed as hook JSON so it reaches the user and Claude without blocking.
+func reportMessage(deps *Dependencies, exitCode int, message string) {
+	if message == "" {
+		return
+	}
+	if exitCode == ExitCodeShowMessage {
+		_, _ = fmt.Fprintln(deps.Stderr, message)
+		return
+	}
+
+	data, err := json.Marshal(hookOutput{
+		SystemMessage: message,
+		HookSpecificOutput: &hookSpecificOutput{
+			HookEventName:     "PostToolUse",
+			AdditionalContext: message,
+		},
+	})
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintln(deps.Stdout, string(data))
+}
+
+// finding is the failure message of a check, empty when it passed, and its
+// severity. Info is reported whether or not the check passed.
+type finding struct {
+	message  string
+	severity string
+	info     string
+}
+
+// reportFindings reports findings on their own, for invocations that do not
+// run validation, and returns the hook's exit code.
+func reportFindings(deps *Dependencies, findings ...finding) int {
+	var messages, infos []string
+	exitCode := 0
+	for _, f := range findings {
+		if f.info != "" {
+			infos = append(infos, f.info)
+		}
+		if f.message == "" || f.severity == config.SeveritySilent {
+			continue
+		}
+		messages = append(messages, f.message)
+		if f.severity == config.SeverityBlock {
+			exitCode = ExitCodeShowMessage
+		}
+	}
+	messages = append(messages, infos...)
+	if len(messages) == 0 {
+		return 0
+	}
+	reportMessage(deps, exitCode, strings.Join(messages, "\n"))
+	return exitCode
+}
+
+// formatFailure formats a failure line for the given severity.
+func formatFailure(severity, format string, args ...any) string {
+	formatter := output.NewHookFormatter()
+	if severity == config.SeverityBlock {
+		return formatter.FormatBlockingError("⛔ BLOCKING: "+format, args...)
+	}
+	return formatter.FormatWarning(fmt.Sprintf("⚠️ ADVISORY: "+format+" (not blocking)", args...))
+}
@@ -0,0 +1,185 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestValidateResult_FormatMessageSeverity(t *testing.T) {
+	failed := func(args string) *ValidationResult {
+		return &ValidationResult{
+			Success: false,
+			Command: &DiscoveredCommand{Command: "make", Args: []string{args}, WorkingDir: "/project"},
+			LogPath: "/logs/" + args + ".log",
+		}
+	}
+	passed := &ValidationResult{Success: true, Command: &DiscoveredCommand{Command: "make", Args: []string{"test"}}}
+
+	tests := []struct {
+		name        string
+		result      *ValidateResult
+		wantContain []string
+		wantMissing []string
+	}{
+		{
+			name: "advisory lint",
+			result: &ValidateResult{
+				LintResult: failed("lint"),
+				TestResult: passed,
+				Severity:   config.SeverityConfig{Lint: config.SeverityWarn},
+			},
+			wantContain: []string{"ADVISORY", "make lint", "not blocking"},
+			wantMissing: []string{"BLOCKING"},
+		},
+		{
+			name: "blocking test with advisory lint",
+			result: &ValidateResult{
+				LintResult: failed("lint"),
+				TestResult: failed("test"),
+				Severity:   config.SeverityConfig{Lint: config.SeverityWarn},
+			},
+			wantContain: []string{"BLOCKING: Run 'cd /project && make test'", "ADVISORY: Run 'cd /project && make lint'"},
+			wantMissing: []string{"Lint and test failures"},
+		},
+		{
+			name: "silent lint reads as a pass",
+			result: &ValidateResult{
+				LintResult: failed("lint"),
+				TestResult: passed,
+				Severity:   config.SeverityConfig{Lint: config.SeveritySilent},
+			},
+			wantContain: []string{"Validations pass"},
+			wantMissing: []string{"make lint", "/logs/lint.log"},
+		},
+		{
+			name: "unknown severity blocks",
+			result: &ValidateResult{
+				TestResult: failed("test"),
+				Severity:   config.SeverityConfig{Test: "sometimes"},
+			},
+			wantContain: []string{"BLOCKING", "/logs/test.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.result.FormatMessage()
+			for _, want := range tt.wantContain {
+				if !strings.Contains(message, want) {
+					t.Errorf("FormatMessage() = %q, want to contain %q", message, want)
+				}
+			}
+			for _, unwanted := range tt.wantMissing {
+				if strings.Contains(message, unwanted) {
+					t.Errorf("FormatMessage() = %q, should not contain %q", message, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_Severity(t *testing.T) {
+	tests := []struct {
+		name         string
+		severity     string
+		wantExitCode int
+		wantStderr   string
+		wantJSON     string
+	}{
+		{name: "block", severity: config.SeverityBlock, wantExitCode: ExitCodeShowMessage, wantStderr: "BLOCKING"},
+		{name: "warn", severity: config.SeverityWarn, wantJSON: "ADVISORY"},
+		{name: "silent", severity: config.SeveritySilent, wantExitCode: ExitCodeShowMessage, wantStderr: "Validations pass"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			newMemFiles(testDeps)
+			setupQueueProject(testDeps, func() bool { return true })
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{
+					Severity: config.SeverityConfig{Lint: tt.severity},
+				},
+				TimeoutSeconds: 10,
+			}
+			exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+
+			if exitCode != tt.wantExitCode {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExitCode)
+			}
+			if !strings.Contains(testDeps.MockStderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want to contain %q", testDeps.MockStderr.String(), tt.wantStderr)
+			}
+
+			stdout := testDeps.MockStdout.String()
+			if tt.wantJSON == "" {
+				if stdout != "" {
+					t.Errorf("expected no stdout, got %q", stdout)
+				}
+				return
+			}
+			var out hookOutput
+			if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+				t.Fatalf("stdout is not hook JSON: %v\n%s", err, stdout)
+			}
+			if !strings.Contains(out.SystemMessage, tt.wantJSON) || out.HookSpecificOutput == nil ||
+				!strings.Contains(out.HookSpecificOutput.AdditionalContext, tt.wantJSON) {
+				t.Errorf("hook JSON = %+v, want message containing %q", out, tt.wantJSON)
+			}
+			if testDeps.MockStderr.String() != "" {
+				t.Errorf("advisory result should not write stderr, got %q", testDeps.MockStderr.String())
+			}
+		})
+	}
+}
+
+func TestReportFindings(t *testing.T) {
+	tests := []struct {
+		name       string
+		findings   []finding
+		wantExit   int
+		wantStderr string
+		wantStdout string
+	}{
+		{
+			name:     "nothing to report",
+			findings: []finding{{severity: config.SeverityBlock}, {message: "hidden", severity: config.SeveritySilent}},
+		},
+		{
+			name: "blocking finding with info",
+			findings: []finding{
+				{message: "blocked", severity: config.SeverityBlock},
+				{severity: config.SeverityWarn, info: "for the record"},
+			},
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: "blocked\nfor the record",
+		},
+		{
+			name:       "info alone does not block",
+			findings:   []finding{{severity: config.SeverityBlock, info: "for the record"}},
+			wantStdout: "for the record",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			if exitCode := reportFindings(testDeps.Dependencies, tt.findings...); exitCode != tt.wantExit {
+				t.Errorf("reportFindings() = %d, want %d", exitCode, tt.wantExit)
+			}
+			if stderr := testDeps.MockStderr.String(); !strings.Contains(stderr, tt.wantStderr) ||
+				(tt.wantStderr == "" && stderr != "") {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+			if stdout := testDeps.MockStdout.String(); !strings.Contains(stdout, tt.wantStdout) ||
+				(tt.wantStdout == "" && stdout != "") {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+}
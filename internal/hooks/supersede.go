@@ -0,0 +1,87 @@
+package hooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+const (
+	// supersedeWait bounds how long a new invocation waits for the old holder to exit.
+	supersedeWait = 10 * time.Second
+	// supersedePoll is how often the lock is retried while waiting.
+	supersedePoll = 100 * time.Millisecond
+)
+
+// supersedeHolder signals the current lock holder to cancel its run and waits
+// to take over the lock. If another new invocation grabs the lock first, that
+// one is signaled in turn, so the latest edit wins.
+func supersedeHolder(
+	ctx context.Context,
+	lockMgr *LockManager,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) bool {
+	waitCtx, cancel := context.WithTimeout(ctx, supersedeWait)
+	defer cancel()
+
+	// Without a signal the holder's run is waited out
+	sig := supersedeSignal()
+	signaled := 0
+	for {
+		if pid := lockMgr.HolderPID(); sig != nil && pid != 0 && pid != signaled && pid != deps.Process.GetPID() {
+			if err := deps.Process.Signal(pid, sig); err != nil {
+				if logger != nil && logger.IsEnabled() {
+					logger.LogError(err, "signaling lock holder")
+				}
+			} else if logger != nil && logger.IsEnabled() {
+				logger.Log("Asked PID %d to cancel its run", pid)
+			}
+			signaled = pid
+		}
+
+		if !sleepContext(waitCtx, supersedePoll) {
+			if logger != nil && logger.IsEnabled() {
+				logger.Log("Lock holder did not exit in time")
+			}
+			return false
+		}
+
+		acquired, err := lockMgr.TryAcquire()
+		if err != nil {
+			if logger != nil && logger.IsEnabled() {
+				logger.LogError(err, "acquiring lock")
+			}
+			return false
+		}
+		if acquired {
+			if logger != nil && logger.IsEnabled() {
+				logger.Log("Took over the lock from the superseded run")
+			}
+			return true
+		}
+	}
+}
+
+// watchSupersede returns a context that is canceled when a newer invocation
+// supersedes this run, and a function reporting whether that happened. It
+// must be called before the lock is acquired, as newer invocations signal the
+// holder as soon as its PID is in the lock file.
+func watchSupersede(
+	ctx context.Context,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+) (context.Context, func() bool, context.CancelFunc) {
+	sig := supersedeSignal()
+	if cfg.GetOnBusy() != config.OnBusySupersede || sig == nil {
+		return ctx, func() bool { return false }, func() {}
+	}
+
+	runCtx, stop := deps.Process.NotifyContext(ctx, sig)
+	superseded := func() bool {
+		return runCtx.Err() != nil && ctx.Err() == nil
+	}
+	return runCtx, superseded, stop
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package hooks
+
+import "os"
+
+// supersedeSignal returns nil, as there is no signal a process can catch to
+// cancel its run. Superseding invocations wait for the holder instead.
+func supersedeSignal() os.Signal {
+	return nil
+}
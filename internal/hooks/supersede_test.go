@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func supersedeConfig() *config.ValidateConfig {
+	cfg := queueConfig()
+	cfg.OnBusy = config.OnBusySupersede
+	return cfg
+}
+
+func TestSupersede_NewInvocationTakesOverLock(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+
+	var signaled []int
+	testDeps.MockProcess.signalFunc = func(pid int, sig os.Signal) error {
+		if sig != supersedeSignal() {
+			t.Errorf("signal = %v, want %v", sig, supersedeSignal())
+		}
+		signaled = append(signaled, pid)
+		// The old holder cancels its run and exits, dropping the lock
+		files.mu.Lock()
+		delete(files.held, lockMgr.lockFile)
+		files.mu.Unlock()
+		return nil
+	}
+
+	exitCode := RunValidateHookWithConfig(context.Background(), false, supersedeConfig(), nil, testDeps.Dependencies)
+
+	if exitCode != ExitCodeShowMessage {
+		t.Errorf("exit code = %d, want %d", exitCode, ExitCodeShowMessage)
+	}
+	if len(signaled) != 1 || signaled[0] != 4242 {
+		t.Errorf("signaled %v, want [4242]", signaled)
+	}
+	if !strings.Contains(testDeps.MockStderr.String(), "Validations pass") {
+		t.Errorf("expected the new invocation to validate, got %q", testDeps.MockStderr.String())
+	}
+}
+
+func TestSupersede_CanceledHolderExitsSilently(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return true })
+
+	var cancelRun context.CancelFunc
+	var mu sync.Mutex
+	testDeps.MockProcess.notifyContextFunc = func(ctx context.Context, _ os.Signal) (context.Context, context.CancelFunc) {
+		runCtx, cancel := context.WithCancel(ctx)
+		mu.Lock()
+		cancelRun = cancel
+		mu.Unlock()
+		return runCtx, cancel
+	}
+
+	// The supersede signal arrives while lint is running
+	baseRunner := testDeps.MockRunner.runContextFunc
+	testDeps.MockRunner.runContextFunc = func(ctx context.Context, dir, name string, args ...string) (*CommandOutput, error) {
+		if name == "make" && len(args) == 1 && args[0] == "lint" {
+			mu.Lock()
+			cancelRun()
+			mu.Unlock()
+		}
+		return baseRunner(ctx, dir, name, args...)
+	}
+
+	exitCode := RunValidateHookWithConfig(context.Background(), false, supersedeConfig(), nil, testDeps.Dependencies)
+
+	if exitCode != 0 {
+		t.Errorf("exit code = %d, want 0", exitCode)
+	}
+	if testDeps.MockStderr.String() != "" {
+		t.Errorf("superseded run should be silent, got %q", testDeps.MockStderr.String())
+	}
+
+	lockMgr := NewLockManager("/project", "validate", 5, testDeps.Dependencies)
+	if remaining := lockMgr.CooldownRemaining(); remaining != 0 {
+		t.Errorf("superseded run left a cooldown of %v", remaining)
+	}
+	if len(files.withSuffix(".lock")) != 1 {
+		t.Error("expected the lock file to remain")
+	}
+}
+
+func TestSupersede_StalePIDIsNotSignaled(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	// A holder that died left its PID behind without holding the lock
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	files.hold(lockMgr.lockFile)
+	files.mu.Lock()
+	delete(files.held, lockMgr.lockFile)
+	files.mu.Unlock()
+
+	if pid := lockMgr.HolderPID(); pid != 0 {
+		t.Errorf("HolderPID() = %d, want 0 for a lock nobody holds", pid)
+	}
+	testDeps.MockProcess.signalFunc = func(pid int, _ os.Signal) error {
+		t.Errorf("signaled PID %d, which no longer holds the lock", pid)
+		return nil
+	}
+
+	RunValidateHookWithConfig(context.Background(), false, supersedeConfig(), nil, testDeps.Dependencies)
+}
+
+func TestSupersede_WatchesBeforeHoldingLock(t *testing.T) {
+	testDeps := createTestDependencies()
+	files := newMemFiles(testDeps)
+	setupQueueProject(testDeps, func() bool { return false })
+
+	lockMgr := NewLockManager("/project", "validate", 0, testDeps.Dependencies)
+	testDeps.MockProcess.notifyContextFunc = func(ctx context.Context, _ os.Signal) (context.Context, context.CancelFunc) {
+		files.mu.Lock()
+		defer files.mu.Unlock()
+		if files.held[lockMgr.lockFile] {
+			t.Error("signal handler installed after the lock was taken")
+		}
+		return context.WithCancel(ctx)
+	}
+
+	RunValidateHookWithConfig(context.Background(), false, supersedeConfig(), nil, testDeps.Dependencies)
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package hooks
+
+import (
+	"os"
+	"syscall"
+)
+
+// supersedeSignal returns the signal that asks a running validate hook to cancel its run.
+func supersedeSignal() os.Signal {
+	return syscall.SIGUSR1
+}
@@ -0,0 +1,175 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
+)
+
+var (
+	// nolintPattern matches golangci-lint's "//nolint" and "//nolint:linter" directives.
+	nolintPattern = regexp.MustCompile(`//\s*nolint\b`)
+	// eslintDisablePattern matches "eslint-disable", "-line" and "-next-line" comments.
+	eslintDisablePattern = regexp.MustCompile(`(?://|/\*)\s*eslint-disable\b`)
+	// typeIgnorePattern matches mypy and pyright "# type: ignore" comments.
+	typeIgnorePattern = regexp.MustCompile(`#\s*type:\s*ignore\b`)
+	// noqaPattern matches flake8 and ruff "# noqa" comments.
+	noqaPattern = regexp.MustCompile(`(?i)#\s*noqa\b`)
+	// rustAllowPattern matches "#[allow(...)]" and "#![allow(...)]" attributes.
+	rustAllowPattern = regexp.MustCompile(`#!?\[\s*allow\s*\(`)
+	// goSkipPattern matches t.Skip, t.Skipf and t.SkipNow, and their benchmark forms.
+	goSkipPattern = regexp.MustCompile(`\b[tb]\.Skip(?:f|Now)?\(`)
+	// jsSkipPattern matches it.skip, test.skip, describe.skip and the xit family.
+	jsSkipPattern = regexp.MustCompile(`\b(?:(?:it|test|describe)\.skip|xit|xtest|xdescribe)\s*\(`)
+	// pytestSkipPattern matches pytest and unittest skip decorators, including skipif.
+	pytestSkipPattern = regexp.MustCompile(`@(?:pytest\.mark|unittest)\.skip`)
+	// rustIgnorePattern matches the "#[ignore]" test attribute.
+	rustIgnorePattern = regexp.MustCompile(`#\[\s*ignore\b`)
+)
+
+// maxListedLines caps the added lines quoted in a message.
+const maxListedLines = 5
+
+// shortcutRule recognizes a way of silencing a check in files with the given extensions.
+type shortcutRule struct {
+	name       string
+	pattern    *regexp.Regexp
+	extensions []string
+}
+
+// shortcutRules returns the suppressions and test skips the guard looks for.
+func shortcutRules() []shortcutRule {
+	js := []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts", ".vue", ".svelte"}
+	return []shortcutRule{
+		{name: "//nolint", pattern: nolintPattern, extensions: []string{".go"}},
+		{name: "eslint-disable", pattern: eslintDisablePattern, extensions: js},
+		{name: "# type: ignore", pattern: typeIgnorePattern, extensions: []string{".py", ".pyi"}},
+		{name: "# noqa", pattern: noqaPattern, extensions: []string{".py", ".pyi"}},
+		{name: "#[allow]", pattern: rustAllowPattern, extensions: []string{".rs"}},
+		{name: "t.Skip", pattern: goSkipPattern, extensions: []string{".go"}},
+		{name: "skipped test", pattern: jsSkipPattern, extensions: js},
+		{name: "@pytest.mark.skip", pattern: pytestSkipPattern, extensions: []string{".py"}},
+		{name: "#[ignore]", pattern: rustIgnorePattern, extensions: []string{".rs"}},
+	}
+}
+
+// Shortcut is an added line that suppresses a lint finding or skips a test.
+type Shortcut struct {
+	Rule string
+	Line string
+}
+
+// FindShortcuts returns the lines added between before and after that
+// suppress lint findings or skip tests, for a file with the given path. A
+// line counts as added when it occurs more often after the edit than before,
+// so moved lines are not flagged.
+func FindShortcuts(path, before, after string) []Shortcut {
+	ext := strings.ToLower(filepath.Ext(path))
+	var rules []shortcutRule
+	for _, rule := range shortcutRules() {
+		for _, e := range rule.extensions {
+			if e == ext {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var found []Shortcut
+	for _, line := range addedLines(before, after, 1) {
+		for _, rule := range rules {
+			if rule.pattern.MatchString(line.text) {
+				found = append(found, Shortcut{Rule: rule.name, Line: line.text})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// addedLine is a line added by an edit, trimmed, with its line number.
+type addedLine struct {
+	number int
+	text   string
+}
+
+// addedLines returns the lines of after that occur more often than in
+// before, numbering the first line of after as first.
+func addedLines(before, after string, first int) []addedLine {
+	existing := make(map[string]int)
+	for line := range strings.Lines(before) {
+		existing[strings.TrimSpace(line)]++
+	}
+	var added []addedLine
+	number := first
+	for line := range strings.Lines(after) {
+		line = strings.TrimSpace(line)
+		if existing[line] > 0 {
+			existing[line]--
+		} else {
+			added = append(added, addedLine{number: number, text: line})
+		}
+		number++
+	}
+	return added
+}
+
+// checkSuppressions flags lint suppressions and skipped tests added by the
+// edit. The previous content comes from the tool input for Edit and
+// MultiEdit, and from git HEAD otherwise; files new since HEAD count as
+// entirely added.
+func checkSuppressions(
+	ctx context.Context,
+	filePath string,
+	input *HookInput,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) finding {
+	severity := cfg.Severity.Get(config.CheckSuppressions)
+	if !cfg.Suppressions.Enabled {
+		return finding{severity: severity}
+	}
+
+	before, after, ok := input.EditedText()
+	if !ok {
+		current, err := deps.FS.ReadFile(filePath)
+		if err != nil {
+			if logger != nil && logger.IsEnabled() {
+				logger.LogError(err, "reading edited file for the suppression guard")
+			}
+			return finding{severity: severity}
+		}
+		after = string(current)
+		if out, err := deps.Runner.RunContext(ctx, filepath.Dir(filePath), "git", "show",
+			"HEAD:./"+filepath.Base(filePath)); err == nil {
+			before = string(out.Stdout)
+		}
+	}
+
+	shortcuts := FindShortcuts(filePath, before, after)
+	if len(shortcuts) == 0 {
+		return finding{severity: severity}
+	}
+	lines := make([]string, 0, min(len(shortcuts), maxListedLines)+1)
+	for i, shortcut := range shortcuts {
+		if i == maxListedLines {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(shortcuts)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", shortcut.Rule, shortcut.Line))
+	}
+	return finding{
+		message: formatFailure(severity, "The edit to %s silences checks instead of fixing them:\n%s\n"+
+			"Fix the underlying problem, or ask the user to approve the exception",
+			filepath.Base(filePath), strings.Join(lines, "\n")),
+		severity: severity,
+	}
+}
@@ -0,0 +1,182 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
+)
+
+func TestFindShortcuts(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		before string
+		after  string
+		want   []string
+	}{
+		{
+			name:   "go nolint and skip",
+			path:   "pkg/a_test.go",
+			before: "x := f()\n",
+			after:  "x := f() //nolint:errcheck\nt.Skip(\"flaky\")\n",
+			want:   []string{"//nolint: x := f() //nolint:errcheck", `t.Skip: t.Skip("flaky")`},
+		},
+		{
+			name:   "existing and moved lines are not new",
+			path:   "a.go",
+			before: "\t//nolint:gosec // Controlled path\n\tb.SkipNow()\n",
+			after:  "b.SkipNow()\n//nolint:gosec // Controlled path\n",
+		},
+		{
+			name:   "duplicated line is new",
+			path:   "a.go",
+			before: "//nolint:mnd\n",
+			after:  "//nolint:mnd\n//nolint:mnd\n",
+			want:   []string{"//nolint: //nolint:mnd"},
+		},
+		{
+			name:  "python",
+			path:  "tests/test_api.py",
+			after: "import os  # noqa: F401\nx = y  # type: ignore[attr-defined]\n@pytest.mark.skipif(True, reason=\"\")\n",
+			want: []string{
+				"# noqa: import os  # noqa: F401",
+				"# type: ignore: x = y  # type: ignore[attr-defined]",
+				`@pytest.mark.skip: @pytest.mark.skipif(True, reason="")`,
+			},
+		},
+		{
+			name:  "javascript",
+			path:  "src/app.test.tsx",
+			after: "// eslint-disable-next-line no-console\nit.skip('works', () => {})\nxit('later', () => {})\n",
+			want: []string{
+				"eslint-disable: // eslint-disable-next-line no-console",
+				"skipped test: it.skip('works', () => {})",
+				"skipped test: xit('later', () => {})",
+			},
+		},
+		{
+			name:  "rust",
+			path:  "src/lib.rs",
+			after: "#[allow(dead_code)]\n#[ignore]\n#![allow(unused)]\n",
+			want:  []string{"#[allow]: #[allow(dead_code)]", "#[ignore]: #[ignore]", "#[allow]: #![allow(unused)]"},
+		},
+		{
+			name:  "patterns of other languages are ignored",
+			path:  "main.go",
+			after: "// # noqa is a flake8 comment\nx := y // type: ignore\n",
+		},
+		{
+			name:  "documentation is not checked",
+			path:  "README.md",
+			after: "Add `//nolint:errcheck` or `t.Skip()`\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, shortcut := range FindShortcuts(tt.path, tt.before, tt.after) {
+				got = append(got, shortcut.Rule+": "+shortcut.Line)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("FindShortcuts() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRunValidateHook_Suppressions(t *testing.T) {
+	const skipEdit = `{"hook_event_name":"PostToolUse","tool_name":"Edit","tool_input":` +
+		`{"file_path":"/project/pkg_test.go","old_string":"func TestA(t *testing.T) {",` +
+		`"new_string":"func TestA(t *testing.T) {\n\tt.Skip(\"flaky\")"}}`
+	const write = `{"hook_event_name":"PostToolUse","tool_name":"Write","tool_input":{"file_path":"/project/main.go"}}`
+
+	tests := []struct {
+		name       string
+		input      string
+		severity   string
+		disabled   bool
+		lintFails  bool
+		wantExit   int
+		wantStderr []string
+		wantStdout string
+	}{
+		{
+			name:       "new skip blocks",
+			input:      skipEdit,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: []string{"BLOCKING", "pkg_test.go silences checks", `t.Skip: t.Skip("flaky")`},
+		},
+		{
+			name:       "warn policy does not block",
+			input:      skipEdit,
+			severity:   config.SeverityWarn,
+			wantStdout: "ADVISORY",
+		},
+		{
+			name:       "merged with failing lint",
+			input:      strings.Replace(skipEdit, "pkg_test.go", "main.go", 1),
+			lintFails:  true,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: []string{"to fix lint failures", "main.go silences checks"},
+		},
+		{
+			name:       "write compares with git HEAD",
+			input:      write,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: []string{"//nolint: x := f() //nolint:errcheck"},
+		},
+		{
+			name:       "disabled",
+			input:      skipEdit,
+			disabled:   true,
+			wantExit:   ExitCodeShowMessage,
+			wantStderr: []string{"Validations pass"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			files := newMemFiles(testDeps)
+			files.files["/project/main.go"] = []byte("package main\n\nfunc main() {\n\tx := f() //nolint:errcheck\n}\n")
+			setupQueueProject(testDeps, func() bool { return tt.lintFails })
+			projectRunner := testDeps.MockRunner.runContextFunc
+			testDeps.MockRunner.runContextFunc = func(ctx context.Context, dir, name string, args ...string) (
+				*CommandOutput, error) {
+				if name == "git" && strings.Join(args, " ") == "show HEAD:./main.go" {
+					return &CommandOutput{Stdout: []byte("package main\n\nfunc main() {\n\tx := f()\n}\n")}, nil
+				}
+				if name == "git" {
+					return nil, errors.New("not a git repository")
+				}
+				return projectRunner(ctx, dir, name, args...)
+			}
+			testDeps.MockInput.readAllFunc = func() ([]byte, error) { return []byte(tt.input), nil }
+
+			cfg := &config.ValidateConfig{
+				ValidateOptions: config.ValidateOptions{
+					Suppressions: config.SuppressionConfig{Enabled: !tt.disabled},
+					Severity:     config.SeverityConfig{Suppressions: tt.severity},
+				},
+				TimeoutSeconds: 10,
+			}
+			exitCode := RunValidateHookWithConfig(context.Background(), false, cfg, nil, testDeps.Dependencies)
+			if exitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExit)
+			}
+			stderr := testDeps.MockStderr.String()
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("stderr missing %q:\n%s", want, stderr)
+				}
+			}
+			if tt.wantStdout != "" && !strings.Contains(testDeps.MockStdout.String(), tt.wantStdout) {
+				t.Errorf("stdout missing %q:\n%s", tt.wantStdout, testDeps.MockStdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxListedTestFailures caps how many failing tests a message lists.
+const maxListedTestFailures = 5
+
+// maxFailureMessage caps the length of a failure's assertion message.
+const maxFailureMessage = 160
+
+var (
+	// goFailPattern matches "--- FAIL: TestName (0.01s)", indented for subtests.
+	goFailPattern = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	// goRunPattern matches "=== RUN   TestName" in verbose output.
+	goRunPattern = regexp.MustCompile(`^=== (?:RUN|CONT|PAUSE)\s+(\S+)`)
+	// goLogPattern matches "    foo_test.go:42: message" logged by a test.
+	goLogPattern = regexp.MustCompile(`^\s+([^\s:]+\.go):(\d+): (.*)$`)
+	// pytestSummaryPattern matches "FAILED path::test - message" in pytest's short summary.
+	pytestSummaryPattern = regexp.MustCompile(`^FAILED (\S+?)::(\S+)(?: - (.*))?$`)
+	// pytestReportPattern matches the line pytest prints after writing a JUnit report.
+	pytestReportPattern = regexp.MustCompile(`generated xml file: (\S+)`)
+	// cargoPanicPattern matches "thread 'name' panicked at src/lib.rs:10:5:" (Rust 1.73+).
+	cargoPanicPattern = regexp.MustCompile(`^thread '([^']+)' panicked at ([^:]+):(\d+):\d+:$`)
+	// cargoLegacyPanicPattern matches "thread 'name' panicked at 'message', src/lib.rs:10:5".
+	cargoLegacyPanicPattern = regexp.MustCompile(`^thread '([^']+)' panicked at '(.*)', ([^:]+):(\d+):\d+$`)
+	// ansiPattern matches terminal color codes in reporter messages.
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// TestFailure is a single failing test.
+type TestFailure struct {
+	Name    string
+	File    string
+	Line    int
+	Message string
+}
+
+// String formats the failure as "Name (file:line): message".
+func (f TestFailure) String() string {
+	s := f.Name
+	switch {
+	case f.File != "" && f.Line > 0:
+		s += fmt.Sprintf(" (%s:%d)", f.File, f.Line)
+	case f.File != "":
+		s += " (" + f.File + ")"
+	}
+	if f.Message != "" {
+		s += ": " + f.Message
+	}
+	return s
+}
+
+// ParseTestFailures extracts failing tests from the output of a test command.
+// It understands go test (plain and -json), pytest's short summary and JUnit
+// reports, jest and vitest JSON reporters, and cargo test. JUnit reports that
+// pytest says it wrote are read through fs. Paths are resolved against
+// workingDir and made relative to projectRoot.
+func ParseTestFailures(output, workingDir, projectRoot string, fs FileSystem) []TestFailure {
+	parsers := []func(string) []TestFailure{
+		parseGoTestJSON,
+		parseGoTest,
+		parseJestJSON,
+		parseJUnitXML,
+		func(output string) []TestFailure { return parsePytestReport(output, workingDir, fs) },
+		parsePytestSummary,
+		parseCargoTest,
+	}
+	for _, parse := range parsers {
+		failures := parse(output)
+		if len(failures) == 0 {
+			continue
+		}
+		for i := range failures {
+			failures[i].File = relativeTo(failures[i].File, workingDir, projectRoot)
+			failures[i].Message = firstLine(failures[i].Message)
+		}
+		return failures
+	}
+	return nil
+}
+
+// relativeTo resolves file against workingDir and makes it relative to
+// projectRoot. Bare file names, as go test prints them relative to an unknown
+// package directory, are kept as they are.
+func relativeTo(file, workingDir, projectRoot string) string {
+	if !strings.Contains(file, "/") {
+		return file
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(workingDir, file)
+	}
+	if rel, err := filepath.Rel(projectRoot, file); err == nil && !strings.HasPrefix(rel, "..") {
+		file = rel
+	}
+	return filepath.ToSlash(file)
+}
+
+// firstLine returns the first non-empty line of a message without color codes, shortened if long.
+func firstLine(message string) string {
+	for line := range strings.SplitSeq(ansiPattern.ReplaceAllString(message, ""), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > maxFailureMessage {
+			line = string(runes[:maxFailureMessage]) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// goTestEvent is a line of `go test -json` output.
+type goTestEvent struct {
+	Action string `json:"Action"`
+	Output string `json:"Output"`
+}
+
+// parseGoTestJSON reassembles the text output of `go test -json` and parses it.
+func parseGoTestJSON(output string) []TestFailure {
+	var text strings.Builder
+	for line := range strings.SplitSeq(output, "\n") {
+		if !strings.HasPrefix(line, `{"`) {
+			continue
+		}
+		var event goTestEvent
+		if err := json.Unmarshal([]byte(line), &event); err == nil && event.Action == "output" {
+			text.WriteString(event.Output)
+		}
+	}
+	if text.Len() == 0 {
+		return nil
+	}
+	return parseGoTest(text.String())
+}
+
+// parseGoTest parses plain go test output. Log lines follow "--- FAIL" in
+// normal mode and precede it in verbose mode, so the first log line of the
+// most recent test is its location and message either way.
+func parseGoTest(output string) []TestFailure {
+	var failed []string
+	logs := make(map[string]TestFailure)
+	current := ""
+
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if match := goRunPattern.FindStringSubmatch(line); match != nil {
+			current = match[1]
+			continue
+		}
+		if match := goFailPattern.FindStringSubmatch(line); match != nil {
+			current = match[1]
+			failed = append(failed, current)
+			continue
+		}
+		if match := goLogPattern.FindStringSubmatch(line); match != nil && current != "" {
+			if _, seen := logs[current]; !seen {
+				lineNum, _ := strconv.Atoi(match[2])
+				logs[current] = TestFailure{File: match[1], Line: lineNum, Message: match[3]}
+			}
+		}
+	}
+
+	var failures []TestFailure
+	for _, name := range failed {
+		// A test fails when one of its subtests does; the subtest is the one worth naming
+		if hasFailedSubtest(failed, name) {
+			continue
+		}
+		failure := logs[name]
+		failure.Name = name
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// hasFailedSubtest reports whether a subtest of name failed.
+func hasFailedSubtest(failed []string, name string) bool {
+	for _, other := range failed {
+		if strings.HasPrefix(other, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// jestReport is the output of jest's and vitest's JSON reporters.
+type jestReport struct {
+	TestResults []struct {
+		Name             string `json:"name"`
+		AssertionResults []struct {
+			FullName        string   `json:"fullName"`
+			Status          string   `json:"status"`
+			FailureMessages []string `json:"failureMessages"`
+			Location        *struct {
+				Line int `json:"line"`
+			} `json:"location"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+// parseJestJSON parses a jest or vitest JSON report, which may follow other output.
+func parseJestJSON(output string) []TestFailure {
+	start := strings.Index(output, `{"num`)
+	if start < 0 {
+		return nil
+	}
+	var report jestReport
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&report); err != nil {
+		return nil
+	}
+
+	var failures []TestFailure
+	for _, file := range report.TestResults {
+		for _, assertion := range file.AssertionResults {
+			if assertion.Status != "failed" {
+				continue
+			}
+			failure := TestFailure{Name: assertion.FullName, File: file.Name}
+			if assertion.Location != nil {
+				failure.Line = assertion.Location.Line
+			}
+			if len(assertion.FailureMessages) > 0 {
+				failure.Message = assertion.FailureMessages[0]
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// junitTestSuites is the root of a JUnit XML report. Some tools write a
+// single testsuite as the root instead.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is a suite of test cases in a JUnit XML report.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr,omitempty"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a test case in a JUnit XML report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr,omitempty"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+}
+
+// junitFailure is the failure or error of a JUnit test case.
+type junitFailure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJUnitXML parses a JUnit XML report embedded in the output.
+func parseJUnitXML(output string) []TestFailure {
+	start := strings.Index(output, "<testsuite")
+	if start < 0 {
+		return nil
+	}
+	data := []byte(output[start:])
+
+	var suites []junitTestSuite
+	if strings.HasPrefix(output[start:], "<testsuites") {
+		var report junitTestSuites
+		if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&report); err != nil {
+			return nil
+		}
+		suites = report.Suites
+	} else {
+		var suite junitTestSuite
+		if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&suite); err != nil {
+			return nil
+		}
+		suites = []junitTestSuite{suite}
+	}
+
+	var failures []TestFailure
+	for _, suite := range suites {
+		for _, tc := range suite.Cases {
+			problem := tc.Failure
+			if problem == nil {
+				problem = tc.Error
+			}
+			if problem == nil {
+				continue
+			}
+			message := problem.Message
+			if message == "" {
+				message = problem.Text
+			}
+			name := tc.Name
+			if tc.Classname != "" {
+				name = tc.Classname + "." + tc.Name
+			}
+			failures = append(failures, TestFailure{Name: name, File: tc.File, Line: tc.Line, Message: message})
+		}
+	}
+	return failures
+}
+
+// parsePytestReport reads the JUnit report pytest wrote with --junitxml.
+func parsePytestReport(output, workingDir string, fs FileSystem) []TestFailure {
+	match := pytestReportPattern.FindStringSubmatch(output)
+	if match == nil || fs == nil {
+		return nil
+	}
+	path := match[1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseJUnitXML(string(data))
+}
+
+// parsePytestSummary parses the "short test summary info" section of pytest.
+func parsePytestSummary(output string) []TestFailure {
+	var failures []TestFailure
+	for line := range strings.SplitSeq(output, "\n") {
+		match := pytestSummaryPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		failures = append(failures, TestFailure{Name: match[2], File: match[1], Message: match[3]})
+	}
+	return failures
+}
+
+// parseCargoTest parses the panics of failed tests in cargo test output.
+func parseCargoTest(output string) []TestFailure {
+	var failures []TestFailure
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if match := cargoLegacyPanicPattern.FindStringSubmatch(line); match != nil {
+			lineNum, _ := strconv.Atoi(match[4])
+			failures = append(failures, TestFailure{Name: match[1], File: match[3], Line: lineNum, Message: match[2]})
+			continue
+		}
+		match := cargoPanicPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		lineNum, _ := strconv.Atoi(match[3])
+		failure := TestFailure{Name: match[1], File: match[2], Line: lineNum}
+		// The panic message follows on the next line
+		if i+1 < len(lines) {
+			failure.Message = lines[i+1]
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// testFailureList lists the failing tests of a failed test run.
+func testFailureList(failures []TestFailure) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d failing:", len(failures))
+	for i, failure := range failures {
+		if i == maxListedTestFailures {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(failures)-i)
+			break
+		}
+		b.WriteString("\n  " + failure.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseTestFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		files  map[string]string
+		want   []string
+	}{
+		{
+			name: "go test",
+			output: `--- FAIL: TestAdd (0.00s)
+    math_test.go:42: expected 2 got 3
+    math_test.go:43: second problem
+--- FAIL: TestTable (0.00s)
+    --- FAIL: TestTable/negative (0.00s)
+        table_test.go:17: got -1
+FAIL
+FAIL	example.com/math	0.002s`,
+			want: []string{
+				"TestAdd (math_test.go:42): expected 2 got 3",
+				"TestTable/negative (table_test.go:17): got -1",
+			},
+		},
+		{
+			name: "go test verbose",
+			output: `=== RUN   TestAdd
+    math_test.go:42: expected 2 got 3
+--- FAIL: TestAdd (0.00s)
+=== RUN   TestSub
+--- PASS: TestSub (0.00s)`,
+			want: []string{"TestAdd (math_test.go:42): expected 2 got 3"},
+		},
+		{
+			name: "go test json",
+			output: `{"Action":"run","Package":"example.com/math","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/math","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/math","Test":"TestAdd","Output":"    math_test.go:42: expected 2 got 3\n"}
+{"Action":"output","Package":"example.com/math","Test":"TestAdd","Output":"--- FAIL: TestAdd (0.00s)\n"}
+{"Action":"fail","Package":"example.com/math","Test":"TestAdd"}`,
+			want: []string{"TestAdd (math_test.go:42): expected 2 got 3"},
+		},
+		{
+			name: "pytest summary",
+			output: `=========================== short test summary info ============================
+FAILED tests/test_math.py::test_add - assert 2 == 3
+FAILED tests/test_math.py::TestCalc::test_div
+========================= 2 failed, 3 passed in 0.12s ==========================`,
+			want: []string{
+				"test_add (tests/test_math.py): assert 2 == 3",
+				"TestCalc::test_div (tests/test_math.py)",
+			},
+		},
+		{
+			name:   "pytest junit report",
+			output: "- generated xml file: /project/report.xml -\n1 failed",
+			files: map[string]string{"/project/report.xml": `<?xml version="1.0" encoding="utf-8"?>
+<testsuites><testsuite name="pytest" tests="2" failures="1">
+<testcase classname="tests.test_math" name="test_add" file="tests/test_math.py" line="9">
+<failure message="assert 2 == 3">def test_add(): ...</failure></testcase>
+<testcase classname="tests.test_math" name="test_sub" file="tests/test_math.py" line="12"/>
+</testsuite></testsuites>`},
+			want: []string{"tests.test_math.test_add (tests/test_math.py:9): assert 2 == 3"},
+		},
+		{
+			name: "jest json",
+			output: `> jest --json
+{"numFailedTests":1,"testResults":[{"name":"/project/src/sum.test.ts","assertionResults":[
+{"fullName":"sum adds","status":"failed","location":{"line":5,"column":3},
+"failureMessages":["\u001b[2mexpect(\u001b[22mreceived\u001b[2m).toBe(\u001b[22mexpected\u001b[2m)\nExpected: 3"]},
+{"fullName":"sum subtracts","status":"passed","failureMessages":[]}]}]}`,
+			want: []string{"sum adds (src/sum.test.ts:5): expect(received).toBe(expected)"},
+		},
+		{
+			name: "cargo test",
+			output: `running 2 tests
+test tests::adds ... FAILED
+
+failures:
+
+---- tests::adds stdout ----
+thread 'tests::adds' panicked at src/lib.rs:10:9:
+assertion ` + "`left == right`" + ` failed
+  left: 4
+ right: 5
+
+failures:
+    tests::adds`,
+			want: []string{"tests::adds (src/lib.rs:10): assertion `left == right` failed"},
+		},
+		{
+			name:   "unrecognized output",
+			output: "make: *** [test] Error 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDeps := createTestDependencies()
+			testDeps.MockFS.readFileFunc = func(name string) ([]byte, error) {
+				if content, ok := tt.files[name]; ok {
+					return []byte(content), nil
+				}
+				return nil, os.ErrNotExist
+			}
+
+			failures := ParseTestFailures(tt.output, "/project", "/project", testDeps.MockFS)
+			got := make([]string, len(failures))
+			for i, failure := range failures {
+				got[i] = failure.String()
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("ParseTestFailures() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExecuteValidations_ListsFailingTests(t *testing.T) {
+	testDeps := createTestDependencies()
+	testDeps.MockFS.statFunc = func(path string) (os.FileInfo, error) {
+		if path == "/project/Makefile" {
+			return mockFileInfo{name: "Makefile"}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	var output strings.Builder
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		output.WriteString("--- FAIL: Test" + name + " (0.00s)\n    x_test.go:1: broken\n")
+	}
+	testDeps.MockRunner.runContextFunc = func(_ context.Context, _, name string, args ...string) (*CommandOutput, error) {
+		if name == "make" && args[0] == "test" {
+			return &CommandOutput{Stdout: []byte(output.String())}, errors.New("exit status 1")
+		}
+		return &CommandOutput{}, nil
+	}
+
+	executor := NewParallelValidateExecutor("/project", 10, false, nil, testDeps.Dependencies)
+	result, err := executor.ExecuteValidations(context.Background(), "/project", "/project")
+	if err != nil {
+		t.Fatalf("ExecuteValidations() error = %v", err)
+	}
+	if len(result.TestResult.TestFailures) != 7 {
+		t.Fatalf("TestFailures = %v, want 7 failures", result.TestResult.TestFailures)
+	}
+
+	message := result.FormatMessage()
+	for _, want := range []string{"7 failing:", "TestA (x_test.go:1): broken", "... and 2 more"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("FormatMessage() = %q, want to contain %q", message, want)
+		}
+	}
+	if strings.Contains(message, "TestF") {
+		t.Errorf("FormatMessage() = %q, should cap the list", message)
+	}
+}
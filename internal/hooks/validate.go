@@ -2,11 +2,18 @@ package hooks
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/Veraticus/cc-tools/internal/config"
+	debuglog "github.com/Veraticus/cc-tools/internal/debug"
 	"github.com/Veraticus/cc-tools/internal/output"
+	"github.com/Veraticus/cc-tools/internal/sandbox"
 	"github.com/Veraticus/cc-tools/internal/shared"
 )
 
@@ -24,6 +31,25 @@ type ValidationResult struct {
 	Message  string
 	Command  *DiscoveredCommand
 	Error    error
+	// Output is the combined stdout and stderr of the command.
+	Output string
+	// Cached is set when the result was reused from an identical tree.
+	Cached bool
+	// Baselined counts lint findings ignored because they are in the project baseline.
+	Baselined int
+	// NewDiagnostics lists lint findings that are not in the project baseline.
+	NewDiagnostics []Diagnostic
+	// UnparsedLint lists lint output lines that are not findings, which keep
+	// a baselined lint failure in place.
+	UnparsedLint []string
+	// LogPath is the run log holding the full output of the command, if one was written.
+	LogPath string
+	// Duration is how long the command ran. It is zero for cached results.
+	Duration time.Duration
+	// FailureReason is the executor's failure reason, such as a sandbox violation.
+	FailureReason string
+	// TestFailures lists the failing tests parsed from a failed test run.
+	TestFailures []TestFailure
 }
 
 // ValidateExecutor executes parallel validation commands.
@@ -36,57 +62,218 @@ type ValidateResult struct {
 	LintResult *ValidationResult
 	TestResult *ValidationResult
 	BothPassed bool
+	// Severity decides how failures are reported. The zero value blocks on every failure.
+	Severity config.SeverityConfig
+	// Autofix describes the fixer run after a lint failure, if any.
+	Autofix *AutofixResult
 }
 
 // FormatMessage returns the appropriate user message based on validation results.
 func (vr *ValidateResult) FormatMessage() string {
+	message := vr.formatOutcome()
+	if message == "" {
+		return ""
+	}
+
 	formatter := output.NewHookFormatter()
+	if findings := vr.newFindings(); findings != "" {
+		message += "\n" + formatter.FormatError(findings)
+	}
+	if test := vr.TestResult; test != nil && !test.Success && len(test.TestFailures) > 0 &&
+		vr.Severity.Get(config.CheckTest) != config.SeveritySilent {
+		message += "\n" + formatter.FormatError(testFailureList(test.TestFailures))
+	}
+	for _, c := range vr.checks() {
+		result := c.result
+		if result != nil && !result.Success && result.LogPath != "" &&
+			vr.Severity.Get(c.check) != config.SeveritySilent {
+			message += "\n" + formatter.FormatError(fmt.Sprintf("Full %s output: %s", result.Type, result.LogPath))
+		}
+	}
+	for _, c := range vr.checks() {
+		if note := sandboxNote(c.result); note != "" && vr.Severity.Get(c.check) != config.SeveritySilent {
+			message += "\n" + formatter.FormatWarning(note)
+		}
+	}
+	if lint := vr.LintResult; lint != nil && lint.Baselined > 0 {
+		message += "\n" + formatter.FormatWarning(fmt.Sprintf(
+			"%d known lint finding(s) ignored via %s", lint.Baselined, BaselineFile))
+	}
+	if fix := vr.Autofix; fix != nil && len(fix.Modified) > 0 {
+		message += "\n" + formatter.FormatWarning(fmt.Sprintf(
+			"🔧 '%s' modified %s. Re-read before editing them again.",
+			fix.Command.String(), strings.Join(fix.Modified, ", ")))
+	}
+	if note := vr.cacheNote(); note != "" {
+		message += "\n" + formatter.FormatWarning(note)
+	}
+	return message
+}
+
+// sandboxNote explains a failure caused by the sandbox rather than the command.
+func sandboxNote(result *ValidationResult) string {
+	if result == nil || result.Success {
+		return ""
+	}
+	switch result.FailureReason {
+	case FailureReasonSandboxViolation:
+		return fmt.Sprintf("🔒 '%s' was denied a write by the sandbox. "+
+			"If it needs the path, add it to validate.sandbox.allow_write.", result.Command.String())
+	case FailureReasonSandboxUnavailable:
+		_, reason, _ := strings.Cut(result.Output, sandbox.UnavailableMarker)
+		reason, _, _ = strings.Cut(strings.TrimSpace(reason), "\n")
+		return fmt.Sprintf("🔒 '%s' could not be sandboxed: %s. "+
+			"Disable validate.sandbox on systems without Landlock.", result.Command.String(), reason)
+	default:
+		return ""
+	}
+}
+
+// newFindings lists lint findings that are not in the project baseline, and
+// the output lines that are not findings at all.
+func (vr *ValidateResult) newFindings() string {
+	lint := vr.LintResult
+	if lint == nil || lint.Success || vr.Severity.Get(config.CheckLint) == config.SeveritySilent {
+		return ""
+	}
+
+	var sections []string
+	if len(lint.NewDiagnostics) > 0 {
+		lines := make([]string, len(lint.NewDiagnostics))
+		for i, d := range lint.NewDiagnostics {
+			lines[i] = d.String()
+		}
+		sections = append(sections, listLines(fmt.Sprintf("New lint findings (not in %s):", BaselineFile), lines))
+	}
+	if len(lint.UnparsedLint) > 0 {
+		sections = append(sections, listLines("Lint output that is not a finding:", lint.UnparsedLint))
+	}
+	return strings.Join(sections, "\n")
+}
 
-	// Both passed
-	if vr.BothPassed {
-		return formatter.FormatValidationPass()
+// listLines formats a heading followed by at most maxListedDiagnostics indented lines.
+func listLines(heading string, lines []string) string {
+	var b strings.Builder
+	b.WriteString(heading)
+	for i, line := range lines {
+		if i == maxListedDiagnostics {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(lines)-i)
+			break
+		}
+		b.WriteString("\n  " + line)
 	}
+	return b.String()
+}
 
-	// Determine what failed
-	lintFailed := vr.LintResult != nil && !vr.LintResult.Success
-	testFailed := vr.TestResult != nil && !vr.TestResult.Success
+// formatOutcome describes which validations passed or failed.
+func (vr *ValidateResult) formatOutcome() string {
+	// Both passed, or only silent checks failed
+	if vr.reportsPass() {
+		return output.NewHookFormatter().FormatValidationPass()
+	}
+
+	var lines []string
+	for _, severity := range []string{config.SeverityBlock, config.SeverityWarn} {
+		if line := vr.formatFailures(severity); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatFailures describes the failed validations of one severity.
+func (vr *ValidateResult) formatFailures(severity string) string {
+	lintFailed := vr.failedWith(vr.LintResult, config.CheckLint, severity)
+	testFailed := vr.failedWith(vr.TestResult, config.CheckTest, severity)
 
 	// Both failed
 	if lintFailed && testFailed {
 		lintCmd := vr.LintResult.Command.String()
 		testCmd := vr.TestResult.Command.String()
-		return formatter.FormatBlockingError(
-			"⛔ BLOCKING: Lint and test failures. Run 'cd %s && %s' and '%s'",
+		return formatFailure(severity, "Lint and test failures. Run 'cd %s && %s' and '%s'",
 			vr.LintResult.Command.WorkingDir, lintCmd, testCmd)
 	}
 
 	// Only lint failed
 	if lintFailed {
 		cmdStr := vr.LintResult.Command.String()
-		return formatter.FormatBlockingError(
-			"⛔ BLOCKING: Run 'cd %s && %s' to fix lint failures",
+		return formatFailure(severity, "Run 'cd %s && %s' to fix lint failures",
 			vr.LintResult.Command.WorkingDir, cmdStr)
 	}
 
 	// Only test failed
 	if testFailed {
 		cmdStr := vr.TestResult.Command.String()
-		return formatter.FormatBlockingError(
-			"⛔ BLOCKING: Run 'cd %s && %s' to fix test failures",
+		return formatFailure(severity, "Run 'cd %s && %s' to fix test failures",
 			vr.TestResult.Command.WorkingDir, cmdStr)
 	}
 
-	// Neither command was found (both nil results)
+	// Nothing of this severity failed, or neither command was found
 	return ""
 }
 
+// failedWith reports whether a validation ran and failed, and its check has the given severity.
+func (vr *ValidateResult) failedWith(result *ValidationResult, check, severity string) bool {
+	return result != nil && !result.Success && vr.Severity.Get(check) == severity
+}
+
+// checkResult pairs a validation result with the check that sets its severity.
+type checkResult struct {
+	check  string
+	result *ValidationResult
+}
+
+// checks returns the lint and test results with their checks.
+func (vr *ValidateResult) checks() []checkResult {
+	return []checkResult{{config.CheckLint, vr.LintResult}, {config.CheckTest, vr.TestResult}}
+}
+
+// hasFailures reports whether a validation with the given severity failed.
+func (vr *ValidateResult) hasFailures(severity string) bool {
+	for _, c := range vr.checks() {
+		if vr.failedWith(c.result, c.check, severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportsPass reports whether the result is shown as a pass: every validation
+// passed, or the failures are all silent.
+func (vr *ValidateResult) reportsPass() bool {
+	return vr.BothPassed || !vr.hasFailures(config.SeverityBlock) && !vr.hasFailures(config.SeverityWarn)
+}
+
+// cacheNote labels results that were reused from the result cache.
+func (vr *ValidateResult) cacheNote() string {
+	var cached []string
+	for _, result := range []*ValidationResult{vr.LintResult, vr.TestResult} {
+		if result != nil && result.Cached {
+			cached = append(cached, string(result.Type))
+		}
+	}
+	if len(cached) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("♻ Cached %s result from an identical tree (not re-run)", strings.Join(cached, " and "))
+}
+
 // ParallelValidateExecutor implements ValidateExecutor with parallel execution.
 type ParallelValidateExecutor struct {
-	discovery  *CommandDiscovery
-	executor   *CommandExecutor
-	timeout    int
-	debug      bool
-	skipConfig *SkipConfig
+	discovery   *CommandDiscovery
+	executor    *CommandExecutor
+	timeout     int
+	debug       bool
+	skipConfig  *SkipConfig
+	cache       *ResultCache
+	tree        string
+	baseline    *Baseline
+	runLog      *RunLog
+	clock       Clock
+	fixer       *Autofixer
+	fixFile     string
+	impact      *ImpactSelector
+	editedFiles []string
 }
 
 // NewParallelValidateExecutor creates a new parallel validate executor.
@@ -106,9 +293,39 @@ func NewParallelValidateExecutor(
 		timeout:    timeout,
 		debug:      debug,
 		skipConfig: skipConfig,
+		clock:      deps.Clock,
 	}
 }
 
+// EnableCache makes the executor reuse results of commands that already ran on an identical tree.
+func (pve *ParallelValidateExecutor) EnableCache(cache *ResultCache) {
+	pve.cache = cache
+}
+
+// EnableBaseline makes lint pass when all of its findings are in the baseline.
+func (pve *ParallelValidateExecutor) EnableBaseline(baseline *Baseline) {
+	pve.baseline = baseline
+}
+
+// EnableRunLog makes the executor write the full output of every command to a run log.
+func (pve *ParallelValidateExecutor) EnableRunLog(runLog *RunLog) {
+	pve.runLog = runLog
+}
+
+// EnableAutofix makes the executor run the fixer for filePath when lint fails,
+// then lint again if the fixer changed anything.
+func (pve *ParallelValidateExecutor) EnableAutofix(fixer *Autofixer, filePath string) {
+	pve.fixer = fixer
+	pve.fixFile = filePath
+}
+
+// EnableImpact makes the executor test the Go packages affected by edits to
+// filePaths instead of running the project's test command.
+func (pve *ParallelValidateExecutor) EnableImpact(impact *ImpactSelector, filePaths ...string) {
+	pve.impact = impact
+	pve.editedFiles = filePaths
+}
+
 // ExecuteValidations discovers and runs lint and test commands in parallel.
 func (pve *ParallelValidateExecutor) ExecuteValidations(
 	ctx context.Context,
@@ -122,8 +339,22 @@ func (pve *ParallelValidateExecutor) ExecuteValidations(
 		return &ValidateResult{BothPassed: true}, nil
 	}
 
+	// Outside a git work tree the content cannot be hashed, so nothing is cached
+	pve.tree = ""
+	if pve.cache != nil {
+		pve.tree, _ = pve.cache.TreeHash(ctx, fileDir)
+	}
+
 	// Execute commands in parallel
 	result := pve.executeParallel(ctx, lintCmd, testCmd)
+	pve.applyBaseline(result)
+	if test := result.TestResult; test != nil && !test.Success {
+		test.TestFailures = ParseTestFailures(test.Output, test.Command.WorkingDir,
+			pve.discovery.projectRoot, pve.executor.deps.FS)
+	}
+	if pve.fixer != nil && result.LintResult != nil && !result.LintResult.Success && ctx.Err() == nil {
+		pve.autofix(ctx, fileDir, result)
+	}
 
 	// Determine overall success
 	result.BothPassed = pve.checkSuccess(result)
@@ -131,6 +362,35 @@ func (pve *ParallelValidateExecutor) ExecuteValidations(
 	return result, nil
 }
 
+// applyBaseline ignores lint findings that are in the baseline, if one is enabled.
+func (pve *ParallelValidateExecutor) applyBaseline(result *ValidateResult) {
+	if pve.baseline != nil {
+		applyBaseline(result.LintResult, pve.discovery.projectRoot, pve.baseline)
+	}
+}
+
+// autofix runs the fixer after a lint failure and lints again if it changed any file.
+func (pve *ParallelValidateExecutor) autofix(ctx context.Context, fileDir string, result *ValidateResult) {
+	fix, err := pve.fixer.Fix(ctx, pve.fixFile)
+	if err != nil {
+		if pve.debug && !errors.Is(err, ErrNoFixer) {
+			_, _ = fmt.Fprintf(pve.fixer.deps.Stderr, "Error running fixer: %v\n", err)
+		}
+		return
+	}
+	result.Autofix = fix
+	if len(fix.Modified) == 0 {
+		return
+	}
+
+	// The fixer changed the tree, so the earlier lint result no longer applies
+	if pve.cache != nil {
+		pve.tree, _ = pve.cache.TreeHash(ctx, fileDir)
+	}
+	result.LintResult = pve.executeCommand(ctx, result.LintResult.Command, CommandTypeLint)
+	pve.applyBaseline(result)
+}
+
 // discoverCommands discovers lint and test commands based on skip configuration.
 func (pve *ParallelValidateExecutor) discoverCommands(
 	ctx context.Context,
@@ -143,7 +403,10 @@ func (pve *ParallelValidateExecutor) discoverCommands(
 	if !skipLint {
 		lintCmd, _ = pve.discovery.DiscoverCommand(ctx, CommandTypeLint, fileDir)
 	}
-	if !skipTest {
+	if !skipTest && pve.impact != nil {
+		testCmd = pve.impact.TestCommand(ctx, pve.editedFiles...)
+	}
+	if !skipTest && testCmd == nil {
 		testCmd, _ = pve.discovery.DiscoverCommand(ctx, CommandTypeTest, fileDir)
 	}
 
@@ -200,14 +463,62 @@ func (pve *ParallelValidateExecutor) executeCommand(
 	cmd *DiscoveredCommand,
 	cmdType CommandType,
 ) *ValidationResult {
+	started := pve.clock.Now()
+	if pve.tree != "" {
+		if entry, ok := pve.cache.Lookup(cmd, pve.tree); ok {
+			result := &ValidationResult{
+				Type:     cmdType,
+				Success:  entry.Success,
+				ExitCode: entry.ExitCode,
+				Command:  cmd,
+				Output:   entry.Output,
+				Cached:   true,
+			}
+			pve.writeRunLog(runLogEntry{cmd: cmd, cmdType: cmdType, started: started, result: result})
+			return result
+		}
+	}
+
 	execResult := pve.executor.Execute(ctx, cmd)
 
-	return &ValidationResult{
+	result := &ValidationResult{
 		Type:     cmdType,
 		Success:  execResult.Success,
 		ExitCode: execResult.ExitCode,
 		Command:  cmd,
 		Error:    execResult.Error,
+		Output:   execResult.Stdout + execResult.Stderr,
+		Duration: pve.clock.Now().Sub(started),
+
+		FailureReason: execResult.Reason,
+	}
+	pve.writeRunLog(runLogEntry{
+		cmd:      cmd,
+		cmdType:  cmdType,
+		started:  started,
+		duration: result.Duration,
+		result:   result,
+		stdout:   execResult.Stdout,
+		stderr:   execResult.Stderr,
+	})
+
+	// Only definite outcomes are cached; timeouts, cancellations and sandbox
+	// failures say nothing about the tree
+	if pve.tree != "" && execResult.Reason == "" && execResult.ExitCode >= 0 && ctx.Err() == nil {
+		_ = pve.cache.Store(cmd, pve.tree, result)
+	}
+	return result
+}
+
+// writeRunLog records a command run in the run log, if enabled, and notes the log path on the result.
+func (pve *ParallelValidateExecutor) writeRunLog(entry runLogEntry) {
+	if pve.runLog == nil {
+		return
+	}
+	if path, err := pve.runLog.Write(entry); path != "" {
+		entry.result.LogPath = path
+	} else if pve.debug {
+		_, _ = fmt.Fprintf(pve.runLog.deps.Stderr, "Error writing run log: %v\n", err)
 	}
 }
 
@@ -220,7 +531,19 @@ func RunValidateHookWithSkip(
 	skipConfig *SkipConfig,
 	deps *Dependencies,
 ) int {
-	return runValidateHookInternal(ctx, debug, timeoutSecs, cooldownSecs, skipConfig, deps)
+	cfg := &config.ValidateConfig{TimeoutSeconds: timeoutSecs, CooldownSeconds: cooldownSecs}
+	return runValidateHookInternal(ctx, debug, cfg, skipConfig, deps)
+}
+
+// RunValidateHookWithConfig is the main entry point for the validate hook with full configuration.
+func RunValidateHookWithConfig(
+	ctx context.Context,
+	debug bool,
+	cfg *config.ValidateConfig,
+	skipConfig *SkipConfig,
+	deps *Dependencies,
+) int {
+	return runValidateHookInternal(ctx, debug, cfg, skipConfig, deps)
 }
 
 // RunValidateHook is the main entry point for the validate hook.
@@ -231,75 +554,326 @@ func RunValidateHook(
 	cooldownSecs int,
 	deps *Dependencies,
 ) int {
-	return runValidateHookInternal(ctx, debug, timeoutSecs, cooldownSecs, nil, deps)
+	cfg := &config.ValidateConfig{TimeoutSeconds: timeoutSecs, CooldownSeconds: cooldownSecs}
+	return runValidateHookInternal(ctx, debug, cfg, nil, deps)
+}
+
+// loadProjectConfig applies the project's .cc-tools.json, if any, to the validate configuration.
+func loadProjectConfig(
+	cfg *config.ValidateConfig,
+	projectRoot string,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) *config.ValidateConfig {
+	data, err := deps.FS.ReadFile(filepath.Join(projectRoot, config.ProjectConfigFile))
+	if err != nil {
+		return cfg
+	}
+
+	merged, err := cfg.WithProjectOverrides(data)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "loading project config")
+		}
+		return cfg
+	}
+
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("Loaded project config: %s", filepath.Join(projectRoot, config.ProjectConfigFile))
+	}
+	return &merged
 }
 
 // runValidateHookInternal contains the shared logic for running validation.
 func runValidateHookInternal(
 	ctx context.Context,
 	debug bool,
-	timeoutSecs int,
-	cooldownSecs int,
+	cfg *config.ValidateConfig,
 	skipConfig *SkipConfig,
 	deps *Dependencies,
 ) int {
 	if deps == nil {
 		deps = NewDefaultDependencies()
 	}
-
-	// Read and validate input
-	input, err := ReadHookInput(deps.Input)
-	if err != nil {
-		handleInputError(err, debug, deps.Stderr)
-		return 0
+	if cfg == nil {
+		cfg = &config.ValidateConfig{}
 	}
 
-	// Validate event and get file path
-	filePath, shouldProcess := validateHookEvent(input, debug, deps.Stderr)
-	if !shouldProcess {
-		return 0
-	}
+	logger := initLogger(ctx)
+	defer func() {
+		if logger != nil {
+			_ = logger.Close()
+		}
+	}()
+
+	logHookStart(logger, "validate", cfg.TimeoutSeconds, cfg.CooldownSeconds)
 
-	// Check if file should be skipped
-	if shared.ShouldSkipFile(filePath) {
+	// Read and validate input
+	input, filePath, shouldProcess := processHookInput(deps, logger, debug)
+	if !shouldProcess {
+		if input != nil && input.HookEventName == "PreToolUse" && input.IsEditTool() {
+			// Before an edit lands, only the content it writes is checked
+			return checkSecretsBeforeEdit(ctx, input, cfg, deps, logger)
+		}
 		return 0
 	}
 
 	// Find project root
-	fileDir := filepath.Dir(filePath)
-	projectRoot, err := shared.FindProjectRoot(fileDir, nil)
+	root, err := shared.DetectProjectRoot(filepath.Dir(filePath), ProjectRootOptions(cfg.Root, input.CWD), nil)
 	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "finding project root")
+		}
 		if debug {
 			_, _ = fmt.Fprintf(deps.Stderr, "Error finding project root: %v\n", err)
 		}
 		return 0
 	}
 
+	projectRoot := root.Dir
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("Project root: %s (%s: %s)", projectRoot, root.Strategy, root.Reason)
+	}
+
+	cfg = loadProjectConfig(cfg, projectRoot, deps, logger)
+	// The edit itself is checked whether or not validation runs
+	editFindings := []finding{
+		// A header is inserted first so that later checks see the final file
+		checkLicense(ctx, filePath, input, cfg, deps, logger),
+		checkSuppressions(ctx, filePath, input, cfg, deps, logger),
+		checkPlaceholders(ctx, filePath, input, cfg, deps, logger),
+		checkSecrets(ctx, projectRoot, filePath, input, cfg, deps, logger),
+		checkLSP(ctx, projectRoot, filePath, cfg, deps, logger),
+	}
+	editFindings = append(editFindings, checkEditCommands(ctx, projectRoot, filePath, cfg, deps, logger)...)
+
+	// Decide which commands an edit of this file triggers
+	lintFile, testFile := NewFileFilter(projectRoot, cfg.Filters, deps).Triggers(ctx, filePath)
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("File triggers lint: %v, test: %v", lintFile, testFile)
+	}
+	skipped := func(reason string) {
+		record := NewSkippedHistoryRecord(deps.Clock.Now(), input.SessionID, projectRoot, filePath, reason)
+		recordHistory(ctx, cfg.History, record, deps, logger)
+	}
+	if !lintFile && !testFile {
+		skipped(HistorySkipChecks)
+		return reportFindings(deps, editFindings...)
+	}
+	skipConfig = filterSkipConfig(skipConfig, lintFile, testFile)
+	if skipConfig.SkipLint && skipConfig.SkipTest {
+		if logger != nil && logger.IsEnabled() {
+			logger.Log("Both lint and test skipped by the skip registry")
+		}
+		skipped(HistorySkipChecks)
+		return reportFindings(deps, editFindings...)
+	}
+
+	// Newer invocations may signal this one as soon as it holds the lock
+	runCtx, superseded, stopWatching := watchSupersede(ctx, cfg, deps)
+	defer stopWatching()
+
 	// Acquire lock for validate
-	lockMgr := NewLockManager(projectRoot, "validate", cooldownSecs, deps)
-	if !acquireLock(lockMgr, debug, deps.Stderr, nil) {
-		return 0
+	lockMgr := NewLockManagerInDir(cfg.LockDir, projectRoot, "validate", cfg.CooldownSeconds, deps)
+	if !acquireValidateLock(ctx, lockMgr, filePath, skipConfig, cfg, debug, deps, logger) {
+		if cfg.GetOnBusy() == config.OnBusyQueue {
+			skipped(HistorySkipQueued)
+		} else {
+			skipped(HistorySkipBusy)
+		}
+		if slices.ContainsFunc(editFindings, func(f finding) bool { return f.message != "" || f.info != "" }) {
+			// A deferred result stays queued for the next invocation
+			return reportFindings(deps, editFindings...)
+		}
+		return deliverDeferredResult(lockMgr, cfg, deps, logger)
 	}
 	defer func() {
 		_ = lockMgr.Release()
 	}()
+	coalesced := clearQueueState(lockMgr, cfg)
+
+	exitCode, message := runValidation(runCtx, projectRoot, filePath, coalesced, input.SessionID, cfg, debug,
+		skipConfig, deps, logger, editFindings...)
+	if superseded() {
+		// A newer edit is validating the latest tree; this result is stale
+		if logger != nil && logger.IsEnabled() {
+			logger.Log("Run superseded by a newer invocation")
+		}
+		if cfg.Impact.Enabled {
+			// The newer run only knows its own edit
+			for _, file := range append(coalesced, filePath) {
+				_ = lockMgr.MarkPending(file, skipConfig)
+			}
+		}
+		lockMgr.SkipCooldown()
+		return reportFindings(deps, editFindings...)
+	}
+	reportMessage(deps, exitCode, message)
+
+	rerunPending(ctx, lockMgr, cfg, deps, logger, func(pending *PendingRun) (int, string) {
+		queuedFile, queuedSkip := filePath, skipConfig
+		if pending.File != "" {
+			queuedFile = pending.File
+			queuedSkip = &SkipConfig{SkipLint: pending.SkipLint, SkipTest: pending.SkipTest}
+		}
+		return runValidation(ctx, projectRoot, queuedFile, pending.EditedFiles(), input.SessionID, cfg, debug,
+			queuedSkip, deps, logger)
+	})
+
+	return exitCode
+}
+
+// checkEditCommands runs the edit checks that execute project tools, the API
+// diff and the lockfile check, in the environment and sandbox of validation.
+// They cover only the edited file, so they run whether or not validation does.
+func checkEditCommands(
+	ctx context.Context,
+	projectRoot, filePath string,
+	cfg *config.ValidateConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+) []finding {
+	api := cfg.API.Enabled && filepath.Ext(filePath) == ".go"
+	manifest := cfg.Manifests.Enabled && isManifest(filepath.Base(filePath))
+	if !api && !manifest {
+		return nil
+	}
+
+	runDeps, _, err := validationDependencies(ctx, projectRoot, cfg, deps, nil)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "applying sandbox")
+		}
+		return []finding{{
+			message:  formatFailure(config.SeverityBlock, "Validation sandbox failed: %v", err),
+			severity: config.SeverityBlock,
+		}}
+	}
+	return []finding{
+		checkAPI(ctx, projectRoot, filePath, cfg, runDeps, logger),
+		checkManifest(ctx, projectRoot, filePath, cfg, runDeps, logger),
+	}
+}
+
+// runValidation runs lint and test for the edited file's project and returns
+// the exit code and message to report. Coalesced are the files of other edits
+// this run covers, whose impacted packages are tested too. Findings of checks
+// that ran before validation are merged into the result.
+func runValidation(
+	ctx context.Context,
+	projectRoot, filePath string,
+	coalesced []string,
+	sessionID string,
+	cfg *config.ValidateConfig,
+	debug bool,
+	skipConfig *SkipConfig,
+	deps *Dependencies,
+	logger *debuglog.Logger,
+	findings ...finding,
+) (int, string) {
+	// Load the project environment for discovery and execution
+	runDeps, envDiff, err := validationDependencies(ctx, projectRoot, cfg, deps, logger)
+	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "applying sandbox")
+		}
+		return ExitCodeShowMessage, formatFailure(config.SeverityBlock, "Validation sandbox failed: %v", err)
+	}
 
 	// Execute validations in parallel with optional skip configuration
-	validateExecutor := NewParallelValidateExecutor(projectRoot, timeoutSecs, debug, skipConfig, deps)
-	result, err := validateExecutor.ExecuteValidations(ctx, projectRoot, fileDir)
+	validateExecutor := NewParallelValidateExecutor(projectRoot, cfg.TimeoutSeconds, debug, skipConfig, runDeps)
+	if cfg.Cache.Enabled {
+		cache := NewResultCache(cfg.Cache, runDeps)
+		cache.SetInputs(cfg.Env, envDiff, cfg.Sandbox)
+		validateExecutor.EnableCache(cache)
+	}
+	if cfg.Impact.Enabled {
+		selector := NewImpactSelector(projectRoot, cfg.Impact, cfg.TimeoutSeconds, runDeps)
+		validateExecutor.EnableImpact(selector, append([]string{filePath}, coalesced...)...)
+	}
+	if cfg.Autofix.Enabled {
+		validateExecutor.EnableAutofix(NewAutofixer(projectRoot, cfg.Autofix, cfg.TimeoutSeconds, runDeps), filePath)
+	}
+	if !cfg.RunLogs.Disabled {
+		validateExecutor.EnableRunLog(NewRunLog(projectRoot, cfg.RunLogs, runLogEnv(cfg.Env, envDiff), runDeps))
+	}
+	if baseline, baselineErr := LoadBaseline(projectRoot, deps); baselineErr == nil {
+		validateExecutor.EnableBaseline(baseline)
+	} else if !errors.Is(baselineErr, ErrNoBaseline) && logger != nil && logger.IsEnabled() {
+		logger.LogError(baselineErr, "loading lint baseline")
+	}
+	result, err := validateExecutor.ExecuteValidations(ctx, projectRoot, filepath.Dir(filePath))
 	if err != nil {
+		if logger != nil && logger.IsEnabled() {
+			logger.LogError(err, "executing validations")
+		}
 		if debug {
 			_, _ = fmt.Fprintf(deps.Stderr, "Error executing validations: %v\n", err)
 		}
-		return 0
+		return 0, ""
 	}
 
-	// Format and display message
+	// Format message
+	result.Severity = cfg.Severity
+	writeReports(ctx, projectRoot, cfg.Reports, result, deps, logger)
 	message := result.FormatMessage()
-	if message != "" {
-		_, _ = fmt.Fprintln(deps.Stderr, message)
-		return ExitCodeShowMessage
+	// Checks beyond lint and test, in the order their messages appear
+	postChecks := append([]finding{
+		{
+			message:  checkCoverage(ctx, projectRoot, filePath, cfg, result, runDeps, logger),
+			severity: cfg.Severity.Get(config.CheckCoverage),
+		},
+		{
+			message:  checkBenchmarks(ctx, projectRoot, filePath, cfg, result, runDeps, logger),
+			severity: cfg.Severity.Get(config.CheckBench),
+		},
+	}, findings...)
+	passed := result.BothPassed
+	// Silent failures are recorded but leave the pass message in place
+	showsPass := result.reportsPass()
+	blocking := result.hasFailures(config.SeverityBlock)
+	for _, check := range postChecks {
+		if check.message == "" {
+			continue
+		}
+		passed = false
+		if check.severity == config.SeveritySilent {
+			continue
+		}
+		if showsPass {
+			message = check.message
+			showsPass = false
+		} else {
+			message += "\n" + check.message
+		}
+		blocking = blocking || check.severity == config.SeverityBlock
+	}
+	for _, check := range postChecks {
+		if check.info == "" {
+			continue
+		}
+		if message == "" {
+			message = check.info
+		} else {
+			message += "\n" + check.info
+		}
 	}
 
-	return 0
+	record := NewHistoryRecord(deps.Clock.Now(), sessionID, projectRoot, filePath, result, skipConfig, passed)
+	recordHistory(ctx, cfg.History, record, deps, logger)
+	if logger != nil && logger.IsEnabled() {
+		logger.Log("Validation passed: %v", result.BothPassed)
+		if message != "" {
+			logger.Log("Message: %s", message)
+		}
+	}
+	switch {
+	case message == "":
+		return 0, ""
+	case showsPass, blocking:
+		return ExitCodeShowMessage, message
+	default:
+		// Only advisory failures: report them without blocking
+		return 0, message
+	}
 }
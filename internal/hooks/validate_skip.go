@@ -7,6 +7,7 @@ import (
 	"io"
 	"path/filepath"
 
+	"github.com/Veraticus/cc-tools/internal/config"
 	"github.com/Veraticus/cc-tools/internal/shared"
 	"github.com/Veraticus/cc-tools/internal/skipregistry"
 )
@@ -21,23 +22,33 @@ func ValidateWithSkipCheck(
 	debug bool,
 	timeoutSecs int,
 	cooldownSecs int,
+) int {
+	cfg := &config.ValidateConfig{TimeoutSeconds: timeoutSecs, CooldownSeconds: cooldownSecs}
+	return ValidateWithConfig(ctx, stdin, stdout, stderr, debug, cfg)
+}
+
+// ValidateWithConfig is ValidateWithSkipCheck with the full validate configuration.
+func ValidateWithConfig(
+	ctx context.Context,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+	debug bool,
+	cfg *config.ValidateConfig,
 ) int {
 	// Read stdin once
 	stdinData, err := io.ReadAll(stdin)
 	if err != nil {
 		// If we can't read input, run normally without skip checking
-		return RunValidateHook(ctx, debug, timeoutSecs, cooldownSecs, nil)
+		return RunValidateHookWithConfig(ctx, debug, cfg, nil, nil)
 	}
 
 	// Check if directory should be skipped
-	skipLint, skipTest := checkSkipsFromInput(ctx, stdinData, debug, stderr)
+	skipLint, skipTest := checkSkipsFromInput(ctx, stdinData, cfg.Root, debug, stderr)
 
-	// If both are skipped, exit silently
-	if skipLint && skipTest {
-		if debug {
-			_, _ = fmt.Fprintf(stderr, "Both lint and test skipped, exiting silently\n")
-		}
-		return 0
+	// Edits in skipped directories are still checked for secrets and the like
+	if skipLint && skipTest && debug {
+		_, _ = fmt.Fprintf(stderr, "Both lint and test skipped, running edit checks only\n")
 	}
 
 	// Pass skip information to the validate hook
@@ -54,10 +65,12 @@ func ValidateWithSkipCheck(
 		FS:      NewDefaultDependencies().FS,
 		Runner:  NewDefaultDependencies().Runner,
 		Process: NewDefaultDependencies().Process,
+		Locker:  NewDefaultDependencies().Locker,
 		Clock:   NewDefaultDependencies().Clock,
+		LSP:     NewDefaultDependencies().LSP,
 	}
 
-	return RunValidateHookWithSkip(ctx, debug, timeoutSecs, cooldownSecs, skipConfig, deps)
+	return RunValidateHookWithConfig(ctx, debug, cfg, skipConfig, deps)
 }
 
 // bytesInputReader implements InputReader for a byte slice.
@@ -74,7 +87,13 @@ func (b *bytesInputReader) IsTerminal() bool {
 }
 
 // checkSkipsFromInput parses the JSON input and checks the skip registry.
-func checkSkipsFromInput(ctx context.Context, stdinData []byte, debug bool, stderr io.Writer) (bool, bool) {
+func checkSkipsFromInput(
+	ctx context.Context,
+	stdinData []byte,
+	rootCfg config.RootConfig,
+	debug bool,
+	stderr io.Writer,
+) (bool, bool) {
 	// Parse the JSON
 	var input map[string]any
 	if err := json.Unmarshal(stdinData, &input); err != nil {
@@ -105,7 +124,9 @@ func checkSkipsFromInput(ctx context.Context, stdinData []byte, debug bool, stde
 	fileDir := filepath.Dir(filePath)
 
 	// Find the project root - same as we do for discovering lint/test commands
-	projectRoot, err := shared.FindProjectRoot(fileDir, nil)
+	cwd, _ := input["cwd"].(string)
+	root, err := shared.DetectProjectRoot(fileDir, ProjectRootOptions(rootCfg, cwd), nil)
+	projectRoot := root.Dir
 	if err != nil {
 		if debug {
 			_, _ = fmt.Fprintf(stderr, "Failed to find project root: %v\n", err)
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/Veraticus/cc-tools/internal/config"
 )
 
 func TestValidateWithSkipCheck_RealIntegration(t *testing.T) {
@@ -173,7 +175,7 @@ func TestCheckSkipsFromInput_Unit(t *testing.T) {
 			var stderr bytes.Buffer
 
 			// Call the function
-			_, _ = checkSkipsFromInput(ctx, []byte(tt.input), tt.debug, &stderr)
+			_, _ = checkSkipsFromInput(ctx, []byte(tt.input), config.RootConfig{}, tt.debug, &stderr)
 
 			// Check debug logs
 			stderrStr := stderr.String()
@@ -0,0 +1,391 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// settleDelay is how long Diagnose waits for further publications after
+	// the first one, as servers often publish syntax errors before type errors.
+	settleDelay = 150 * time.Millisecond
+	// exitTimeout bounds how long a server may take to exit once its input closes.
+	exitTimeout = 2 * time.Second
+)
+
+// publication holds the diagnostics a server last published for a document.
+type publication struct {
+	seq         int
+	version     int
+	diagnostics []Diagnostic
+}
+
+// document is a document opened on the server.
+type document struct {
+	version int
+	text    string
+	// sentSeq is the publication sequence number when the text was sent.
+	sentSeq int
+}
+
+// Client talks to a language server over a JSON-RPC stream.
+type Client struct {
+	writeMu sync.Mutex
+	stream  io.ReadWriteCloser
+
+	mu        sync.Mutex
+	nextID    int64
+	pending   map[string]chan *message
+	seq       int
+	published map[string]publication
+	changed   chan struct{}
+	documents map[string]document
+	err       error
+	done      chan struct{}
+}
+
+// NewClient returns a client reading messages from stream. Initialize must
+// be called before anything else.
+func NewClient(stream io.ReadWriteCloser) *Client {
+	c := &Client{
+		stream:    stream,
+		pending:   make(map[string]chan *message),
+		published: make(map[string]publication),
+		changed:   make(chan struct{}),
+		documents: make(map[string]document),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// Start runs a language server in root and initializes it. The server keeps
+// running until the client is closed.
+func Start(ctx context.Context, root string, command []string) (*Client, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no language server command")
+	}
+	// The server outlives ctx, which only bounds the initialization
+	cmd := exec.CommandContext(context.WithoutCancel(ctx), command[0], command[1:]...) // #nosec G204 - from config
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %w", err)
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", command[0], err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_, _ = cmd.Process.Wait()
+		close(exited)
+	}()
+	client := NewClient(&processStream{Reader: stdout, stdin: stdin, process: cmd.Process, exited: exited})
+	if err = client.Initialize(ctx, root); err != nil {
+		_ = client.stream.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Initialize performs the initialize handshake for a workspace rooted at root.
+func (c *Client) Initialize(ctx context.Context, root string) error {
+	uri := fileURI(root)
+	params := map[string]any{
+		"processId":  os.Getpid(),
+		"clientInfo": map[string]string{"name": "cc-tools"},
+		"rootUri":    uri,
+		"rootPath":   root,
+		"workspaceFolders": []map[string]string{
+			{"uri": uri, "name": filepath.Base(root)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"publishDiagnostics": map[string]any{"versionSupport": true},
+			},
+			"workspace": map[string]any{"configuration": true, "workspaceFolders": true},
+		},
+	}
+	if err := c.call(ctx, "initialize", params); err != nil {
+		return fmt.Errorf("initializing language server: %w", err)
+	}
+	return c.notify("initialized", struct{}{})
+}
+
+// Diagnose opens a document, or updates it if it is open, and returns the
+// diagnostics the server publishes for that text. An unchanged document
+// returns its last diagnostics straight away. It returns ErrNoDiagnostics
+// when nothing is published before ctx is done.
+func (c *Client) Diagnose(ctx context.Context, path, languageID, text string) ([]Diagnostic, error) {
+	c.mu.Lock()
+	doc, open := c.documents[path]
+	if pub, ok := c.published[path]; open && ok && doc.text == text && pub.seq > doc.sentSeq {
+		c.mu.Unlock()
+		return pub.diagnostics, nil
+	}
+	doc = document{version: doc.version + 1, text: text, sentSeq: c.seq}
+	c.documents[path] = doc
+	c.mu.Unlock()
+
+	identifier := map[string]any{"uri": fileURI(path), "version": doc.version}
+	var err error
+	if open {
+		err = c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   identifier,
+			"contentChanges": []map[string]string{{"text": text}},
+		})
+	} else {
+		identifier["languageId"] = languageID
+		identifier["text"] = text
+		err = c.notify("textDocument/didOpen", map[string]any{"textDocument": identifier})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.await(ctx, path, doc)
+}
+
+// await waits for the diagnostics of a document sent as doc.
+func (c *Client) await(ctx context.Context, path string, doc document) ([]Diagnostic, error) {
+	var latest *publication
+	var settled <-chan time.Time
+	for {
+		c.mu.Lock()
+		pub, ok := c.published[path]
+		changed := c.changed
+		c.mu.Unlock()
+		// Servers that support versions say which text a publication is for
+		if ok && pub.seq > doc.sentSeq && (pub.version == 0 || pub.version >= doc.version) {
+			if latest == nil {
+				settled = time.After(settleDelay)
+			}
+			latest = &pub
+		}
+
+		select {
+		case <-changed:
+		case <-settled:
+			return latest.diagnostics, nil
+		case <-ctx.Done():
+			if latest != nil {
+				return latest.diagnostics, nil
+			}
+			return nil, ErrNoDiagnostics
+		case <-c.done:
+			return nil, c.Err()
+		}
+	}
+}
+
+// Done returns a channel that is closed when the connection to the server is lost.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection to the server was lost, or nil while it is up.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close asks the server to shut down and closes the connection.
+func (c *Client) Close(ctx context.Context) error {
+	err := c.call(ctx, "shutdown", nil)
+	if err == nil {
+		err = c.notify("exit", nil)
+	}
+	if closeErr := c.stream.Close(); closeErr != nil {
+		err = errors.Join(err, fmt.Errorf("closing language server: %w", closeErr))
+	}
+	return err
+}
+
+// call sends a request and waits for its response. Results are not needed
+// by any request the client makes, so they are discarded.
+func (c *Client) call(ctx context.Context, method string, params any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	response := make(chan *message, 1)
+	c.pending[id] = response
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(&message{ID: json.RawMessage(id), Method: method}, params); err != nil {
+		return err
+	}
+	select {
+	case msg := <-response:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-c.done:
+		return c.Err()
+	}
+}
+
+// notify sends a notification.
+func (c *Client) notify(method string, params any) error {
+	return c.send(&message{Method: method}, params)
+}
+
+// send encodes params into msg and writes it.
+func (c *Client) send(msg *message, params any) error {
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("encoding %s params: %w", msg.Method, err)
+		}
+		msg.Params = raw
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeMessage(c.stream, msg)
+}
+
+// readLoop dispatches messages from the server until the stream fails.
+func (c *Client) readLoop() {
+	reader := bufio.NewReader(c.stream)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			c.fail(fmt.Errorf("language server connection lost: %w", err))
+			return
+		}
+		switch {
+		case msg.isRequest():
+			c.reply(msg)
+		case msg.Method == "textDocument/publishDiagnostics":
+			c.publish(msg.Params)
+		case msg.Method == "":
+			c.mu.Lock()
+			response, ok := c.pending[string(msg.ID)]
+			c.mu.Unlock()
+			if ok {
+				response <- msg
+			}
+		}
+	}
+}
+
+// reply answers a request from the server. Configuration requests get an
+// empty configuration for every item and all other requests a null result.
+func (c *Client) reply(req *message) {
+	result := json.RawMessage("null")
+	if req.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if json.Unmarshal(req.Params, &params) == nil {
+			result, _ = json.Marshal(make([]any, len(params.Items)))
+		}
+	}
+	_ = c.send(&message{ID: req.ID, Result: result}, nil)
+}
+
+// publish records the diagnostics published for a document.
+func (c *Client) publish(raw json.RawMessage) {
+	var params struct {
+		URI         string       `json:"uri"`
+		Version     int          `json:"version"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if json.Unmarshal(raw, &params) != nil {
+		return
+	}
+	path := uriPath(params.URI)
+	if path == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	c.published[path] = publication{seq: c.seq, version: params.Version, diagnostics: params.Diagnostics}
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// fail records why the connection was lost and wakes everything waiting on it.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
+
+// fileURI returns the file URI of an absolute path.
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// uriPath returns the path of a file URI, or "" for other URIs.
+func uriPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(parsed.Path)
+}
+
+// processStream is the stdio of a language server process.
+type processStream struct {
+	io.Reader
+
+	stdin   io.WriteCloser
+	process *os.Process
+	exited  <-chan struct{}
+}
+
+func (s *processStream) Write(p []byte) (int, error) {
+	n, err := s.stdin.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("writing to language server: %w", err)
+	}
+	return n, nil
+}
+
+// Close closes the server's input and kills it if it does not exit in time.
+func (s *processStream) Close() error {
+	_ = s.stdin.Close()
+	select {
+	case <-s.exited:
+		return nil
+	case <-time.After(exitTimeout):
+		if err := s.process.Kill(); err != nil {
+			return fmt.Errorf("killing language server: %w", err)
+		}
+		<-s.exited
+		return nil
+	}
+}
@@ -0,0 +1,213 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is a minimal language server. For every document it is sent it
+// asks for configuration, publishes no diagnostics and then an error for
+// each line containing "undefined", as servers publish syntax errors before
+// type errors. Documents containing "slow" get no diagnostics at all.
+type fakeServer struct {
+	conn net.Conn
+
+	mu       sync.Mutex
+	methods  []string
+	replies  []string
+	outbox   chan *message
+	finished chan struct{}
+}
+
+func startFakeServer(t *testing.T) (*fakeServer, *Client) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	server := &fakeServer{conn: serverConn, outbox: make(chan *message, 16), finished: make(chan struct{})}
+	go server.write()
+	go server.serve()
+
+	client := NewClient(clientConn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Initialize(ctx, "/project"); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		<-server.finished
+	})
+	return server, client
+}
+
+// write sends queued messages, so that the server never blocks reading.
+func (s *fakeServer) write() {
+	for msg := range s.outbox {
+		_ = writeMessage(s.conn, msg)
+	}
+}
+
+func (s *fakeServer) serve() {
+	defer close(s.finished)
+	defer close(s.outbox)
+	reader := bufio.NewReader(s.conn)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if msg.Method == "" {
+			s.replies = append(s.replies, string(msg.Result))
+		} else {
+			s.methods = append(s.methods, msg.Method)
+		}
+		s.mu.Unlock()
+
+		switch msg.Method {
+		case "initialize", "shutdown":
+			s.outbox <- &message{ID: msg.ID, Result: json.RawMessage(`{"capabilities":{"textDocumentSync":1}}`)}
+		case "textDocument/didOpen", "textDocument/didChange":
+			s.diagnose(msg.Params)
+		case "exit":
+			_ = s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *fakeServer) diagnose(raw json.RawMessage) {
+	var params struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+			Text    string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	_ = json.Unmarshal(raw, &params)
+	doc := params.TextDocument
+	text := doc.Text
+	if len(params.ContentChanges) > 0 {
+		text = params.ContentChanges[0].Text
+	}
+	if strings.Contains(text, "slow") {
+		return
+	}
+
+	diagnostics := []Diagnostic{}
+	for i, line := range strings.Split(text, "\n") {
+		if col := strings.Index(line, "undefined"); col >= 0 {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    Range{Start: Position{Line: i, Character: col}, End: Position{Line: i, Character: len(line)}},
+				Severity: SeverityError,
+				Code:     json.RawMessage(`"UndeclaredName"`),
+				Source:   "compiler",
+				Message:  "undefined: x",
+			})
+		}
+	}
+	publish := func(diagnostics []Diagnostic) *message {
+		params, _ := json.Marshal(map[string]any{"uri": doc.URI, "version": doc.Version, "diagnostics": diagnostics})
+		return &message{Method: "textDocument/publishDiagnostics", Params: params}
+	}
+	s.outbox <- &message{ID: json.RawMessage(`"config"`), Method: "workspace/configuration",
+		Params: json.RawMessage(`{"items":[{"section":"fake"}]}`)}
+	s.outbox <- publish([]Diagnostic{})
+	s.outbox <- publish(diagnostics)
+}
+
+func (s *fakeServer) received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...), append([]string(nil), s.replies...)
+}
+
+func TestClientDiagnose(t *testing.T) {
+	server, client := startFakeServer(t)
+	diagnose := func(text string) ([]Diagnostic, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return client.Diagnose(ctx, "/project/main.go", "go", text)
+	}
+
+	diagnostics, err := diagnose("package main\n\nvar y = undefined\n")
+	if err != nil {
+		t.Fatalf("Diagnose() error = %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Range.Start != (Position{Line: 2, Character: 8}) ||
+		diagnostics[0].CodeString() != "UndeclaredName" {
+		t.Fatalf("Diagnose() = %+v, want the undefined name on line 2", diagnostics)
+	}
+
+	// Unchanged text is answered from the last publication
+	if _, err = diagnose("package main\n\nvar y = undefined\n"); err != nil {
+		t.Fatalf("Diagnose() unchanged error = %v", err)
+	}
+	diagnostics, err = diagnose("package main\n")
+	if err != nil || len(diagnostics) != 0 {
+		t.Fatalf("Diagnose() after fix = %+v, %v, want none", diagnostics, err)
+	}
+
+	methods, replies := server.received()
+	want := []string{"initialize", "initialized", "textDocument/didOpen", "textDocument/didChange"}
+	if strings.Join(methods, " ") != strings.Join(want, " ") {
+		t.Errorf("server received %q, want %q", methods, want)
+	}
+	if len(replies) != 2 || replies[0] != "[null]" {
+		t.Errorf("configuration replies = %q, want [null] for each document", replies)
+	}
+}
+
+func TestClientDiagnose_Deadline(t *testing.T) {
+	_, client := startFakeServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Diagnose(ctx, "/project/main.go", "go", "slow"); !errors.Is(err, ErrNoDiagnostics) {
+		t.Errorf("Diagnose() error = %v, want ErrNoDiagnostics", err)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	server, client := startFakeServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	<-server.finished
+	methods, _ := server.received()
+	if got := strings.Join(methods[len(methods)-2:], " "); got != "shutdown exit" {
+		t.Errorf("last methods = %q, want shutdown and exit", got)
+	}
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Error("client not done after Close")
+	}
+}
+
+func TestDiagnosticCodeString(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: `"E0425"`, want: "E0425"},
+		{code: `2304`, want: "2304"},
+		{code: ``, want: ""},
+		{code: `null`, want: ""},
+	}
+	for _, tt := range tests {
+		if got := (Diagnostic{Code: json.RawMessage(tt.code)}).CodeString(); got != tt.want {
+			t.Errorf("CodeString(%s) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
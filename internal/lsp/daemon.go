@@ -0,0 +1,322 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Veraticus/cc-tools/internal/sandbox"
+)
+
+// DaemonCommand is the hidden argument that makes a cc-tools binary run as
+// the daemon of a language server.
+const DaemonCommand = "__lsp"
+
+const (
+	// exitUsage is the exit code of the daemon when its arguments are invalid.
+	exitUsage = 2
+	// initializeTimeout bounds how long a language server may take to start.
+	initializeTimeout = 2 * time.Minute
+	// defaultRequestTimeout bounds requests that do not set a timeout.
+	defaultRequestTimeout = 5 * time.Second
+	// ioTimeout bounds reading a request from, and writing a response to, a socket.
+	ioTimeout = 5 * time.Second
+	// responseMargin is kept from a request's deadline for the response to arrive.
+	responseMargin = 50 * time.Millisecond
+	// dialInterval is how often a starting daemon's socket is tried.
+	dialInterval = 25 * time.Millisecond
+	// socketDirMode keeps the socket directory private to the user.
+	socketDirMode = 0o700
+)
+
+// errDaemonRunning is returned when another daemon already serves a socket.
+var errDaemonRunning = errors.New("a daemon is already listening")
+
+// Command returns the argv that runs the daemon of server, using executable
+// as the cc-tools binary.
+func Command(executable string, server Server) []string {
+	argv := []string{
+		executable, DaemonCommand,
+		"--root", server.Root,
+		"--socket", server.Socket,
+		"--idle", strconv.Itoa(server.IdleSeconds),
+		"--",
+	}
+	return append(argv, server.Command...)
+}
+
+// Main runs the daemon with the arguments following DaemonCommand.
+func Main(args []string) int {
+	server, err := parseArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cc-tools lsp: %v\n", err)
+		return exitUsage
+	}
+	if err = run(server); err != nil {
+		fmt.Fprintf(os.Stderr, "cc-tools lsp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseArgs parses "--root DIR --socket PATH --idle SECONDS -- command args".
+func parseArgs(args []string) (Server, error) {
+	var server Server
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		if flag == "--" {
+			server.Command = args[i+1:]
+			break
+		}
+		if i+1 >= len(args) {
+			return server, fmt.Errorf("%s requires a value", flag)
+		}
+		i++
+		switch flag {
+		case "--root":
+			server.Root = args[i]
+		case "--socket":
+			server.Socket = args[i]
+		case "--idle":
+			idle, err := strconv.Atoi(args[i])
+			if err != nil || idle <= 0 {
+				return server, fmt.Errorf("invalid idle time %q", args[i])
+			}
+			server.IdleSeconds = idle
+		default:
+			return server, fmt.Errorf("unexpected argument %q", flag)
+		}
+	}
+
+	switch {
+	case server.Root == "" || server.Socket == "":
+		return server, errors.New("--root and --socket are required")
+	case server.IdleSeconds == 0:
+		return server, errors.New("--idle is required")
+	case len(server.Command) == 0:
+		return server, errors.New("no language server command")
+	}
+	return server, nil
+}
+
+// run serves a language server on its socket until it is idle or exits.
+func run(server Server) error {
+	listener, err := listen(server.Socket)
+	if errors.Is(err, errDaemonRunning) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Requests queue on the socket while the server initializes
+	ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+	client, err := Start(ctx, server.Root, server.Command)
+	cancel()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+	defer func() {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), exitTimeout)
+		_ = client.Close(closeCtx)
+		closeCancel()
+	}()
+	return Serve(listener, client, time.Duration(server.IdleSeconds)*time.Second)
+}
+
+// listen listens on socket, replacing a socket left behind by a daemon that
+// died. It returns errDaemonRunning if a live daemon answers on it.
+func listen(socket string) (*net.UnixListener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), socketDirMode); err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+	addr := &net.UnixAddr{Name: socket, Net: "unix"}
+	listener, err := net.ListenUnix("unix", addr)
+	if err == nil {
+		return listener, nil
+	}
+
+	if conn, dialErr := net.DialTimeout("unix", socket, ioTimeout); dialErr == nil {
+		_ = conn.Close()
+		return nil, errDaemonRunning
+	}
+	if removeErr := os.Remove(socket); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		return nil, fmt.Errorf("removing stale socket: %w", removeErr)
+	}
+	listener, err = net.ListenUnix("unix", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", socket, err)
+	}
+	return listener, nil
+}
+
+// Serve answers requests on listener with diagnostics from client, one at a
+// time, until no request arrives for idle or the connection to the server is
+// lost. It closes listener when it returns.
+func Serve(listener *net.UnixListener, client *Client, idle time.Duration) error {
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		_ = listener.Close()
+	}()
+	go func() {
+		select {
+		case <-client.Done():
+			_ = listener.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		if err := listener.SetDeadline(time.Now().Add(idle)); err != nil {
+			if clientErr := client.Err(); clientErr != nil {
+				return clientErr
+			}
+			return fmt.Errorf("setting idle deadline: %w", err)
+		}
+		conn, err := listener.AcceptUnix()
+		if err != nil {
+			if clientErr := client.Err(); clientErr != nil {
+				return clientErr
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil
+			}
+			return fmt.Errorf("accepting request: %w", err)
+		}
+		handle(conn, client)
+	}
+}
+
+// handle answers a single request.
+func handle(conn net.Conn, client *Client) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var resp Response
+	diagnostics, err := client.Diagnose(ctx, req.Path, req.LanguageID, req.Text)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	resp.Diagnostics = diagnostics
+
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// Diagnose asks the daemon of server for the diagnostics of a file. When no
+// daemon is running it starts one, re-executing executable, and waits for it
+// within the deadline of ctx. Requests without a deadline use the daemon's
+// default timeout.
+func Diagnose(ctx context.Context, executable string, server Server, req Request) ([]Diagnostic, error) {
+	conn, err := dial(ctx, server.Socket)
+	if err != nil {
+		if startErr := startDaemon(ctx, executable, server); startErr != nil {
+			return nil, startErr
+		}
+		conn, err = redial(ctx, server.Socket)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to language server daemon: %w", err)
+		}
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		req.TimeoutMS = max(time.Until(deadline)-responseMargin, time.Millisecond).Milliseconds()
+	}
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("sending diagnostics request: %w", err)
+	}
+	var resp Response
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("reading diagnostics response: %w", err)
+	}
+	switch resp.Error {
+	case "":
+		return resp.Diagnostics, nil
+	case ErrNoDiagnostics.Error():
+		return nil, ErrNoDiagnostics
+	default:
+		return nil, fmt.Errorf("language server: %s", resp.Error)
+	}
+}
+
+// dial connects to the socket of a daemon.
+func dial(ctx context.Context, socket string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", socket, err)
+	}
+	return conn, nil
+}
+
+// redial retries dial until the daemon listens or ctx is done.
+func redial(ctx context.Context, socket string) (net.Conn, error) {
+	ticker := time.NewTicker(dialInterval)
+	defer ticker.Stop()
+	for {
+		conn, err := dial(ctx, socket)
+		if err == nil {
+			return conn, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-ticker.C:
+		}
+	}
+}
+
+// startDaemon starts the daemon of server in the background, detached from
+// the hook so that it outlives it. A confined daemon is started through the
+// sandbox helper of executable.
+func startDaemon(ctx context.Context, executable string, server Server) error {
+	argv := Command(executable, server)
+	if server.Writable != nil {
+		// The socket directory is created before the daemon loses the right to
+		socketDir := filepath.Dir(server.Socket)
+		if err := os.MkdirAll(socketDir, socketDirMode); err != nil {
+			return fmt.Errorf("creating socket directory: %w", err)
+		}
+		policy := sandbox.Policy{Writable: append(slices.Clone(server.Writable), socketDir)}
+		argv = sandbox.Command(executable, policy, argv[0], argv[1:])
+	}
+	cmd := exec.CommandContext(context.WithoutCancel(ctx), argv[0], argv[1:]...) // #nosec G204 - re-executes cc-tools
+	cmd.Dir = server.Root
+	cmd.Env = server.Env
+	cmd.SysProcAttr = detached()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting language server daemon: %w", err)
+	}
+	if err := cmd.Process.Release(); err != nil {
+		return fmt.Errorf("releasing language server daemon: %w", err)
+	}
+	return nil
+}
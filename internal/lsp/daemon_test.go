@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCommandRoundTrip(t *testing.T) {
+	server := Server{
+		Root:        "/project",
+		Command:     []string{"pyright-langserver", "--stdio", "--"},
+		Socket:      "/run/user/1000/cc-tools/locks/lsp-0123.sock",
+		IdleSeconds: 600,
+	}
+	argv := Command("/usr/bin/cc-tools", server)
+	if argv[0] != "/usr/bin/cc-tools" || argv[1] != DaemonCommand {
+		t.Fatalf("Command() = %q, want the daemon of cc-tools", argv)
+	}
+
+	parsed, err := parseArgs(argv[2:])
+	if err != nil {
+		t.Fatalf("parseArgs() error = %v", err)
+	}
+	if parsed.Root != server.Root || parsed.Socket != server.Socket || parsed.IdleSeconds != server.IdleSeconds ||
+		!slices.Equal(parsed.Command, server.Command) {
+		t.Errorf("parseArgs() = %+v, want %+v", parsed, server)
+	}
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "empty", args: nil},
+		{name: "missing value", args: []string{"--root"}},
+		{name: "no socket", args: []string{"--root", "/p", "--idle", "60", "--", "gopls"}},
+		{name: "invalid idle", args: []string{"--root", "/p", "--socket", "/s", "--idle", "soon", "--", "gopls"}},
+		{name: "no command", args: []string{"--root", "/p", "--socket", "/s", "--idle", "60", "--"}},
+		{name: "unknown flag", args: []string{"--port", "80", "--", "gopls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseArgs(tt.args); err == nil {
+				t.Errorf("parseArgs(%q) should fail", tt.args)
+			}
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "lsp", "a.sock")
+	first, err := listen(socket)
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	if _, err = listen(socket); !errors.Is(err, errDaemonRunning) {
+		t.Fatalf("listen() on a live socket error = %v, want errDaemonRunning", err)
+	}
+
+	// A daemon that died leaves its socket behind
+	first.SetUnlinkOnClose(false)
+	_ = first.Close()
+	second, err := listen(socket)
+	if err != nil {
+		t.Fatalf("listen() on a stale socket error = %v", err)
+	}
+	_ = second.Close()
+}
+
+func TestServe(t *testing.T) {
+	_, client := startFakeServer(t)
+	server := Server{Root: "/project", Socket: filepath.Join(t.TempDir(), "a.sock"), IdleSeconds: 1}
+	listener, err := listen(server.Socket)
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(listener, client, 200*time.Millisecond)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// The daemon is running, so no executable is started
+	req := Request{Path: "/project/main.go", LanguageID: "go", Text: "package main\n\nvar y = undefined\n"}
+	diagnostics, err := Diagnose(ctx, "/nonexistent/cc-tools", server, req)
+	if err != nil {
+		t.Fatalf("Diagnose() error = %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Message != "undefined: x" {
+		t.Errorf("Diagnose() = %+v, want the undefined name", diagnostics)
+	}
+
+	slowCtx, slowCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer slowCancel()
+	req.Text = "slow"
+	if _, err = Diagnose(slowCtx, "/nonexistent/cc-tools", server, req); !errors.Is(err, ErrNoDiagnostics) {
+		t.Errorf("Diagnose() of a slow document error = %v, want ErrNoDiagnostics", err)
+	}
+
+	select {
+	case err = <-served:
+		if err != nil {
+			t.Errorf("Serve() error = %v, want nil after idling", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve() did not return when idle")
+	}
+	if _, err = net.Dial("unix", server.Socket); err == nil {
+		t.Error("socket still answers after the daemon exited")
+	}
+}
+
+func TestDiagnose_StartFails(t *testing.T) {
+	server := Server{Root: t.TempDir(), Socket: filepath.Join(t.TempDir(), "a.sock"), IdleSeconds: 1,
+		Command: []string{"gopls"}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := Diagnose(ctx, "/nonexistent/cc-tools", server, Request{}); err == nil {
+		t.Error("Diagnose() should fail when the daemon cannot be started")
+	}
+}
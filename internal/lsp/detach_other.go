@@ -0,0 +1,10 @@
+//go:build !unix
+
+package lsp
+
+import "syscall"
+
+// detached returns no attributes; the daemon already outlives the hook.
+func detached() *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package lsp
+
+import "syscall"
+
+// detached puts the daemon in its own session so that it outlives the hook
+// and its terminal.
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
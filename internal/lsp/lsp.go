@@ -0,0 +1,95 @@
+// Package lsp collects diagnostics for single files from language servers.
+//
+// Starting a language server takes seconds, far longer than a hook may wait,
+// so servers are kept warm between hook invocations: a cc-tools binary is
+// re-executed as a small daemon that owns the server, talks to it over stdio
+// JSON-RPC and answers diagnostics requests on a per-project unix socket.
+// The daemon exits when it has been idle for a while or the server exits.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrNoDiagnostics is returned when the server publishes no diagnostics for
+// a file before the deadline.
+var ErrNoDiagnostics = errors.New("no diagnostics published before the deadline")
+
+// Diagnostic severities defined by the protocol.
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+
+// Position is a zero-based line and UTF-16 character offset in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic is a problem a language server found in a document.
+type Diagnostic struct {
+	Range Range `json:"range"`
+	// Severity is one of the Severity constants. Servers may omit it.
+	Severity int `json:"severity,omitempty"`
+	// Code is a string or a number, as chosen by the server.
+	Code    json.RawMessage `json:"code,omitempty"`
+	Source  string          `json:"source,omitempty"`
+	Message string          `json:"message"`
+}
+
+// CodeString returns the diagnostic code as text, or "" when there is none.
+func (d Diagnostic) CodeString() string {
+	var code any
+	if len(d.Code) == 0 || json.Unmarshal(d.Code, &code) != nil || code == nil {
+		return ""
+	}
+	if s, ok := code.(string); ok {
+		return s
+	}
+	return strings.TrimSpace(string(d.Code))
+}
+
+// Server identifies a language server of a project and the daemon that
+// keeps it running.
+type Server struct {
+	// Root is the project root the server is started in.
+	Root string
+	// Command is the argv that starts the server speaking LSP on stdio.
+	Command []string
+	// Socket is the unix socket the daemon listens on.
+	Socket string
+	// IdleSeconds is how long the daemon waits for a request before exiting.
+	IdleSeconds int
+	// Env is the environment of the daemon and the server. Nil inherits the
+	// environment of the caller.
+	Env []string
+	// Writable confines the daemon and the server to writing beneath these
+	// paths and the socket's directory. Nil leaves them unconfined.
+	Writable []string
+}
+
+// Request asks the daemon for the diagnostics of a file.
+type Request struct {
+	Path       string `json:"path"`
+	LanguageID string `json:"language_id"`
+	Text       string `json:"text"`
+	// TimeoutMS bounds how long the daemon waits for the server.
+	TimeoutMS int64 `json:"timeout_ms"`
+}
+
+// Response carries the diagnostics of a file, or the error collecting them.
+type Response struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Error       string       `json:"error,omitempty"`
+}
@@ -0,0 +1,118 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeAccess is every filesystem right that modifies the tree in Landlock ABI 1.
+const writeAccess = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+	unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+	unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+	unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+	unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+	unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+	unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+	unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+	unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+	unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+
+// fileAccess is the subset of rights that applies to a path that is not a directory.
+const fileAccess = unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+
+// Landlock ABI versions that added write rights.
+const (
+	abiRefer    = 2
+	abiTruncate = 3
+)
+
+// abiVersion returns the Landlock ABI the kernel supports.
+func abiVersion() (int, error) {
+	version, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, fmt.Errorf("landlock is not enabled: %w", errno)
+	}
+	return int(version), nil
+}
+
+func supported() error {
+	_, err := abiVersion()
+	return err
+}
+
+// handledAccess returns the write rights the kernel's ABI can restrict.
+func handledAccess(abi int) uint64 {
+	access := uint64(writeAccess)
+	if abi >= abiRefer {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= abiTruncate {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return access
+}
+
+// restrict confines writes of the calling thread and its future children to
+// the policy's writable paths and sets no_new_privs. It locks the goroutine to
+// its thread for good, since the restrictions only apply to that thread.
+func restrict(policy Policy) error {
+	abi, err := abiVersion()
+	if err != nil {
+		return err
+	}
+	runtime.LockOSThread()
+
+	handled := handledAccess(abi)
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	rulesetFd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr.Access_fs), 0)
+	if errno != 0 {
+		return fmt.Errorf("creating ruleset: %w", errno)
+	}
+	defer func() { _ = unix.Close(int(rulesetFd)) }()
+
+	for _, path := range policy.Writable {
+		if ruleErr := allowWrite(int(rulesetFd), path, handled); ruleErr != nil {
+			return ruleErr
+		}
+	}
+
+	if prctlErr := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); prctlErr != nil {
+		return fmt.Errorf("setting no_new_privs: %w", prctlErr)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, rulesetFd, 0, 0); errno != 0 {
+		return fmt.Errorf("restricting self: %w", errno)
+	}
+	return nil
+}
+
+// allowWrite adds a rule allowing writes beneath path. Paths that do not exist
+// are skipped, since nothing can be written beneath them anyway.
+func allowWrite(rulesetFd int, path string, handled uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	access := handled
+	var stat unix.Stat_t
+	if statErr := unix.Fstat(fd, &stat); statErr == nil && stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= fileAccess
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)} //nolint:gosec // fds fit in int32
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFd),
+		unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("allowing writes to %s: %w", path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as the sandbox helper, the way the
+// cc-tools binaries do.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == HelperCommand {
+		os.Exit(Main(os.Args[2:]))
+	}
+	os.Exit(m.Run())
+}
+
+func TestMain_RestrictsWrites(t *testing.T) {
+	if err := Supported(); err != nil {
+		t.Skipf("landlock unavailable: %v", err)
+	}
+
+	writable := t.TempDir()
+	readOnly := t.TempDir()
+	policy := Policy{Writable: []string{writable, "/dev"}}
+
+	run := func(script string) (string, error) {
+		argv := Command(os.Args[0], policy, "sh", []string{"-c", script})
+		out, err := exec.Command(argv[0], argv[1:]...).CombinedOutput() //nolint:gosec // Test helper argv
+		return string(out), err
+	}
+
+	if out, err := run("echo ok > " + filepath.Join(writable, "allowed")); err != nil {
+		t.Fatalf("write inside the sandbox failed: %v\n%s", err, out)
+	}
+
+	out, err := run("echo no > " + filepath.Join(readOnly, "denied"))
+	if err == nil {
+		t.Fatal("write outside the sandbox should fail")
+	}
+	if !strings.Contains(strings.ToLower(out), "permission denied") {
+		t.Errorf("output = %q, want a permission error", out)
+	}
+	if _, statErr := os.Stat(filepath.Join(readOnly, "denied")); statErr == nil {
+		t.Error("file outside the sandbox was created")
+	}
+
+	if out, err := run("cat /proc/self/status"); err != nil || !strings.Contains(out, "NoNewPrivs:\t1") {
+		t.Errorf("no_new_privs not set: %v\n%s", err, out)
+	}
+}
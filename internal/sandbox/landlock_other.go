@@ -0,0 +1,16 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+)
+
+func supported() error {
+	return fmt.Errorf("landlock requires Linux: %w", errors.ErrUnsupported)
+}
+
+func restrict(Policy) error {
+	return supported()
+}
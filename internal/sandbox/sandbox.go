@@ -0,0 +1,175 @@
+// Package sandbox runs commands with writes restricted to a set of paths.
+//
+// Restrictions apply to the calling thread and everything it executes, so a
+// command is sandboxed by re-executing a cc-tools binary as a small helper:
+// the helper restricts itself and then replaces itself with the command.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// HelperCommand is the hidden argument that makes a cc-tools binary run as
+// the sandbox helper.
+const HelperCommand = "__sandbox"
+
+// ExitUnavailable is the exit code of the helper when it cannot apply the sandbox.
+const ExitUnavailable = 125
+
+// exitNotFound is the exit code of the helper when the command does not exist.
+const exitNotFound = 127
+
+// UnavailableMarker starts the helper's error message when it cannot apply the sandbox.
+const UnavailableMarker = "cc-tools sandbox unavailable:"
+
+// Policy lists what a sandboxed command may do. Reads and execution are not
+// restricted; writes are only allowed beneath the writable paths.
+type Policy struct {
+	Writable []string
+}
+
+// Command returns the argv that runs name with args under the policy, using
+// helper as the cc-tools binary that applies the sandbox.
+func Command(helper string, policy Policy, name string, args []string) []string {
+	argv := []string{helper, HelperCommand}
+	for _, path := range policy.Writable {
+		argv = append(argv, "--write", path)
+	}
+	argv = append(argv, "--", name)
+	return append(argv, args...)
+}
+
+// Main runs the sandbox helper with the arguments following HelperCommand.
+// On success it does not return, since the process becomes the command.
+func Main(args []string) int {
+	policy, argv, err := parseArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cc-tools sandbox: %v\n", err)
+		return ExitUnavailable
+	}
+
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cc-tools sandbox: %v\n", err)
+		return exitNotFound
+	}
+
+	if restrictErr := restrict(policy); restrictErr != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", UnavailableMarker, restrictErr)
+		return ExitUnavailable
+	}
+
+	execErr := syscall.Exec(path, argv, os.Environ())
+	fmt.Fprintf(os.Stderr, "cc-tools sandbox: exec %s: %v\n", path, execErr)
+	return exitNotFound
+}
+
+// Supported reports why the sandbox cannot be applied on this system, if it cannot.
+func Supported() error {
+	return supported()
+}
+
+// parseArgs parses "--write PATH ... -- command args".
+func parseArgs(args []string) (Policy, []string, error) {
+	var policy Policy
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--write":
+			if i+1 >= len(args) {
+				return policy, nil, errors.New("--write requires a path")
+			}
+			i++
+			policy.Writable = append(policy.Writable, args[i])
+		case "--":
+			if i+1 >= len(args) {
+				return policy, nil, errors.New("no command to run")
+			}
+			return policy, args[i+1:], nil
+		default:
+			return policy, nil, fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	return policy, nil, errors.New("no command to run")
+}
+
+// DefaultWritable returns the paths validation commands of a project may write
+// to: the project, the temporary directory, harmless devices such as
+// /dev/null, and the caches of the Go, Node, Rust, Python and Nix toolchains.
+// Environment variables that relocate a cache take precedence over its
+// default location. Toolchain homes are not writable as a whole, since their
+// bin directories and configuration are used outside the sandbox.
+func DefaultWritable(projectRoot string) []string {
+	home, _ := os.UserHomeDir()
+	cacheDir, _ := os.UserCacheDir()
+	cargoHome := os.Getenv("CARGO_HOME")
+	if cargoHome == "" {
+		cargoHome = under(home, ".cargo")
+	}
+
+	paths := append([]string{projectRoot, os.TempDir(), "/tmp"}, devices...)
+	for _, cache := range []struct {
+		env      string
+		fallback string
+	}{
+		{"GOCACHE", under(cacheDir, "go-build")},
+		{"GOMODCACHE", goModCache(home)},
+		{"GOLANGCI_LINT_CACHE", under(cacheDir, "golangci-lint")},
+		{"npm_config_cache", under(home, ".npm")},
+		{"YARN_CACHE_FOLDER", under(cacheDir, "yarn")},
+		{"npm_config_store_dir", pnpmStore(home)},
+		{"", under(cargoHome, "registry")},
+		{"", under(cargoHome, "git")},
+		{"CARGO_TARGET_DIR", ""},
+		{"PIP_CACHE_DIR", under(cacheDir, "pip")},
+		{"UV_CACHE_DIR", under(cacheDir, "uv")},
+		{"", under(cacheDir, "nix")},
+	} {
+		path := cache.fallback
+		if value := os.Getenv(cache.env); cache.env != "" && value != "" {
+			path = value
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// devices are the device files and directories commands commonly write to.
+var devices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/tty", "/dev/ptmx", "/dev/pts", "/dev/shm"}
+
+// under joins elem to base, or returns "" when base is unknown.
+func under(base string, elem ...string) string {
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(append([]string{base}, elem...)...)
+}
+
+// pnpmStore returns the default pnpm content-addressable store.
+func pnpmStore(home string) string {
+	if pnpmHome := os.Getenv("PNPM_HOME"); pnpmHome != "" {
+		return filepath.Join(pnpmHome, "store")
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "pnpm", "store")
+	}
+	return under(home, ".local", "share", "pnpm", "store")
+}
+
+// goModCache returns the default Go module cache.
+func goModCache(home string) string {
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		if home == "" {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
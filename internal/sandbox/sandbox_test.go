@@ -0,0 +1,77 @@
+package sandbox
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandRoundTrip(t *testing.T) {
+	policy := Policy{Writable: []string{"/project", "/tmp"}}
+	argv := Command("/usr/bin/cc-tools", policy, "make", []string{"lint", "--", "-v"})
+
+	want := []string{"/usr/bin/cc-tools", HelperCommand, "--write", "/project", "--write", "/tmp", "--", "make", "lint", "--", "-v"}
+	if !slices.Equal(argv, want) {
+		t.Fatalf("Command() = %q, want %q", argv, want)
+	}
+
+	parsed, command, err := parseArgs(argv[2:])
+	if err != nil {
+		t.Fatalf("parseArgs() error = %v", err)
+	}
+	if !slices.Equal(parsed.Writable, policy.Writable) || !slices.Equal(command, []string{"make", "lint", "--", "-v"}) {
+		t.Errorf("parseArgs() = %v, %q", parsed, command)
+	}
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "empty", args: nil},
+		{name: "write without path", args: []string{"--write"}},
+		{name: "no command", args: []string{"--write", "/tmp", "--"}},
+		{name: "unknown flag", args: []string{"--read", "/tmp", "--", "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := parseArgs(tt.args); err == nil {
+				t.Errorf("parseArgs(%q) should fail", tt.args)
+			}
+		})
+	}
+}
+
+func TestDefaultWritable(t *testing.T) {
+	t.Setenv("GOCACHE", "/custom/go-build")
+	t.Setenv("CARGO_TARGET_DIR", "")
+
+	paths := DefaultWritable("/project")
+	for _, want := range []string{"/project", "/tmp", "/custom/go-build"} {
+		if !slices.Contains(paths, want) {
+			t.Errorf("DefaultWritable() = %q, want to contain %q", paths, want)
+		}
+	}
+	if slices.Contains(paths, "") {
+		t.Errorf("DefaultWritable() contains an empty path: %q", paths)
+	}
+}
+
+func TestDefaultWritable_ExcludesToolchainHomes(t *testing.T) {
+	t.Setenv("CARGO_HOME", "/home/user/.cargo")
+	t.Setenv("PNPM_HOME", "/home/user/.local/share/pnpm")
+
+	paths := DefaultWritable("/project")
+	for _, want := range []string{"/home/user/.cargo/registry", "/home/user/.cargo/git",
+		"/home/user/.local/share/pnpm/store", "/dev/null"} {
+		if !slices.Contains(paths, want) {
+			t.Errorf("DefaultWritable() = %q, want to contain %q", paths, want)
+		}
+	}
+	for _, unwanted := range []string{"/home/user/.cargo", "/home/user/.local/share/pnpm", "/dev"} {
+		if slices.Contains(paths, unwanted) {
+			t.Errorf("DefaultWritable() = %q, must not contain %q", paths, unwanted)
+		}
+	}
+}
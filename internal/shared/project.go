@@ -1,9 +1,7 @@
 package shared
 
 import (
-	"fmt"
 	"path/filepath"
-	"strings"
 )
 
 // ProjectHelper provides project-related functions with dependency injection.
@@ -22,57 +20,11 @@ func NewProjectHelper(deps *Dependencies) *ProjectHelper {
 // FindProjectRoot walks up from the current directory to find the project root.
 // It looks for common project markers like .git, go.mod, package.json, etc.
 func FindProjectRoot(startDir string, deps *Dependencies) (string, error) {
-	if deps == nil {
-		deps = NewDefaultDependencies()
-	}
-
-	dir := startDir
-	if dir == "" {
-		var err error
-		dir, err = deps.FS.Getwd()
-		if err != nil {
-			return "", fmt.Errorf("getting working directory: %w", err)
-		}
-	}
-
-	// Convert to absolute path
-	absDir, err := deps.FS.Abs(dir)
+	match, err := DetectProjectRoot(startDir, RootOptions{Strategies: []string{RootStrategyNearest}}, deps)
 	if err != nil {
-		return "", fmt.Errorf("getting absolute path: %w", err)
+		return "", err
 	}
-
-	for {
-		// Check for project root markers
-		markers := []string{
-			".git",
-			"go.mod",
-			"package.json",
-			"Cargo.toml",
-			"setup.py",
-			"pyproject.toml",
-			"Makefile",
-			"justfile",
-			"Justfile",
-		}
-
-		for _, marker := range markers {
-			path := filepath.Join(absDir, marker)
-			if _, statErr := deps.FS.Stat(path); statErr == nil {
-				return absDir, nil
-			}
-		}
-
-		// Move up one directory
-		parent := filepath.Dir(absDir)
-		if parent == absDir {
-			// Reached root of filesystem
-			break
-		}
-		absDir = parent
-	}
-
-	// No project root found, return original directory
-	return dir, nil
+	return match.Dir, nil
 }
 
 // DetectProjectType analyzes the project directory to determine its type.
@@ -148,62 +100,3 @@ func fileExists(path string, deps *Dependencies) bool {
 	_, err := deps.FS.Stat(path)
 	return err == nil
 }
-
-// ShouldSkipFile determines if a file should be skipped based on common patterns.
-// This function doesn't need dependency injection as it only does string manipulation.
-func ShouldSkipFile(filePath string) bool {
-	// Built-in patterns to always skip
-	skipPatterns := []string{
-		"/vendor/",
-		"/node_modules/",
-		"/build/",
-		"/.git/",
-		"/dist/",
-		"/__pycache__/",
-		"/.cache/",
-		"/target/", // Rust
-		"/.next/",  // Next.js
-	}
-
-	for _, pattern := range skipPatterns {
-		if strings.Contains(filePath, pattern) {
-			return true
-		}
-	}
-
-	// Skip test files for linting/formatting hooks
-	testSuffixes := []string{
-		"_test.go",
-		"_test.py",
-		".test.js",
-		".test.ts",
-		".spec.js",
-		".spec.ts",
-		".test.jsx",
-		".test.tsx",
-		".spec.jsx",
-		".spec.tsx",
-	}
-
-	for _, suffix := range testSuffixes {
-		if strings.HasSuffix(filePath, suffix) {
-			return true
-		}
-	}
-
-	// Skip generated files
-	generatedSuffixes := []string{
-		".generated.go",
-		".pb.go",
-		".gen.go",
-		"_gen.go",
-	}
-
-	for _, suffix := range generatedSuffixes {
-		if strings.HasSuffix(filePath, suffix) {
-			return true
-		}
-	}
-
-	return false
-}
@@ -528,60 +528,6 @@ func TestGetPackageManager(t *testing.T) {
 	}
 }
 
-func TestShouldSkipFile(t *testing.T) {
-	tests := []struct {
-		name     string
-		filePath string
-		expected bool
-	}{
-		// Skip patterns
-		{"vendor directory", "/project/vendor/github.com/lib/file.go", true},
-		{"node_modules", "/project/node_modules/package/index.js", true},
-		{"build directory", "/project/build/output.js", true},
-		{"git directory", "/project/.git/config", true},
-		{"dist directory", "/project/dist/bundle.js", true},
-		{"python cache", "/project/__pycache__/module.pyc", true},
-		{"cache directory", "/project/.cache/data", true},
-		{"rust target", "/project/target/release/binary", true},
-		{"next.js cache", "/project/.next/static/chunks/main.js", true},
-
-		// Test files
-		{"go test file", "/project/main_test.go", true},
-		{"python test file", "/project/test_module_test.py", true},
-		{"js test file", "/project/component.test.js", true},
-		{"ts test file", "/project/component.test.ts", true},
-		{"js spec file", "/project/component.spec.js", true},
-		{"ts spec file", "/project/component.spec.ts", true},
-		{"jsx test file", "/project/component.test.jsx", true},
-		{"tsx test file", "/project/component.test.tsx", true},
-		{"jsx spec file", "/project/component.spec.jsx", true},
-		{"tsx spec file", "/project/component.spec.tsx", true},
-
-		// Generated files
-		{"generated go file", "/project/api.generated.go", true},
-		{"protobuf go file", "/project/service.pb.go", true},
-		{"gen go file", "/project/models.gen.go", true},
-		{"underscore gen go file", "/project/types_gen.go", true},
-
-		// Should not skip
-		{"regular go file", "/project/main.go", false},
-		{"regular js file", "/project/index.js", false},
-		{"regular py file", "/project/module.py", false},
-		{"vendored but not in vendor dir", "/project/vendored_lib.go", false},
-		{"test in name but not suffix", "/project/test_utils.go", false},
-		{"generated in name but not suffix", "/project/generated_utils.go", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ShouldSkipFile(tt.filePath)
-			if result != tt.expected {
-				t.Errorf("ShouldSkipFile(%q) = %v, expected %v", tt.filePath, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestProjectHelper(t *testing.T) {
 	t.Run("NewProjectHelper with nil deps", func(t *testing.T) {
 		helper := NewProjectHelper(nil)
@@ -0,0 +1,196 @@
+package shared
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// RootMarkerFile explicitly marks a project root. It takes precedence over
+// other markers with the default strategies.
+const RootMarkerFile = ".cc-tools-root"
+
+// Project root detection strategies.
+const (
+	// RootStrategyMarkerFile picks the nearest directory containing RootMarkerFile.
+	RootStrategyMarkerFile = "root_file"
+	// RootStrategyProjectDir picks the project directory Claude Code reports
+	// (CLAUDE_PROJECT_DIR or the hook's cwd) when the file is inside it.
+	RootStrategyProjectDir = "project_dir"
+	// RootStrategyVCS picks the outermost version control root.
+	RootStrategyVCS = "vcs"
+	// RootStrategyNearest picks the nearest directory containing a project marker.
+	RootStrategyNearest = "nearest"
+)
+
+// DefaultRootStrategies returns the strategies used when none are configured.
+func DefaultRootStrategies() []string {
+	return []string{RootStrategyMarkerFile, RootStrategyNearest}
+}
+
+// DefaultRootMarkers returns the project markers used by the nearest strategy
+// when none are configured.
+func DefaultRootMarkers() []string {
+	return []string{
+		".git",
+		"go.mod",
+		"package.json",
+		"Cargo.toml",
+		"setup.py",
+		"pyproject.toml",
+		"Makefile",
+		"justfile",
+		"Justfile",
+	}
+}
+
+// vcsMarkers are the directories that mark a version control root.
+func vcsMarkers() []string {
+	return []string{".git", ".hg", ".svn", ".jj"}
+}
+
+// RootOptions configures project root detection.
+type RootOptions struct {
+	// Strategies are tried in order; the first that matches wins.
+	Strategies []string
+	// Markers are the files the nearest strategy looks for.
+	Markers []string
+	// ProjectDir is the project directory reported by Claude Code, if any.
+	ProjectDir string
+	// ProjectDirSource names where ProjectDir came from, for explanations.
+	ProjectDirSource string
+}
+
+// RootMatch is the outcome of one root detection strategy.
+type RootMatch struct {
+	Strategy string
+	Matched  bool
+	Dir      string
+	Reason   string
+}
+
+// DetectProjectRoot returns the root chosen by the first matching strategy.
+// If none matches, the start directory is used.
+func DetectProjectRoot(startDir string, opts RootOptions, deps *Dependencies) (RootMatch, error) {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+	dir, err := resolveStartDir(startDir, deps)
+	if err != nil {
+		return RootMatch{}, err
+	}
+
+	matches, err := EvaluateRootStrategies(dir, opts, deps)
+	if err != nil {
+		return RootMatch{}, err
+	}
+	for _, match := range matches {
+		if match.Matched {
+			return match, nil
+		}
+	}
+	return RootMatch{Dir: dir, Reason: "no strategy matched, using the start directory"}, nil
+}
+
+// resolveStartDir defaults an empty start directory to the working directory.
+func resolveStartDir(startDir string, deps *Dependencies) (string, error) {
+	if startDir != "" {
+		return startDir, nil
+	}
+	dir, err := deps.FS.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+	return dir, nil
+}
+
+// EvaluateRootStrategies runs every configured strategy from startDir and
+// reports what each found, in order.
+func EvaluateRootStrategies(startDir string, opts RootOptions, deps *Dependencies) ([]RootMatch, error) {
+	if deps == nil {
+		deps = NewDefaultDependencies()
+	}
+
+	dir, err := resolveStartDir(startDir, deps)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := deps.FS.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path: %w", err)
+	}
+
+	strategies := opts.Strategies
+	if len(strategies) == 0 {
+		strategies = DefaultRootStrategies()
+	}
+	markers := opts.Markers
+	if len(markers) == 0 {
+		markers = DefaultRootMarkers()
+	}
+
+	matches := make([]RootMatch, 0, len(strategies))
+	for _, strategy := range strategies {
+		var match RootMatch
+		switch strategy {
+		case RootStrategyMarkerFile:
+			match = nearestMarker(absDir, []string{RootMarkerFile}, deps)
+		case RootStrategyProjectDir:
+			match = insideProjectDir(absDir, opts)
+		case RootStrategyVCS:
+			match = outermostVCSRoot(absDir, deps)
+		case RootStrategyNearest:
+			match = nearestMarker(absDir, markers, deps)
+		default:
+			match = RootMatch{Reason: "unknown strategy"}
+		}
+		match.Strategy = strategy
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// nearestMarker finds the closest ancestor of dir containing one of the markers.
+func nearestMarker(dir string, markers []string, deps *Dependencies) RootMatch {
+	for current := dir; ; current = filepath.Dir(current) {
+		for _, marker := range markers {
+			if fileExists(filepath.Join(current, marker), deps) {
+				return RootMatch{Matched: true, Dir: current, Reason: fmt.Sprintf("found %s in %s", marker, current)}
+			}
+		}
+		if filepath.Dir(current) == current {
+			return RootMatch{Reason: fmt.Sprintf("none of %s above %s", strings.Join(markers, ", "), dir)}
+		}
+	}
+}
+
+// outermostVCSRoot finds the highest ancestor of dir that is a version control root.
+func outermostVCSRoot(dir string, deps *Dependencies) RootMatch {
+	match := RootMatch{Reason: "not inside a version control root"}
+	for current := dir; ; current = filepath.Dir(current) {
+		for _, marker := range vcsMarkers() {
+			if fileExists(filepath.Join(current, marker), deps) {
+				reason := fmt.Sprintf("outermost %s in %s", marker, current)
+				match = RootMatch{Matched: true, Dir: current, Reason: reason}
+				break
+			}
+		}
+		if filepath.Dir(current) == current {
+			return match
+		}
+	}
+}
+
+// insideProjectDir matches when dir is inside the project directory reported by Claude Code.
+func insideProjectDir(dir string, opts RootOptions) RootMatch {
+	if opts.ProjectDir == "" {
+		return RootMatch{Reason: "no project directory reported"}
+	}
+	projectDir := filepath.Clean(opts.ProjectDir)
+	rel, err := filepath.Rel(projectDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return RootMatch{Reason: fmt.Sprintf("%s is outside %s %s", dir, opts.ProjectDirSource, projectDir)}
+	}
+	reason := fmt.Sprintf("inside %s %s", opts.ProjectDirSource, projectDir)
+	return RootMatch{Matched: true, Dir: projectDir, Reason: reason}
+}
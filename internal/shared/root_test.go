@@ -0,0 +1,138 @@
+package shared
+
+import (
+	"os"
+	"testing"
+)
+
+// markerFS returns a mock filesystem in which exactly the given paths exist.
+func markerFS(paths ...string) *mockFileSystem {
+	existing := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		existing[path] = true
+	}
+	return &mockFileSystem{
+		statFunc: func(name string) (os.FileInfo, error) {
+			if existing[name] {
+				return mockFileInfo{name: name}, nil
+			}
+			return nil, os.ErrNotExist
+		},
+	}
+}
+
+func TestDetectProjectRoot(t *testing.T) {
+	tests := []struct {
+		name         string
+		startDir     string
+		opts         RootOptions
+		paths        []string
+		wantDir      string
+		wantStrategy string
+	}{
+		{
+			name:         "stray Makefile wins with nearest",
+			startDir:     "/repo/docs",
+			paths:        []string{"/repo/.git", "/repo/go.mod", "/repo/docs/Makefile"},
+			wantDir:      "/repo/docs",
+			wantStrategy: RootStrategyNearest,
+		},
+		{
+			name:         "root file takes precedence by default",
+			startDir:     "/repo/docs",
+			paths:        []string{"/repo/" + RootMarkerFile, "/repo/docs/Makefile"},
+			wantDir:      "/repo",
+			wantStrategy: RootStrategyMarkerFile,
+		},
+		{
+			name:         "outermost vcs root skips nested repositories",
+			startDir:     "/repo/vendor/lib/src",
+			opts:         RootOptions{Strategies: []string{RootStrategyVCS}},
+			paths:        []string{"/repo/.git", "/repo/vendor/lib/.git", "/repo/vendor/lib/package.json"},
+			wantDir:      "/repo",
+			wantStrategy: RootStrategyVCS,
+		},
+		{
+			name:     "project dir contains the file",
+			startDir: "/repo/web/src",
+			opts: RootOptions{
+				Strategies:       []string{RootStrategyProjectDir, RootStrategyNearest},
+				ProjectDir:       "/repo",
+				ProjectDirSource: "CLAUDE_PROJECT_DIR",
+			},
+			paths:        []string{"/repo/web/package.json"},
+			wantDir:      "/repo",
+			wantStrategy: RootStrategyProjectDir,
+		},
+		{
+			name:     "project dir elsewhere falls through",
+			startDir: "/other/src",
+			opts: RootOptions{
+				Strategies: []string{RootStrategyProjectDir, RootStrategyNearest},
+				ProjectDir: "/repo",
+			},
+			paths:        []string{"/other/go.mod"},
+			wantDir:      "/other",
+			wantStrategy: RootStrategyNearest,
+		},
+		{
+			name:         "custom markers",
+			startDir:     "/repo/pkg/a",
+			opts:         RootOptions{Markers: []string{"WORKSPACE"}},
+			paths:        []string{"/repo/pkg/a/Makefile", "/repo/WORKSPACE"},
+			wantDir:      "/repo",
+			wantStrategy: RootStrategyNearest,
+		},
+		{
+			name:     "no match keeps the start directory",
+			startDir: "/tmp/scratch",
+			wantDir:  "/tmp/scratch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := &Dependencies{FS: markerFS(tt.paths...)}
+			root, err := DetectProjectRoot(tt.startDir, tt.opts, deps)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if root.Dir != tt.wantDir || root.Strategy != tt.wantStrategy {
+				t.Errorf("DetectProjectRoot() = %s via %q (%s), want %s via %q",
+					root.Dir, root.Strategy, root.Reason, tt.wantDir, tt.wantStrategy)
+			}
+		})
+	}
+}
+
+func TestEvaluateRootStrategies(t *testing.T) {
+	deps := &Dependencies{FS: markerFS("/repo/.git", "/repo/docs/Makefile")}
+	opts := RootOptions{Strategies: []string{RootStrategyMarkerFile, RootStrategyVCS, RootStrategyNearest, "bogus"}}
+
+	matches, err := EvaluateRootStrategies("/repo/docs", opts, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 4 {
+		t.Fatalf("expected a result per strategy, got %+v", matches)
+	}
+
+	want := []struct {
+		matched bool
+		dir     string
+	}{
+		{false, ""},
+		{true, "/repo"},
+		{true, "/repo/docs"},
+		{false, ""},
+	}
+	for i, w := range want {
+		if matches[i].Matched != w.matched || matches[i].Dir != w.dir {
+			t.Errorf("%s: matched %v in %q, want %v in %q",
+				matches[i].Strategy, matches[i].Matched, matches[i].Dir, w.matched, w.dir)
+		}
+		if matches[i].Reason == "" {
+			t.Errorf("%s: missing reason", matches[i].Strategy)
+		}
+	}
+}